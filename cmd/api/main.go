@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,12 +14,17 @@ import (
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/telemetry"
+	callerMiddleware "github.com/IgorGrieder/encurtador-url/internal/middleware"
 	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
-	redisStorage "github.com/IgorGrieder/encurtador-url/internal/storage/redis"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/stats"
 	"github.com/IgorGrieder/encurtador-url/internal/storage/mongo"
+	redisStorage "github.com/IgorGrieder/encurtador-url/internal/storage/redis"
+	grpcTransport "github.com/IgorGrieder/encurtador-url/internal/transport/grpc"
+	linksv1 "github.com/IgorGrieder/encurtador-url/internal/transport/grpc/gen/linksv1"
 	httpTransport "github.com/IgorGrieder/encurtador-url/internal/transport/http"
 	"github.com/IgorGrieder/encurtador-url/internal/transport/http/middleware"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -28,11 +34,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := logger.Init(cfg.App.Env); err != nil {
+	var shutdownLogs func(context.Context) error
+	logOpts := logger.Options{}
+	if cfg.OTel.LogsEnabled {
+		core, shutdown, err := telemetry.InitLogs(logsEndpoint(cfg.OTel), cfg.App.Name, cfg.App.Version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to initialize OTLP log export, continuing with stdout only: %v\n", err)
+		} else {
+			logOpts.OTelCore = core
+			shutdownLogs = shutdown
+		}
+	}
+
+	if err := logger.InitWithOptions(cfg.App.Env, logOpts); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Sync()
+	if shutdownLogs != nil {
+		defer func() {
+			if err := shutdownLogs(context.Background()); err != nil {
+				logger.Warn("failed to shutdown OTLP log exporter", zap.Error(err))
+			}
+		}()
+	}
 
 	logger.Info("Starting application",
 		zap.String("name", cfg.App.Name),
@@ -65,8 +90,14 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to initialize click stats repository", zap.Error(err))
 	}
-
-	linkSvc := links.NewService(linkRepo, statsRepo, links.NewCryptoSlugger(), cfg.Shortener.SlugLength)
+	outboxRepo, err := mongo.NewClickOutboxRepository(mongoConn)
+	if err != nil {
+		logger.Fatal("Failed to initialize click outbox repository", zap.Error(err))
+	}
+	apiKeyRepo, err := mongo.NewAPIKeyRepository(mongoConn)
+	if err != nil {
+		logger.Fatal("Failed to initialize api key repository", zap.Error(err))
+	}
 
 	redisClient, err := redisStorage.New(redisStorage.Config{
 		Addr:     cfg.Redis.Addr,
@@ -78,9 +109,66 @@ func main() {
 	}
 	defer func() { _ = redisClient.Close() }()
 
-	redisLimiterStore := redisStorage.NewFixedWindowLimiter(redisClient, "rl:create", time.Minute)
-	createLimiter := middleware.NewRedisFixedWindowLimiter(redisLimiterStore, cfg.Security.CreateRate.RequestsPerMinute)
-	router := httpTransport.NewRouter(cfg, linkSvc, createLimiter)
+	slugger, err := redisStorage.NewSlugger(redisClient, redisStorage.SluggerStrategyConfig{
+		Strategy:   cfg.Shortener.Slugger.Strategy,
+		CounterKey: cfg.Shortener.Slugger.CounterKey,
+		SqidsSalt:  cfg.Shortener.Slugger.SqidsSalt,
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize slugger", zap.Error(err))
+	}
+
+	rollupRepo, err := mongo.NewRollupRepository(mongoConn)
+	if err != nil {
+		logger.Fatal("Failed to initialize click rollup repository", zap.Error(err))
+	}
+
+	idempotencyRepo, err := mongo.NewIdempotencyRepository(mongoConn, 24*time.Hour)
+	if err != nil {
+		logger.Fatal("Failed to initialize idempotency repository", zap.Error(err))
+	}
+
+	linkSvc := links.NewServiceWithOptions(linkRepo, statsRepo, outboxRepo, slugger, cfg.Shortener.SlugLength, links.ServiceOptions{
+		RollupReader:          rollupRepo,
+		IdempotencyRepository: idempotencyRepo,
+	})
+
+	// ClickOutboxDrainer supersedes the single-threaded OutboxDispatcher
+	// (internal/storage/mongo/outbox_dispatcher.go) as the thing draining
+	// outboxRepo into stats/click counters: both would otherwise race to
+	// apply the same pending events, since OutboxDispatcher's ListPending
+	// has no claim semantics of its own. OutboxDispatcher stays in the
+	// tree for now for deployments that haven't moved to the batched
+	// drainer yet, but this binary only runs one of the two.
+	outboxDrainer := links.NewClickOutboxDrainer(
+		mongo.NewClickDrainRepository(outboxRepo),
+		mongo.NewClickDrainSink(mongoConn),
+		links.DrainerOptions{
+			BatchSize:    cfg.OutboxDrainer.BatchSize,
+			Workers:      cfg.OutboxDrainer.WorkerCount,
+			PollInterval: cfg.OutboxDrainer.PollInterval,
+			WorkerID:     fmt.Sprintf("%s-drainer", cfg.App.Name),
+		},
+	)
+	drainerCtx, stopDrainer := context.WithCancel(context.Background())
+	go outboxDrainer.Run(drainerCtx)
+
+	rollupLease := mongo.NewLease(mongoConn, "leader:rollup_scheduler", 10*time.Second)
+	rollupScheduler := stats.NewScheduler(statsRepo, rollupRepo, linkRepo, rollupLease, stats.SchedulerOptions{})
+	rollupCtx, stopRollup := context.WithCancel(context.Background())
+	go func() {
+		if err := rollupScheduler.Run(rollupCtx); err != nil {
+			logger.Error("rollup scheduler stopped", zap.Error(err))
+		}
+	}()
+
+	live := config.NewLive(cfg)
+	liveCtx, stopLive := context.WithCancel(context.Background())
+	go live.Run(liveCtx, cfg)
+
+	createLimiter := newCreateLimiter(redisClient, live, cfg.Security.CreateRate.Strategy)
+	apiKeyLimiter := callerMiddleware.NewInMemoryTokenBucketLimiter(cfg.Security.RateLimit.BucketCapacity, cfg.Security.RateLimit.RefillPerSecond)
+	router := httpTransport.NewRouterWithOptions(live, linkSvc, createLimiter, apiKeyRepo, apiKeyLimiter, rollupScheduler, httpTransport.DefaultRouterOptions())
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
@@ -90,6 +178,27 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	grpcAuthInterceptor := grpcTransport.AuthInterceptor(
+		middleware.JWTMiddlewareOptions{
+			JWKSURL:    cfg.Security.JWT.JWKSURL,
+			Algorithms: []string{"RS256", "ES256"},
+		},
+		toGRPCAPIKeys(cfg.Security.APIKeys),
+	)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(grpcAuthInterceptor))
+	linksv1.RegisterLinkServiceServer(grpcServer, grpcTransport.NewLinkServer(linkSvc, cfg.Shortener.BaseURL))
+
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.Server.GRPCPort))
+	if err != nil {
+		logger.Fatal("Failed to listen for gRPC", zap.Error(err))
+	}
+	go func() {
+		logger.Info("gRPC server starting", zap.String("port", cfg.Server.GRPCPort))
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Error("gRPC server error", zap.Error(err))
+		}
+	}()
+
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -100,6 +209,11 @@ func main() {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
+		stopDrainer()
+		stopRollup()
+		stopLive()
+		grpcServer.GracefulStop()
+
 		if shutdownTracer != nil {
 			_ = shutdownTracer(shutdownCtx)
 		}
@@ -121,3 +235,44 @@ func main() {
 
 	logger.Info("Server stopped gracefully")
 }
+
+// toGRPCAPIKeys adapts config.APIKeyConfig to middleware.APIKeyConfig,
+// mirroring the translation router.go does for the REST transport.
+func toGRPCAPIKeys(keys []config.APIKeyConfig) []middleware.APIKeyConfig {
+	out := make([]middleware.APIKeyConfig, len(keys))
+	for i, k := range keys {
+		out[i] = middleware.APIKeyConfig{Key: k.Key, Scopes: k.Scopes}
+	}
+	return out
+}
+
+// logsEndpoint returns the OTLP endpoint telemetry.InitLogs should export
+// to, falling back to the trace endpoint when LogsEndpoint isn't set - most
+// deployments run one collector for both signals.
+func logsEndpoint(cfg config.OTelConfig) string {
+	if cfg.LogsEndpoint != "" {
+		return cfg.LogsEndpoint
+	}
+	return cfg.Endpoint
+}
+
+// newCreateLimiter builds the link-creation endpoint's rate limiter per
+// strategy, both reading their limit from live.Current() on every request so
+// a Config.Watch reload takes effect without rebuilding the limiter. fixed
+// keeps the existing counter-per-window behavior; sliding trades it for the
+// sliding-window-log algorithm, which doesn't let a client burst at the
+// boundary between two fixed windows.
+func newCreateLimiter(redisClient *redisStorage.Client, live *config.Live, strategy string) redisStorage.Limiter {
+	limitFn := func() int64 {
+		return int64(live.Current().Security.CreateRate.RequestsPerMinute)
+	}
+
+	if strategy == "sliding" {
+		return redisStorage.NewSlidingWindowLimiterWithLimitFunc(redisClient, "rl:create", time.Minute, limitFn)
+	}
+
+	redisLimiterStore := redisStorage.NewFixedWindowLimiter(redisClient, "rl:create", time.Minute)
+	return middleware.NewRedisFixedWindowLimiter(redisLimiterStore, func() int {
+		return live.Current().Security.CreateRate.RequestsPerMinute
+	})
+}
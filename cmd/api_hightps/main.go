@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
@@ -15,6 +14,8 @@ import (
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/telemetry"
 	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	kafkaClickSink "github.com/IgorGrieder/encurtador-url/internal/processing/links/clicksink/kafka"
+	natsClickSink "github.com/IgorGrieder/encurtador-url/internal/processing/links/clicksink/nats"
 	mongoStorage "github.com/IgorGrieder/encurtador-url/internal/storage/mongo"
 	redisStorage "github.com/IgorGrieder/encurtador-url/internal/storage/redis"
 	httpTransport "github.com/IgorGrieder/encurtador-url/internal/transport/http"
@@ -29,11 +30,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := logger.Init(cfg.App.Env); err != nil {
+	var shutdownLogs func(context.Context) error
+	logOpts := logger.Options{}
+	if cfg.OTel.LogsEnabled {
+		core, shutdown, err := telemetry.InitLogs(logsEndpoint(cfg.OTel), cfg.App.Name, cfg.App.Version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to initialize OTLP log export, continuing with stdout only: %v\n", err)
+		} else {
+			logOpts.OTelCore = core
+			shutdownLogs = shutdown
+		}
+	}
+
+	if err := logger.InitWithOptions(cfg.App.Env, logOpts); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Sync()
+	if shutdownLogs != nil {
+		defer func() {
+			if err := shutdownLogs(context.Background()); err != nil {
+				logger.Warn("failed to shutdown OTLP log exporter", zap.Error(err))
+			}
+		}()
+	}
 
 	logger.Info("Starting application (high TPS)",
 		zap.String("name", cfg.App.Name),
@@ -73,20 +93,41 @@ func main() {
 	var statsShutdown func(context.Context) error
 	var bufferedStats *mongoStorage.BufferedClickStatsRepository
 	statsRepo := links.StatsRepository(mongoStatsRepo)
-	if getEnvBool("CLICK_BUFFER_ENABLED", true) {
-		buffered := mongoStorage.NewBufferedClickStatsRepository(mongoStatsRepo, mongoStorage.BufferedClickStatsOptions{
-			QueueSize:      getEnvInt("CLICK_BUFFER_QUEUE_SIZE", 1_000_000),
-			FlushInterval:  getEnvDuration("CLICK_BUFFER_FLUSH_INTERVAL", 250*time.Millisecond),
-			MaxBatchEvents: getEnvInt("CLICK_BUFFER_MAX_BATCH_EVENTS", 50_000),
-			FlushTimeout:   getEnvDuration("CLICK_BUFFER_FLUSH_TIMEOUT", 2*time.Second),
+	if config.GetEnvBool("CLICK_BUFFER_ENABLED", true) {
+		var clickSink links.ClickSink
+		switch cfg.ClickStats.Backend {
+		case "kafka":
+			transport, err := cfg.ClickStats.Kafka.Security.Transport()
+			if err != nil {
+				logger.Fatal("Failed to configure click-stats kafka transport", zap.Error(err))
+			}
+			clickSink = kafkaClickSink.NewSinkWithOptions(cfg.ClickStats.Kafka.Brokers, cfg.ClickStats.Kafka.Topic, kafkaClickSink.Options{
+				Compression: cfg.ClickStats.Kafka.Security.CompressionCodec(),
+				Transport:   transport,
+			})
+		case "nats":
+			clickSink, err = natsClickSink.NewSink(cfg.ClickStats.NATS.URL, cfg.ClickStats.NATS.Subject)
+			if err != nil {
+				logger.Fatal("Failed to initialize NATS click sink", zap.Error(err))
+			}
+		default:
+			clickSink = mongoStorage.NewMongoSink(mongoStatsRepo)
+		}
+
+		buffered := mongoStorage.NewBufferedClickStatsRepository(mongoStatsRepo, clickSink, mongoStorage.BufferedClickStatsOptions{
+			QueueSize:          config.GetEnvInt("CLICK_BUFFER_QUEUE_SIZE", 1_000_000),
+			FlushInterval:      config.GetEnvDuration("CLICK_BUFFER_FLUSH_INTERVAL", 250*time.Millisecond),
+			MaxBatchEvents:     config.GetEnvInt("CLICK_BUFFER_MAX_BATCH_EVENTS", 50_000),
+			FlushTimeout:       config.GetEnvDuration("CLICK_BUFFER_FLUSH_TIMEOUT", 2*time.Second),
+			OverflowPolicy:     mongoStorage.OverflowPolicy(config.GetEnv("CLICK_BUFFER_OVERFLOW_POLICY", string(mongoStorage.OverflowDropNewest))),
+			BlockTimeout:       config.GetEnvDuration("CLICK_BUFFER_BLOCK_TIMEOUT", 50*time.Millisecond),
+			SampleLowWatermark: config.GetEnvInt("CLICK_BUFFER_SAMPLE_LOW_WATERMARK", 0),
 		})
 		statsRepo = buffered
 		statsShutdown = buffered.Shutdown
 		bufferedStats = buffered
 	}
 
-	linkSvc := links.NewService(linkRepo, statsRepo, links.NewCryptoSlugger(), cfg.Shortener.SlugLength)
-
 	redisClient, err := redisStorage.New(redisStorage.Config{
 		Addr:     cfg.Redis.Addr,
 		Password: cfg.Redis.Password,
@@ -97,21 +138,50 @@ func main() {
 	}
 	defer func() { _ = redisClient.Close() }()
 
-	redisLimiterStore := redisStorage.NewFixedWindowLimiter(redisClient, "rl:create", time.Minute)
-	createLimiter := middleware.NewRedisFixedWindowLimiter(redisLimiterStore, cfg.Security.CreateRate.RequestsPerMinute)
+	slugger, err := redisStorage.NewSlugger(redisClient, redisStorage.SluggerStrategyConfig{
+		Strategy:   cfg.Shortener.Slugger.Strategy,
+		CounterKey: cfg.Shortener.Slugger.CounterKey,
+		SqidsSalt:  cfg.Shortener.Slugger.SqidsSalt,
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize slugger", zap.Error(err))
+	}
+
+	// This binary favors raw redirect throughput over the transactional
+	// outbox's exactly-once guarantee, so clicks are forwarded straight to
+	// the (already batched/buffered) stats repo instead of going through a
+	// Mongo-polling dispatcher.
+	var hotCache *links.HotCache
+	if config.GetEnvBool("HOT_CACHE_ENABLED", true) {
+		hotCache = links.NewHotCache(
+			config.GetEnvInt("HOT_CACHE_CAPACITY", 10_000),
+			config.GetEnvDuration("HOT_CACHE_TTL", 5*time.Second),
+		)
+	}
+
+	linkSvc := links.NewServiceWithOptions(linkRepo, statsRepo, directClickOutbox{statsRepo}, slugger, cfg.Shortener.SlugLength, links.ServiceOptions{
+		HotCache: hotCache,
+	})
+
+	live := config.NewLive(cfg)
+	liveCtx, stopLive := context.WithCancel(context.Background())
+	defer stopLive()
+	go live.Run(liveCtx, cfg)
+
+	createLimiter := newCreateLimiter(redisClient, live, cfg.Security.CreateRate.Strategy)
 
 	routerOpts := httpTransport.DefaultRouterOptions()
-	routerOpts.EnableCORS = getEnvBool("HTTP_ENABLE_CORS", true)
-	routerOpts.EnableLogging = getEnvBool("HTTP_ENABLE_LOGGING", false)
-	routerOpts.EnableMetrics = getEnvBool("HTTP_ENABLE_METRICS", false)
-	routerOpts.EnableTracing = getEnvBool("HTTP_ENABLE_TRACING", cfg.OTel.Enabled)
+	routerOpts.EnableCORS = config.GetEnvBool("HTTP_ENABLE_CORS", true)
+	routerOpts.EnableLogging = config.GetEnvBool("HTTP_ENABLE_LOGGING", false)
+	routerOpts.EnableMetrics = config.GetEnvBool("HTTP_ENABLE_METRICS", false)
+	routerOpts.EnableTracing = config.GetEnvBool("HTTP_ENABLE_TRACING", cfg.OTel.Enabled)
 	routerOpts.LinksHandlerOptions = httpTransport.LinksHandlerOptions{
-		AsyncClick:   getEnvBool("REDIRECT_ASYNC_CLICK", false),
-		ClickTimeout: getEnvDuration("REDIRECT_CLICK_TIMEOUT", 2*time.Second),
-		FastRedirect: getEnvBool("REDIRECT_FAST", true),
+		AsyncClick:   config.GetEnvBool("REDIRECT_ASYNC_CLICK", false),
+		ClickTimeout: config.GetEnvDuration("REDIRECT_CLICK_TIMEOUT", 2*time.Second),
+		FastRedirect: config.GetEnvBool("REDIRECT_FAST", true),
 	}
 
-	router := httpTransport.NewRouterWithOptions(cfg, linkSvc, createLimiter, routerOpts)
+	router := httpTransport.NewRouterWithOptions(live, linkSvc, createLimiter, nil, nil, nil, routerOpts)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
@@ -167,29 +237,44 @@ func main() {
 	logger.Info("Server stopped gracefully")
 }
 
-func getEnvInt(key string, defaultValue int) int {
-	if raw := os.Getenv(key); raw != "" {
-		if v, err := strconv.Atoi(raw); err == nil {
-			return v
-		}
-	}
-	return defaultValue
+// directClickOutbox adapts a links.StatsRepository to links.ClickOutboxRepository
+// by writing clicks straight through instead of queuing them, since this
+// binary already gets batching from the buffered stats repository.
+type directClickOutbox struct {
+	stats links.StatsRepository
 }
 
-func getEnvBool(key string, defaultValue bool) bool {
-	if raw := os.Getenv(key); raw != "" {
-		if v, err := strconv.ParseBool(raw); err == nil {
-			return v
-		}
+func (d directClickOutbox) EnqueueClick(ctx context.Context, slug string, at time.Time) error {
+	return d.stats.IncDaily(ctx, slug, at)
+}
+
+// logsEndpoint returns the OTLP endpoint telemetry.InitLogs should export
+// to, falling back to the trace endpoint when LogsEndpoint isn't set - most
+// deployments run one collector for both signals.
+func logsEndpoint(cfg config.OTelConfig) string {
+	if cfg.LogsEndpoint != "" {
+		return cfg.LogsEndpoint
 	}
-	return defaultValue
+	return cfg.Endpoint
 }
 
-func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if raw := os.Getenv(key); raw != "" {
-		if v, err := time.ParseDuration(raw); err == nil {
-			return v
-		}
+// newCreateLimiter builds the link-creation endpoint's rate limiter per
+// strategy, both reading their limit from live.Current() on every request so
+// a Config.Watch reload takes effect without rebuilding the limiter. fixed
+// keeps the existing counter-per-window behavior; sliding trades it for the
+// sliding-window-log algorithm, which doesn't let a client burst at the
+// boundary between two fixed windows.
+func newCreateLimiter(redisClient *redisStorage.Client, live *config.Live, strategy string) redisStorage.Limiter {
+	limitFn := func() int64 {
+		return int64(live.Current().Security.CreateRate.RequestsPerMinute)
+	}
+
+	if strategy == "sliding" {
+		return redisStorage.NewSlidingWindowLimiterWithLimitFunc(redisClient, "rl:create", time.Minute, limitFn)
 	}
-	return defaultValue
+
+	redisLimiterStore := redisStorage.NewFixedWindowLimiter(redisClient, "rl:create", time.Minute)
+	return middleware.NewRedisFixedWindowLimiter(redisLimiterStore, func() int {
+		return live.Current().Security.CreateRate.RequestsPerMinute
+	})
 }
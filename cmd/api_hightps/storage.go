@@ -8,16 +8,37 @@ import (
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
 	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	mongoStorage "github.com/IgorGrieder/encurtador-url/internal/storage/mongo"
 	postgresStorage "github.com/IgorGrieder/encurtador-url/internal/storage/postgres"
 	"go.uber.org/zap"
 )
 
+// initStorage wires the repository set for cfg.Storage.Backend ("postgres"
+// or "mongo"), so this binary can run against either without the rest of
+// main.go knowing which one it got.
 func initStorage(cfg *config.Config) (
 	links.LinkRepository,
 	links.StatsRepository,
 	links.ClickOutboxRepository,
 	func(),
 	error,
+) {
+	switch cfg.Storage.Backend {
+	case "postgres":
+		return initPostgresStorage(cfg)
+	case "mongo":
+		return initMongoStorage(cfg)
+	default:
+		return nil, nil, nil, nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
+
+func initPostgresStorage(cfg *config.Config) (
+	links.LinkRepository,
+	links.StatsRepository,
+	links.ClickOutboxRepository,
+	func(),
+	error,
 ) {
 	pgConn, err := db.ConnectPostgres(context.Background(), cfg.Postgres.DSN())
 	if err != nil {
@@ -43,3 +64,35 @@ func initStorage(cfg *config.Config) (
 	logger.Info("Storage backend selected", zap.String("backend", "postgres"))
 	return linkRepo, statsRepo, outboxRepo, pgConn.Close, nil
 }
+
+func initMongoStorage(cfg *config.Config) (
+	links.LinkRepository,
+	links.StatsRepository,
+	links.ClickOutboxRepository,
+	func(),
+	error,
+) {
+	mongoConn, err := db.ConnectMongo(cfg.MongoDB.URI, cfg.MongoDB.Database)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("connect mongo: %w", err)
+	}
+
+	linkRepo, err := mongoStorage.NewLinksRepository(mongoConn)
+	if err != nil {
+		_ = mongoConn.Disconnect()
+		return nil, nil, nil, nil, fmt.Errorf("init mongo links repository: %w", err)
+	}
+	statsRepo, err := mongoStorage.NewClickStatsRepository(mongoConn)
+	if err != nil {
+		_ = mongoConn.Disconnect()
+		return nil, nil, nil, nil, fmt.Errorf("init mongo stats repository: %w", err)
+	}
+	outboxRepo, err := mongoStorage.NewClickOutboxRepository(mongoConn)
+	if err != nil {
+		_ = mongoConn.Disconnect()
+		return nil, nil, nil, nil, fmt.Errorf("init mongo outbox repository: %w", err)
+	}
+
+	logger.Info("Storage backend selected", zap.String("backend", "mongo"))
+	return linkRepo, statsRepo, outboxRepo, func() { _ = mongoConn.Disconnect() }, nil
+}
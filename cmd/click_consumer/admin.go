@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// adminServer exposes the one operator action click_consumer needs outside
+// its normal consume loop - replaying a range of dead-lettered events back
+// onto the main topic - so there's no reason to pull in
+// internal/transport/http's full router for a single endpoint.
+type adminServer struct {
+	server    *http.Server
+	dlq       *dlqForwarder
+	brokers   []string
+	destTopic string
+}
+
+func newAdminServer(addr string, dlq *dlqForwarder, brokers []string, destTopic string) *adminServer {
+	a := &adminServer{dlq: dlq, brokers: brokers, destTopic: destTopic}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /admin/dlq/replay", a.handleReplay)
+	a.server = &http.Server{Addr: addr, Handler: mux}
+	return a
+}
+
+func (a *adminServer) Run() error {
+	return a.server.ListenAndServe()
+}
+
+func (a *adminServer) Shutdown(ctx context.Context) error {
+	return a.server.Shutdown(ctx)
+}
+
+type replayResponse struct {
+	Replayed int `json:"replayed"`
+}
+
+// handleReplay replays DLQ offsets [from, to] (both inclusive, from the
+// ?from=&to= query params) back onto the main click topic.
+func (a *adminServer) handleReplay(w http.ResponseWriter, r *http.Request) {
+	from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		http.Error(w, "from must be a valid offset", http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	if err != nil {
+		http.Error(w, "to must be a valid offset", http.StatusBadRequest)
+		return
+	}
+	if to < from {
+		http.Error(w, "to must be >= from", http.StatusBadRequest)
+		return
+	}
+
+	replayed, err := a.dlq.replay(r.Context(), a.brokers, a.destTopic, from, to)
+	if err != nil {
+		logger.Error("dlq replay failed", zap.Error(err), zap.Int64("from", from), zap.Int64("to", to))
+		http.Error(w, "replay failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(replayResponse{Replayed: replayed}); err != nil {
+		logger.Warn("failed to write dlq replay response", zap.Error(err))
+	}
+}
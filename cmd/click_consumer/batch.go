@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/events"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"github.com/IgorGrieder/encurtador-url/internal/messaging/consumer"
+	mongoStorage "github.com/IgorGrieder/encurtador-url/internal/storage/mongo"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// errClickBatchPartialFailure is the error routed to a group's messages when
+// a flush's BulkIncrement call succeeds overall but rejects that particular
+// group - i.e. there's no single underlying error to attach, since the
+// failure came back as an index into the batch rather than a returned error.
+var errClickBatchPartialFailure = errors.New("click batch: bulk write rejected this group")
+
+// addOutcome reports what clickBatcher.add did with a message, so
+// clickTopicConsumer.handleAndCommit knows whether it still owns
+// routing/committing the message (addMalformed, addSkipped) or whether the
+// batcher now owns it until its group's next flush (addBuffered).
+type addOutcome int
+
+const (
+	addBuffered addOutcome = iota
+	addMalformed
+	addSkipped
+)
+
+// clickGroupKey identifies one (slug, UTC day) bucket inside a batch,
+// matching the granularity clickDailyDoc stores clicks at.
+type clickGroupKey struct {
+	slug string
+	date string
+}
+
+// batchedMessage is one buffered click event's raw Kafka message plus enough
+// of its origin to commit or route it once its group's batch flushes.
+type batchedMessage struct {
+	source *clickTopicConsumer
+	msg    kafka.Message
+}
+
+type pendingGroup struct {
+	count    int64
+	messages []batchedMessage
+}
+
+// clickBatcher buffers decoded click events across every clickTopicConsumer,
+// grouped by (slug, day), and applies them with one
+// mongoStorage.ClickBatchRepository.BulkIncrement call per flush instead of
+// the two Mongo round trips per click clickHandler.handle used to make.
+// A flush triggers once maxEvents have accumulated or maxWait has elapsed
+// since the first event of the current batch, whichever comes first.
+// Kafka offsets are committed only after a message's group has flushed;
+// groups a flush fails to apply are routed through their originating
+// clickTopicConsumer's normal retry/DLQ path instead of being dropped.
+type clickBatcher struct {
+	repo      *mongoStorage.ClickBatchRepository
+	maxEvents int
+	maxWait   time.Duration
+	opTimeout time.Duration
+
+	mu     sync.Mutex
+	groups map[clickGroupKey]*pendingGroup
+	count  int
+	timer  *time.Timer
+}
+
+func newClickBatcher(repo *mongoStorage.ClickBatchRepository, maxEvents int, maxWait, opTimeout time.Duration) *clickBatcher {
+	if maxEvents <= 0 {
+		maxEvents = 500
+	}
+	if maxWait <= 0 {
+		maxWait = 250 * time.Millisecond
+	}
+	if opTimeout <= 0 {
+		opTimeout = 5 * time.Second
+	}
+	return &clickBatcher{
+		repo:      repo,
+		maxEvents: maxEvents,
+		maxWait:   maxWait,
+		opTimeout: opTimeout,
+		groups:    make(map[clickGroupKey]*pendingGroup),
+	}
+}
+
+// add decodes msg and, if it's well-formed and carries a slug, buffers it -
+// flushing immediately if that fills the batch. The returned addOutcome
+// tells the caller whether it still needs to route/commit msg itself.
+func (b *clickBatcher) add(source *clickTopicConsumer, msg kafka.Message) (addOutcome, error) {
+	var event events.ClickRecorded
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		logger.Warn("invalid click event payload, forwarding to dead-letter topic",
+			zap.Error(err),
+			zap.ByteString("payload", msg.Value),
+		)
+		return addMalformed, err
+	}
+	if strings.TrimSpace(event.Slug) == "" {
+		logger.Warn("click event missing slug, skipping", zap.String("event_id", event.EventID))
+		return addSkipped, nil
+	}
+
+	occurredAt := msg.Time.UTC()
+	if strings.TrimSpace(event.OccurredAt) != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, event.OccurredAt)
+		if err != nil {
+			logger.Warn("invalid event occurredAt, using kafka timestamp",
+				zap.Error(err),
+				zap.String("event_id", event.EventID),
+			)
+		} else {
+			occurredAt = parsed.UTC()
+		}
+	}
+
+	key := clickGroupKey{slug: event.Slug, date: occurredAt.Format(time.DateOnly)}
+
+	b.mu.Lock()
+	due := b.appendLocked(key, batchedMessage{source: source, msg: msg})
+	b.mu.Unlock()
+
+	if due != nil {
+		b.flush(due)
+	}
+
+	return addBuffered, nil
+}
+
+// appendLocked must be called with b.mu held. It buffers msg under key,
+// starting the wait timer if this is the first event of a new batch, and
+// returns the accumulated groups (resetting the batcher) if that reaches
+// maxEvents - nil otherwise.
+func (b *clickBatcher) appendLocked(key clickGroupKey, bm batchedMessage) map[clickGroupKey]*pendingGroup {
+	g, ok := b.groups[key]
+	if !ok {
+		g = &pendingGroup{}
+		b.groups[key] = g
+	}
+	g.count++
+	g.messages = append(g.messages, bm)
+	b.count++
+
+	if b.count == 1 {
+		b.timer = time.AfterFunc(b.maxWait, b.flushDue)
+	}
+	if b.count >= b.maxEvents {
+		return b.takeLocked()
+	}
+	return nil
+}
+
+// flushDue is the wait timer's callback, firing a flush of whatever has
+// accumulated since the timer started even if maxEvents was never reached.
+func (b *clickBatcher) flushDue() {
+	b.mu.Lock()
+	due := b.takeLocked()
+	b.mu.Unlock()
+	if due != nil {
+		b.flush(due)
+	}
+}
+
+// takeLocked must be called with b.mu held. It stops the wait timer (there's
+// nothing left to wait on) and returns the current batch, leaving the
+// batcher empty for the next one.
+func (b *clickBatcher) takeLocked() map[clickGroupKey]*pendingGroup {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.groups) == 0 {
+		return nil
+	}
+	groups := b.groups
+	b.groups = make(map[clickGroupKey]*pendingGroup)
+	b.count = 0
+	return groups
+}
+
+// flush applies groups in one BulkIncrement call and routes every
+// contributing message to commit or retry/DLQ depending on whether its group
+// succeeded.
+func (b *clickBatcher) flush(groups map[clickGroupKey]*pendingGroup) {
+	start := time.Now()
+
+	clickGroups := make([]mongoStorage.ClickGroup, 0, len(groups))
+	for key, g := range groups {
+		clickGroups = append(clickGroups, mongoStorage.ClickGroup{Slug: key.slug, Date: key.date, Count: g.count})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.opTimeout)
+	defer cancel()
+	failed, err := b.repo.BulkIncrement(ctx, clickGroups)
+
+	batchSize.Observe(float64(len(clickGroups)))
+	batchFlushDuration.Observe(time.Since(start).Seconds())
+
+	failedKeys := make(map[clickGroupKey]bool, len(failed))
+	if err != nil {
+		logger.FromContext(ctx).Error("click batch flush failed, routing every event in the batch to its retry/DLQ path",
+			zap.Error(err),
+			zap.Int("groups", len(clickGroups)),
+		)
+		for key := range groups {
+			failedKeys[key] = true
+		}
+	} else {
+		for _, g := range failed {
+			failedKeys[clickGroupKey{slug: g.Slug, date: g.Date}] = true
+		}
+	}
+
+	for key, g := range groups {
+		groupErr := err
+		if groupErr == nil && failedKeys[key] {
+			groupErr = errClickBatchPartialFailure
+		}
+
+		for _, bm := range g.messages {
+			if failedKeys[key] {
+				bm.source.routeFailure(toConsumerMessage(bm.msg), false, groupErr)
+			}
+			bm.source.commit(bm.msg)
+		}
+	}
+}
+
+// toConsumerMessage adapts a kafka.Message to the transport-agnostic
+// consumer.Message the retry/DLQ machinery works with.
+func toConsumerMessage(msg kafka.Message) consumer.Message {
+	return consumer.Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   msg.Headers,
+		Time:      msg.Time,
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	kafkainfra "github.com/IgorGrieder/encurtador-url/internal/infrastructure/kafka"
+	"github.com/IgorGrieder/encurtador-url/internal/messaging/consumer"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	dlqStackHeader           = "x-dlq-stack"
+	dlqFirstSeenHeader       = "x-dlq-first-seen-at"
+	dlqSourceTopicHeader     = "x-dlq-source-topic"
+	dlqSourcePartitionHeader = "x-dlq-source-partition"
+	dlqSourceOffsetHeader    = "x-dlq-source-offset"
+)
+
+// dlqForwarder sends a click event's raw bytes to clicks.recorded.dlq once
+// retryRouter's budget is exhausted (or the payload was never valid JSON
+// to begin with), carrying enough diagnostics - original headers, the
+// error, a stack trace, when the event was first seen, and its source
+// topic/partition/offset - to triage it, and to replay it, without needing
+// to reproduce the failure from logs alone.
+type dlqForwarder struct {
+	writer   *kafka.Writer
+	topic    string
+	security kafkainfra.Config
+}
+
+func newDLQForwarder(brokers []string, topic string, security kafkainfra.Config) (*dlqForwarder, error) {
+	transport, err := security.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &dlqForwarder{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			Compression:            security.CompressionCodec(),
+			Transport:              transport,
+			AllowAutoTopicCreation: true,
+		},
+		topic:    topic,
+		security: security,
+	}, nil
+}
+
+func (d *dlqForwarder) forward(ctx context.Context, msg consumer.Message, cause error, stack string) error {
+	headers := stampHeader(msg.Headers, consumer.DLQHeader, cause.Error())
+	headers = stampHeader(headers, dlqStackHeader, stack)
+	headers = stampHeader(headers, dlqFirstSeenHeader, firstSeenAt(msg.Headers).Format(time.RFC3339Nano))
+	headers = stampHeader(headers, dlqSourceTopicHeader, msg.Topic)
+	headers = stampHeader(headers, dlqSourcePartitionHeader, strconv.Itoa(msg.Partition))
+	headers = stampHeader(headers, dlqSourceOffsetHeader, strconv.FormatInt(msg.Offset, 10))
+
+	return d.writer.WriteMessages(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+		Time:    time.Now(),
+	})
+}
+
+func (d *dlqForwarder) Close() error {
+	return d.writer.Close()
+}
+
+// replay re-reads messages from offset from (inclusive) through until
+// (inclusive) on the DLQ topic's partition 0 and republishes each one's
+// original payload onto destTopic, stripping the x-dlq-* diagnostic
+// headers it was forwarded with. It's meant for an operator's occasional
+// "the downstream outage is over, replay what piled up" sweep, not a hot
+// path, so reading one partition at a time with a plain (non-group) reader
+// is enough.
+func (d *dlqForwarder) replay(ctx context.Context, brokers []string, destTopic string, from, until int64) (int, error) {
+	dialer, err := d.security.Dialer()
+	if err != nil {
+		return 0, err
+	}
+	transport, err := d.security.Transport()
+	if err != nil {
+		return 0, err
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   brokers,
+		Topic:     d.topic,
+		Partition: 0,
+		MinBytes:  1,
+		MaxBytes:  10e6,
+		Dialer:    dialer,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(from); err != nil {
+		return 0, fmt.Errorf("seeking dlq reader to offset %d: %w", from, err)
+	}
+
+	out := &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Topic:                  destTopic,
+		Balancer:               &kafka.Hash{},
+		Compression:            d.security.CompressionCodec(),
+		Transport:              transport,
+		AllowAutoTopicCreation: true,
+	}
+	defer out.Close()
+
+	replayed := 0
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return replayed, err
+		}
+		if msg.Offset > until {
+			return replayed, nil
+		}
+
+		if err := out.WriteMessages(ctx, kafka.Message{
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: stripDLQHeaders(msg.Headers),
+		}); err != nil {
+			return replayed, fmt.Errorf("replaying dlq offset %d: %w", msg.Offset, err)
+		}
+		replayed++
+	}
+}
+
+func stripDLQHeaders(headers []kafka.Header) []kafka.Header {
+	drop := map[string]bool{
+		consumer.DLQHeader:       true,
+		dlqStackHeader:           true,
+		dlqFirstSeenHeader:       true,
+		dlqSourceTopicHeader:     true,
+		dlqSourcePartitionHeader: true,
+		dlqSourceOffsetHeader:    true,
+	}
+	out := make([]kafka.Header, 0, len(headers))
+	for _, h := range headers {
+		if drop[h.Key] {
+			continue
+		}
+		out = append(out, h)
+	}
+	return out
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	kafkainfra "github.com/IgorGrieder/encurtador-url/internal/infrastructure/kafka"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"github.com/IgorGrieder/encurtador-url/internal/messaging/consumer"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// clickTopicConsumer reads one topic - clicks.recorded itself, or one of
+// its retry.<N> escalations - and drives each message through batcher.
+//
+// A single fetch loop dispatches messages onto one of workers channels,
+// keyed by partition % workers, so messages from the same partition are
+// always handled by the same goroutine in fetch order (preserving
+// per-slug ordering) while different partitions process concurrently.
+// Because Kafka only allows committing a contiguous prefix of offsets, a
+// partitionTracker records each partition's in-flight offsets in a
+// min-heap and only commits once the lowest in-flight offset completes -
+// the same store-vs-commit split librdkafka uses internally - instead of
+// committing the single most recently processed offset and risking an
+// acknowledged gap if an earlier one is still in flight. Since batcher
+// defers commit until a message's group flushes, offsets can stay in
+// flight considerably longer than clickHandler's old per-message handling
+// did; maxInFlight is what keeps that from growing unbounded.
+type clickTopicConsumer struct {
+	reader       *kafka.Reader
+	topic        string
+	retryAttempt int
+	batcher      *clickBatcher
+	retryRouter  *retryRouter
+	dlq          *dlqForwarder
+
+	workers     int
+	maxInFlight int
+
+	partitionsMu sync.Mutex
+	partitions   map[int]*partitionTracker
+
+	wg sync.WaitGroup
+}
+
+func newClickTopicConsumer(brokers []string, topic, groupID string, maxWait time.Duration, retryAttempt, workers, maxInFlight int, security kafkainfra.Config, batcher *clickBatcher, router *retryRouter, dlq *dlqForwarder) (*clickTopicConsumer, error) {
+	dialer, err := security.Dialer()
+	if err != nil {
+		return nil, err
+	}
+	return &clickTopicConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     brokers,
+			Topic:       topic,
+			GroupID:     groupID,
+			MinBytes:    1,
+			MaxBytes:    10e6,
+			MaxWait:     maxWait,
+			StartOffset: kafka.FirstOffset,
+			Dialer:      dialer,
+		}),
+		topic:        topic,
+		retryAttempt: retryAttempt,
+		batcher:      batcher,
+		retryRouter:  router,
+		dlq:          dlq,
+		workers:      workers,
+		maxInFlight:  maxInFlight,
+		partitions:   make(map[int]*partitionTracker),
+	}, nil
+}
+
+// run dispatches messages to its worker pool until ctx is canceled or the
+// reader returns an unrecoverable error. On cancellation it stops
+// fetching immediately but lets already-dispatched messages drain through
+// their workers (and commit) before returning, so a SIGTERM never leaves
+// a message acknowledged-but-unprocessed or vice versa.
+func (c *clickTopicConsumer) run(ctx context.Context) error {
+	chans := make([]chan kafka.Message, c.workers)
+	for i := range chans {
+		chans[i] = make(chan kafka.Message, c.maxInFlight)
+		c.wg.Add(1)
+		go c.worker(chans[i])
+	}
+
+	fetchErr := c.fetchLoop(ctx, chans)
+
+	for _, ch := range chans {
+		close(ch)
+	}
+	c.wg.Wait()
+
+	return fetchErr
+}
+
+func (c *clickTopicConsumer) fetchLoop(ctx context.Context, chans []chan kafka.Message) error {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if c.retryAttempt > 0 {
+			select {
+			case <-time.After(c.retryRouter.delayFor(c.retryAttempt)):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		tracker := c.trackerFor(msg.Partition)
+		if err := tracker.acquire(ctx, msg.Offset); err != nil {
+			return nil
+		}
+
+		select {
+		case chans[msg.Partition%c.workers] <- msg:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (c *clickTopicConsumer) trackerFor(partition int) *partitionTracker {
+	c.partitionsMu.Lock()
+	defer c.partitionsMu.Unlock()
+	t, ok := c.partitions[partition]
+	if !ok {
+		t = newPartitionTracker(c.maxInFlight)
+		c.partitions[partition] = t
+	}
+	return t
+}
+
+// worker drains one dispatch channel in order, so every message routed to
+// it - regardless of which partition it came from - is fully handled,
+// committed (if it completes its partition's contiguous prefix), before
+// the next one starts.
+func (c *clickTopicConsumer) worker(ch <-chan kafka.Message) {
+	defer c.wg.Done()
+	for msg := range ch {
+		c.handleAndCommit(msg)
+	}
+}
+
+// handleAndCommit hands msg to the shared batcher. A malformed or
+// slug-less message is routed/committed immediately, same as before;
+// anything else is buffered and its commit deferred until its (slug, day)
+// group's batch flushes - see clickBatcher for why that's safe with
+// respect to partitionTracker's in-flight accounting.
+func (c *clickTopicConsumer) handleAndCommit(msg kafka.Message) {
+	outcome, err := c.batcher.add(c, msg)
+	switch outcome {
+	case addMalformed:
+		c.forwardToDLQ(toConsumerMessage(msg), err)
+		c.commit(msg)
+	case addSkipped:
+		c.commit(msg)
+	case addBuffered:
+		// Routed/committed later, once this message's group flushes.
+	}
+}
+
+func (c *clickTopicConsumer) routeFailure(msg consumer.Message, malformed bool, err error) {
+	if malformed {
+		c.forwardToDLQ(msg, err)
+		return
+	}
+
+	attempt := retryCount(msg.Headers) + 1
+	if attempt > c.retryRouter.maxRetries {
+		c.forwardToDLQ(msg, err)
+		return
+	}
+
+	if pubErr := c.retryRouter.publish(context.Background(), msg, attempt); pubErr != nil {
+		logger.FromContext(context.Background()).Error("failed to publish click event to retry topic, forwarding to dead-letter topic instead",
+			zap.Error(pubErr),
+			zap.Int("retry_attempt", attempt),
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+		)
+		c.forwardToDLQ(msg, err)
+	}
+}
+
+func (c *clickTopicConsumer) forwardToDLQ(msg consumer.Message, cause error) {
+	if err := c.dlq.forward(context.Background(), msg, cause, string(debug.Stack())); err != nil {
+		logger.FromContext(context.Background()).Error("failed to forward click event to dead-letter topic",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+		)
+	}
+}
+
+// commit marks msg's offset complete in its partition's tracker and, if
+// that advances the highest contiguous completed offset, commits through
+// it.
+func (c *clickTopicConsumer) commit(msg kafka.Message) {
+	tracker := c.trackerFor(msg.Partition)
+	commitThrough, ok := tracker.complete(msg.Offset)
+	if !ok {
+		return
+	}
+
+	if err := c.reader.CommitMessages(context.Background(), kafka.Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    commitThrough,
+	}); err != nil {
+		logger.Error("failed to commit kafka offset",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", commitThrough),
+		)
+	}
+}
+
+func (c *clickTopicConsumer) Close() error {
+	return c.reader.Close()
+}
+
+// partitionTracker bounds how many of one partition's messages may be in
+// flight at once (sem) and records which of them have finished (done),
+// using offsetHeap to find the highest offset that can be committed
+// without skipping over one still in progress.
+type partitionTracker struct {
+	sem chan struct{}
+
+	mu       sync.Mutex
+	inFlight offsetHeap
+	done     map[int64]bool
+}
+
+func newPartitionTracker(maxInFlight int) *partitionTracker {
+	return &partitionTracker{
+		sem:  make(chan struct{}, maxInFlight),
+		done: make(map[int64]bool),
+	}
+}
+
+// acquire blocks until the partition has room for another in-flight
+// offset (or ctx is canceled), then records offset as in flight.
+func (t *partitionTracker) acquire(ctx context.Context, offset int64) error {
+	select {
+	case t.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	t.mu.Lock()
+	heap.Push(&t.inFlight, offset)
+	t.mu.Unlock()
+	return nil
+}
+
+// complete marks offset as finished processing and, if it is (or has
+// become) the lowest in-flight offset, pops it and every other
+// now-contiguous completed offset off the heap, reporting the highest one
+// as safe to commit. ok is false when offset isn't yet the lowest
+// in-flight one - an earlier offset on the same partition is still being
+// processed, so nothing can be committed yet.
+func (t *partitionTracker) complete(offset int64) (commitThrough int64, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done[offset] = true
+	<-t.sem
+
+	commitThrough = -1
+	for t.inFlight.Len() > 0 && t.done[t.inFlight[0]] {
+		o := heap.Pop(&t.inFlight).(int64)
+		delete(t.done, o)
+		commitThrough = o
+	}
+	if commitThrough < 0 {
+		return 0, false
+	}
+	return commitThrough, true
+}
+
+// offsetHeap is a container/heap min-heap of Kafka offsets.
+type offsetHeap []int64
+
+func (h offsetHeap) Len() int           { return len(h) }
+func (h offsetHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h offsetHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *offsetHeap) Push(x any)        { *h = append(*h, x.(int64)) }
+func (h *offsetHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -2,31 +2,25 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/IgorGrieder/encurtador-url/internal/events"
+	"github.com/IgorGrieder/encurtador-url/internal/config"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	kafkainfra "github.com/IgorGrieder/encurtador-url/internal/infrastructure/kafka"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/telemetry"
-	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
 	mongoStorage "github.com/IgorGrieder/encurtador-url/internal/storage/mongo"
-	"github.com/segmentio/kafka-go"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-type config struct {
+type appConfig struct {
 	appEnv        string
 	appName       string
 	appVersion    string
@@ -34,13 +28,38 @@ type config struct {
 	mongoURI      string
 	mongoDatabase string
 
-	kafkaBrokers []string
-	kafkaTopic   string
-	kafkaGroupID string
+	otelLogsEnabled  bool
+	otelLogsEndpoint string
 
-	fetchMaxWait   time.Duration
-	operationTTL   time.Duration
-	consumeBackoff time.Duration
+	kafkaBrokers  []string
+	kafkaTopic    string
+	kafkaGroupID  string
+	kafkaDLQTopic string
+	maxRetries    int
+
+	// kafkaSecurity configures compression/TLS/SASL for every reader and
+	// writer this binary builds, so a hosted cluster is reached the same
+	// way a local plaintext broker is - see kafkainfra.Config.
+	kafkaSecurity kafkainfra.Config
+
+	fetchMaxWait time.Duration
+	operationTTL time.Duration
+	retryBase    time.Duration
+	retryMax     time.Duration
+
+	// consumerWorkers and inFlightPerPartition size each
+	// clickTopicConsumer's per-partition worker pool - see its doc comment
+	// for why throughput no longer has to wait on one Mongo round trip at a
+	// time.
+	consumerWorkers      int
+	inFlightPerPartition int
+
+	// batchMax and batchMaxWait bound the shared clickBatcher's flush
+	// trigger - see its doc comment.
+	batchMax     int
+	batchMaxWait time.Duration
+
+	adminAddr string
 }
 
 func main() {
@@ -50,11 +69,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := logger.Init(cfg.appEnv); err != nil {
+	var shutdownLogs func(context.Context) error
+	logOpts := logger.Options{}
+	if cfg.otelLogsEnabled {
+		core, shutdown, err := telemetry.InitLogs(cfg.otelLogsEndpoint, fmt.Sprintf("%s-click-consumer", cfg.appName), cfg.appVersion)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to initialize OTLP log export, continuing with stdout only: %v\n", err)
+		} else {
+			logOpts.OTelCore = core
+			shutdownLogs = shutdown
+		}
+	}
+
+	if err := logger.InitWithOptions(cfg.appEnv, logOpts); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Sync()
+	if shutdownLogs != nil {
+		defer func() {
+			if err := shutdownLogs(context.Background()); err != nil {
+				logger.Warn("failed to shutdown OTLP log exporter", zap.Error(err))
+			}
+		}()
+	}
 
 	shutdownTracer, err := telemetry.InitTracer(
 		cfg.otelEndpoint,
@@ -79,36 +117,75 @@ func main() {
 		}
 	}()
 
+	if err := kafkainfra.Probe(context.Background(), cfg.kafkaBrokers, cfg.kafkaSecurity); err != nil {
+		logger.Fatal("failed to reach kafka", zap.Error(err))
+	}
+
 	mongoConn, err := db.ConnectMongo(cfg.mongoURI, cfg.mongoDatabase)
 	if err != nil {
 		logger.Fatal("failed to connect to MongoDB", zap.Error(err))
 	}
 	defer func() { _ = mongoConn.Disconnect() }()
 
-	linkRepo, err := mongoStorage.NewLinksRepository(mongoConn)
+	batchRepo := mongoStorage.NewClickBatchRepository(mongoConn)
+	batcher := newClickBatcher(batchRepo, cfg.batchMax, cfg.batchMaxWait, cfg.operationTTL)
+
+	router, err := newRetryRouter(cfg.kafkaBrokers, cfg.kafkaTopic, cfg.maxRetries, cfg.retryBase, cfg.retryMax, cfg.kafkaSecurity)
 	if err != nil {
-		logger.Fatal("failed to initialize links repository", zap.Error(err))
+		logger.Fatal("failed to configure retry router", zap.Error(err))
 	}
-	statsRepo, err := mongoStorage.NewClickStatsRepository(mongoConn)
+	defer func() {
+		if err := router.Close(); err != nil {
+			logger.Warn("failed to close retry router", zap.Error(err))
+		}
+	}()
+
+	dlq, err := newDLQForwarder(cfg.kafkaBrokers, cfg.kafkaDLQTopic, cfg.kafkaSecurity)
 	if err != nil {
-		logger.Fatal("failed to initialize stats repository", zap.Error(err))
+		logger.Fatal("failed to configure dead-letter forwarder", zap.Error(err))
 	}
+	defer func() {
+		if err := dlq.Close(); err != nil {
+			logger.Warn("failed to close dead-letter forwarder", zap.Error(err))
+		}
+	}()
 
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     cfg.kafkaBrokers,
-		Topic:       cfg.kafkaTopic,
-		GroupID:     cfg.kafkaGroupID,
-		MinBytes:    1,
-		MaxBytes:    10e6,
-		MaxWait:     cfg.fetchMaxWait,
-		StartOffset: kafka.FirstOffset,
-	})
+	// One clickTopicConsumer per escalation level: the main topic plus one
+	// clicks.recorded.retry.<N> topic per configured retry attempt. See
+	// clickTopicConsumer's doc comment for why this replaces
+	// consumer.Consumer's single-topic, in-process retry loop here.
+	topics := make([]*clickTopicConsumer, 0, cfg.maxRetries+1)
+	mainTopicConsumer, err := newClickTopicConsumer(cfg.kafkaBrokers, cfg.kafkaTopic, cfg.kafkaGroupID, cfg.fetchMaxWait, 0, cfg.consumerWorkers, cfg.inFlightPerPartition, cfg.kafkaSecurity, batcher, router, dlq)
+	if err != nil {
+		logger.Fatal("failed to configure kafka reader", zap.Error(err), zap.String("topic", cfg.kafkaTopic))
+	}
+	topics = append(topics, mainTopicConsumer)
+	for n := 1; n <= cfg.maxRetries; n++ {
+		retryTopic := retryTopicName(cfg.kafkaTopic, n)
+		retryTopicConsumer, err := newClickTopicConsumer(cfg.kafkaBrokers, retryTopic, cfg.kafkaGroupID, cfg.fetchMaxWait, n, cfg.consumerWorkers, cfg.inFlightPerPartition, cfg.kafkaSecurity, batcher, router, dlq)
+		if err != nil {
+			logger.Fatal("failed to configure kafka reader", zap.Error(err), zap.String("topic", retryTopic))
+		}
+		topics = append(topics, retryTopicConsumer)
+	}
 	defer func() {
-		if err := reader.Close(); err != nil {
-			logger.Warn("failed to close kafka reader", zap.Error(err))
+		for _, t := range topics {
+			if err := t.Close(); err != nil {
+				logger.Warn("failed to close kafka reader", zap.Error(err), zap.String("topic", t.topic))
+			}
 		}
 	}()
 
+	var admin *adminServer
+	if cfg.adminAddr != "" {
+		admin = newAdminServer(cfg.adminAddr, dlq, cfg.kafkaBrokers, cfg.kafkaTopic)
+		go func() {
+			if err := admin.Run(); err != nil && err != http.ErrServerClosed {
+				logger.Error("admin server stopped with error", zap.Error(err))
+			}
+		}()
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -116,194 +193,128 @@ func main() {
 		zap.Strings("kafka_brokers", cfg.kafkaBrokers),
 		zap.String("kafka_topic", cfg.kafkaTopic),
 		zap.String("kafka_group", cfg.kafkaGroupID),
+		zap.String("kafka_dlq_topic", cfg.kafkaDLQTopic),
+		zap.Int("max_retries", cfg.maxRetries),
+		zap.Int("consumer_workers", cfg.consumerWorkers),
+		zap.Int("inflight_per_partition", cfg.inFlightPerPartition),
+		zap.String("admin_addr", cfg.adminAddr),
 	)
 
-	tracer := otel.Tracer("click-consumer")
-	for {
-		msg, err := reader.FetchMessage(ctx)
-		if err != nil {
-			if errors.Is(err, context.Canceled) {
-				logger.Info("click consumer stopping")
-				return
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(topics))
+	for _, t := range topics {
+		wg.Add(1)
+		go func(t *clickTopicConsumer) {
+			defer wg.Done()
+			if err := t.run(ctx); err != nil {
+				errCh <- fmt.Errorf("%s: %w", t.topic, err)
 			}
-			logger.Error("failed to fetch kafka message", zap.Error(err))
-			time.Sleep(cfg.consumeBackoff)
-			continue
+		}(t)
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		logger.Error("click topic consumer stopped with error", zap.Error(err))
+	}
+
+	if admin != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := admin.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("admin server shutdown error", zap.Error(err))
 		}
+	}
 
-		consumeCtx := contextFromKafkaHeaders(ctx, msg.Headers)
-		consumeCtx, span := tracer.Start(
-			consumeCtx,
-			"kafka.consume.click_recorded",
-			trace.WithSpanKind(trace.SpanKindConsumer),
-			trace.WithAttributes(
-				attribute.String("messaging.system", "kafka"),
-				attribute.String("messaging.destination.name", msg.Topic),
-				attribute.String("messaging.operation", "process"),
-				attribute.Int("messaging.kafka.partition", msg.Partition),
-				attribute.Int64("messaging.kafka.offset", msg.Offset),
-			),
-		)
+	logger.Info("click consumer stopping")
+}
 
-		if err := processMessage(consumeCtx, msg, linkRepo, statsRepo, cfg.operationTTL); err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "process click event failed")
-			logger.Error("failed to process click event",
-				zap.Error(err),
-				zap.String("topic", msg.Topic),
-				zap.Int("partition", msg.Partition),
-				zap.Int64("offset", msg.Offset),
-			)
-			span.End()
-			time.Sleep(cfg.consumeBackoff)
-			continue
-		}
+func loadConfig() (appConfig, error) {
+	kafkaTopic := config.GetEnv("KAFKA_CLICK_TOPIC", "clicks.recorded")
 
-		if err := reader.CommitMessages(consumeCtx, msg); err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "commit kafka offset failed")
-			logger.Error("failed to commit kafka offset",
-				zap.Error(err),
-				zap.String("topic", msg.Topic),
-				zap.Int("partition", msg.Partition),
-				zap.Int64("offset", msg.Offset),
-			)
-			span.End()
-			time.Sleep(cfg.consumeBackoff)
-			continue
-		}
+	cfg := appConfig{
+		appEnv:        config.GetEnv("APP_ENV", "production"),
+		appName:       config.GetEnv("APP_NAME", "encurtador-url"),
+		appVersion:    config.GetEnv("APP_VERSION", "0.1.0"),
+		otelEndpoint:  config.GetEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://jaeger:4318"),
+		mongoURI:      config.GetEnv("MONGODB_URI", "mongodb://localhost:27017"),
+		mongoDatabase: config.GetEnv("MONGODB_DATABASE", "encurtador"),
 
-		span.End()
-	}
-}
+		otelLogsEnabled:  config.GetEnvBool("OTEL_LOGS_ENABLED", false),
+		otelLogsEndpoint: config.GetEnv("OTEL_LOGS_ENDPOINT", ""),
 
-func processMessage(
-	ctx context.Context,
-	msg kafka.Message,
-	linkRepo *mongoStorage.LinksRepository,
-	statsRepo *mongoStorage.ClickStatsRepository,
-	operationTTL time.Duration,
-) error {
-	var event events.ClickRecorded
-	if err := json.Unmarshal(msg.Value, &event); err != nil {
-		logger.Warn("invalid click event payload, skipping",
-			zap.Error(err),
-			zap.ByteString("payload", msg.Value),
-		)
-		return nil
-	}
-	if strings.TrimSpace(event.Slug) == "" {
-		logger.Warn("click event missing slug, skipping", zap.String("event_id", event.EventID))
-		return nil
-	}
+		kafkaBrokers:  config.SplitCSV(config.GetEnv("KAFKA_BROKERS", "kafka:9092")),
+		kafkaTopic:    kafkaTopic,
+		kafkaGroupID:  config.GetEnv("KAFKA_CLICK_GROUP_ID", "click-analytics"),
+		kafkaDLQTopic: config.GetEnv("KAFKA_CLICK_DLQ_TOPIC", kafkaTopic+".dlq"),
+		maxRetries:    config.GetEnvInt("KAFKA_CLICK_MAX_RETRIES", 3),
 
-	occurredAt := msg.Time.UTC()
-	if strings.TrimSpace(event.OccurredAt) != "" {
-		parsed, err := time.Parse(time.RFC3339Nano, event.OccurredAt)
-		if err != nil {
-			logger.Warn("invalid event occurredAt, using kafka timestamp",
-				zap.Error(err),
-				zap.String("event_id", event.EventID),
-			)
-		} else {
-			occurredAt = parsed.UTC()
-		}
-	}
+		kafkaSecurity: kafkainfra.Config{
+			Compression:      config.GetEnv("KAFKA_COMPRESSION", ""),
+			SecurityProtocol: config.GetEnv("KAFKA_SECURITY_PROTOCOL", "plaintext"),
+			SASLMechanism:    config.GetEnv("KAFKA_SASL_MECHANISM", ""),
+			SASLUsername:     config.GetEnv("KAFKA_SASL_USERNAME", ""),
+			SASLPassword:     config.GetEnv("KAFKA_SASL_PASSWORD", ""),
+			TLSCAFile:        config.GetEnv("KAFKA_TLS_CA_FILE", ""),
+		},
+		fetchMaxWait: config.GetEnvDuration("KAFKA_CONSUMER_MAX_WAIT", 500*time.Millisecond),
+		operationTTL: config.GetEnvDuration("KAFKA_CONSUMER_OPERATION_TIMEOUT", 5*time.Second),
+		retryBase:    config.GetEnvDuration("KAFKA_CONSUMER_RETRY_BASE_DELAY", 250*time.Millisecond),
+		retryMax:     config.GetEnvDuration("KAFKA_CONSUMER_RETRY_MAX_DELAY", 5*time.Second),
 
-	opCtx, cancel := context.WithTimeout(ctx, operationTTL)
-	defer cancel()
+		consumerWorkers:      config.GetEnvInt("KAFKA_CONSUMER_WORKERS", 8),
+		inFlightPerPartition: config.GetEnvInt("KAFKA_CONSUMER_INFLIGHT_PER_PARTITION", 100),
 
-	_, err := linkRepo.FindActiveBySlugAndIncClick(opCtx, event.Slug, occurredAt)
-	if err != nil {
-		if errors.Is(err, links.ErrNotFound) || errors.Is(err, links.ErrExpired) {
-			// Event is stale relative to current data (e.g. deleted/expired). Safe to skip.
-			logger.Info("click event skipped for missing or expired link",
-				zap.String("event_id", event.EventID),
-				zap.String("slug", event.Slug),
-			)
-			return nil
-		}
-		return err
-	}
+		batchMax:     config.GetEnvInt("CLICK_BATCH_MAX", 500),
+		batchMaxWait: config.GetEnvDuration("CLICK_BATCH_MAX_WAIT", 250*time.Millisecond),
 
-	if err := statsRepo.IncDaily(opCtx, event.Slug, occurredAt); err != nil {
-		return err
+		adminAddr: config.GetEnv("KAFKA_CLICK_ADMIN_ADDR", ":9090"),
 	}
 
-	return nil
-}
-
-func loadConfig() (config, error) {
-	cfg := config{
-		appEnv:         getEnv("APP_ENV", "production"),
-		appName:        getEnv("APP_NAME", "encurtador-url"),
-		appVersion:     getEnv("APP_VERSION", "0.1.0"),
-		otelEndpoint:   getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://jaeger:4318"),
-		mongoURI:       getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-		mongoDatabase:  getEnv("MONGODB_DATABASE", "encurtador"),
-		kafkaBrokers:   splitCSV(getEnv("KAFKA_BROKERS", "kafka:9092")),
-		kafkaTopic:     getEnv("KAFKA_CLICK_TOPIC", "clicks.recorded"),
-		kafkaGroupID:   getEnv("KAFKA_CLICK_GROUP_ID", "click-analytics"),
-		fetchMaxWait:   getEnvDuration("KAFKA_CONSUMER_MAX_WAIT", 500*time.Millisecond),
-		operationTTL:   getEnvDuration("KAFKA_CONSUMER_OPERATION_TIMEOUT", 5*time.Second),
-		consumeBackoff: getEnvDuration("KAFKA_CONSUMER_BACKOFF", 500*time.Millisecond),
+	if cfg.otelLogsEndpoint == "" {
+		cfg.otelLogsEndpoint = cfg.otelEndpoint
 	}
 
 	if len(cfg.kafkaBrokers) == 0 {
-		return config{}, fmt.Errorf("KAFKA_BROKERS must contain at least one broker")
+		return appConfig{}, fmt.Errorf("KAFKA_BROKERS must contain at least one broker")
 	}
 	if strings.TrimSpace(cfg.kafkaTopic) == "" {
-		return config{}, fmt.Errorf("KAFKA_CLICK_TOPIC must not be empty")
+		return appConfig{}, fmt.Errorf("KAFKA_CLICK_TOPIC must not be empty")
 	}
 	if strings.TrimSpace(cfg.kafkaGroupID) == "" {
-		return config{}, fmt.Errorf("KAFKA_CLICK_GROUP_ID must not be empty")
+		return appConfig{}, fmt.Errorf("KAFKA_CLICK_GROUP_ID must not be empty")
+	}
+	if strings.TrimSpace(cfg.kafkaDLQTopic) == "" {
+		return appConfig{}, fmt.Errorf("KAFKA_CLICK_DLQ_TOPIC must not be empty")
+	}
+	if cfg.maxRetries <= 0 {
+		return appConfig{}, fmt.Errorf("KAFKA_CLICK_MAX_RETRIES must be > 0")
+	}
+	switch cfg.kafkaSecurity.SecurityProtocol {
+	case "plaintext", "ssl", "sasl_ssl":
+	default:
+		return appConfig{}, fmt.Errorf("KAFKA_SECURITY_PROTOCOL must be one of plaintext|ssl|sasl_ssl (got %q)", cfg.kafkaSecurity.SecurityProtocol)
 	}
 	if cfg.operationTTL <= 0 {
-		return config{}, fmt.Errorf("KAFKA_CONSUMER_OPERATION_TIMEOUT must be > 0")
+		return appConfig{}, fmt.Errorf("KAFKA_CONSUMER_OPERATION_TIMEOUT must be > 0")
 	}
-
-	return cfg, nil
-}
-
-func getEnv(key, fallback string) string {
-	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
-		return value
+	if cfg.retryBase <= 0 {
+		return appConfig{}, fmt.Errorf("KAFKA_CONSUMER_RETRY_BASE_DELAY must be > 0")
 	}
-	return fallback
-}
-
-func splitCSV(raw string) []string {
-	parts := strings.Split(raw, ",")
-	values := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed != "" {
-			values = append(values, trimmed)
-		}
+	if cfg.retryMax < cfg.retryBase {
+		return appConfig{}, fmt.Errorf("KAFKA_CONSUMER_RETRY_MAX_DELAY must be >= KAFKA_CONSUMER_RETRY_BASE_DELAY")
 	}
-	return values
-}
-
-func getEnvDuration(key string, fallback time.Duration) time.Duration {
-	value := strings.TrimSpace(os.Getenv(key))
-	if value == "" {
-		return fallback
+	if cfg.consumerWorkers <= 0 {
+		return appConfig{}, fmt.Errorf("KAFKA_CONSUMER_WORKERS must be > 0")
 	}
-	d, err := time.ParseDuration(value)
-	if err != nil {
-		return fallback
+	if cfg.inFlightPerPartition <= 0 {
+		return appConfig{}, fmt.Errorf("KAFKA_CONSUMER_INFLIGHT_PER_PARTITION must be > 0")
 	}
-	return d
-}
-
-func contextFromKafkaHeaders(parent context.Context, headers []kafka.Header) context.Context {
-	carrier := propagation.MapCarrier{}
-	for _, header := range headers {
-		key := strings.ToLower(strings.TrimSpace(header.Key))
-		if key == "" {
-			continue
-		}
-		carrier.Set(key, string(header.Value))
+	if cfg.batchMax <= 0 {
+		return appConfig{}, fmt.Errorf("CLICK_BATCH_MAX must be > 0")
+	}
+	if cfg.batchMaxWait <= 0 {
+		return appConfig{}, fmt.Errorf("CLICK_BATCH_MAX_WAIT must be > 0")
 	}
-	return otel.GetTextMapPropagator().Extract(parent, carrier)
+
+	return cfg, nil
 }
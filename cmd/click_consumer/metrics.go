@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	batchSize = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "click_consumer_batch_size",
+			Help:    "Number of (slug, day) groups applied per click batch flush.",
+			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+		},
+	)
+
+	batchFlushDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "click_consumer_batch_flush_duration_seconds",
+			Help:    "Time taken to apply one click batch's BulkIncrement call.",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+	)
+)
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	kafkainfra "github.com/IgorGrieder/encurtador-url/internal/infrastructure/kafka"
+	"github.com/IgorGrieder/encurtador-url/internal/messaging/consumer"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/outbox"
+	"github.com/segmentio/kafka-go"
+)
+
+// RetryCountHeader tracks how many times a click event has been escalated
+// through the clicks.recorded.retry.<N> topics, the same way
+// FirstSeenHeader tracks when it first failed.
+const RetryCountHeader = "x-retry-count"
+
+// FirstSeenHeader is stamped once, on a message's first retry, so the
+// dead-letter payload can report how long an event has been retrying
+// rather than just when it finally gave up.
+const FirstSeenHeader = "x-first-seen-at"
+
+// retryRouter republishes a failed click event onto its next
+// clicks.recorded.retry.<N> topic instead of retrying it in-process like
+// consumer.Consumer does. Each retry topic is drained by its own
+// clickTopicConsumer after a backoff delay, so a downstream blip only
+// delays the events it actually affected instead of head-of-line-blocking
+// the whole partition behind a blocking retry loop.
+type retryRouter struct {
+	writer     *kafka.Writer
+	baseTopic  string
+	maxRetries int
+	backoff    *outbox.SimpleBackoff
+}
+
+func newRetryRouter(brokers []string, baseTopic string, maxRetries int, retryBase, retryMax time.Duration, security kafkainfra.Config) (*retryRouter, error) {
+	transport, err := security.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &retryRouter{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			Compression:            security.CompressionCodec(),
+			Transport:              transport,
+			AllowAutoTopicCreation: true,
+		},
+		baseTopic:  baseTopic,
+		maxRetries: maxRetries,
+		backoff:    outbox.NewSimpleBackoff(retryBase, retryMax, 0),
+	}, nil
+}
+
+func retryTopicName(baseTopic string, n int) string {
+	return fmt.Sprintf("%s.retry.%d", baseTopic, n)
+}
+
+// delayFor returns how long the retry.<n> consumer should wait before
+// handling a message already on its nth retry topic, reusing
+// outbox.SimpleBackoff so this escalation path doubles and jitters the
+// same way every other retry cadence in the codebase does.
+func (r *retryRouter) delayFor(n int) time.Duration {
+	delay, _ := r.backoff.Next(n, nil)
+	return delay
+}
+
+// publish republishes msg onto retry.<attempt>, stamping RetryCountHeader
+// and (on the first retry) FirstSeenHeader.
+func (r *retryRouter) publish(ctx context.Context, msg consumer.Message, attempt int) error {
+	headers := stampHeader(msg.Headers, RetryCountHeader, strconv.Itoa(attempt))
+	headers = ensureFirstSeen(headers)
+
+	return r.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   retryTopicName(r.baseTopic, attempt),
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+		Time:    time.Now(),
+	})
+}
+
+func (r *retryRouter) Close() error {
+	return r.writer.Close()
+}
+
+// retryCount reads RetryCountHeader off headers, defaulting to 0 for a
+// message arriving fresh off the main topic.
+func retryCount(headers []kafka.Header) int {
+	for _, h := range headers {
+		if h.Key == RetryCountHeader {
+			if n, err := strconv.Atoi(string(h.Value)); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// firstSeenAt reads FirstSeenHeader off headers, falling back to now for a
+// message that failed on its very first, main-topic attempt.
+func firstSeenAt(headers []kafka.Header) time.Time {
+	for _, h := range headers {
+		if h.Key == FirstSeenHeader {
+			if t, err := time.Parse(time.RFC3339Nano, string(h.Value)); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Now().UTC()
+}
+
+// stampHeader returns headers with key set to value, replacing any
+// existing header of the same key.
+func stampHeader(headers []kafka.Header, key, value string) []kafka.Header {
+	out := make([]kafka.Header, 0, len(headers)+1)
+	for _, h := range headers {
+		if h.Key == key {
+			continue
+		}
+		out = append(out, h)
+	}
+	return append(out, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func ensureFirstSeen(headers []kafka.Header) []kafka.Header {
+	for _, h := range headers {
+		if h.Key == FirstSeenHeader {
+			return headers
+		}
+	}
+	return append(headers, kafka.Header{Key: FirstSeenHeader, Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))})
+}
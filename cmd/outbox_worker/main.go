@@ -2,20 +2,23 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/IgorGrieder/encurtador-url/internal/config"
-	"github.com/IgorGrieder/encurtador-url/internal/events"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/telemetry"
+	outboxpkg "github.com/IgorGrieder/encurtador-url/internal/processing/outbox"
+	jetstreamsink "github.com/IgorGrieder/encurtador-url/internal/processing/outbox/sink/jetstream"
+	kafkasink "github.com/IgorGrieder/encurtador-url/internal/processing/outbox/sink/kafka"
 	postgresStorage "github.com/IgorGrieder/encurtador-url/internal/storage/postgres"
+	"github.com/nats-io/nats.go"
 	"github.com/segmentio/kafka-go"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -36,6 +39,29 @@ type workerConfig struct {
 	kafkaTopic   string
 	workerID     string
 
+	kafkaCompression kafka.Compression
+	kafkaAcks        kafka.RequiredAcks
+	kafkaBatchBytes  int64
+	kafkaLinger      time.Duration
+	kafkaMaxAttempts int
+
+	// workerCount/workerIndex assign this process a disjoint shard of the
+	// outbox's slugs (see postgresStorage.OutboxShard), so multiple worker
+	// replicas claim disjoint work instead of racing over the same
+	// head-of-queue rows. workerCount of 1 (the default) is unsharded.
+	workerCount int
+	workerIndex int
+
+	// sinkKind selects which durable log processBatch publishes claimed
+	// events to: "kafka", "jetstream", or "multi" (both, gating MarkSent on
+	// both acking). It does not include "mongo" - applying events straight
+	// to Mongo's own stats collection is a different worker entirely
+	// (mongo.OutboxDispatcher), not a publish destination this binary picks
+	// between.
+	sinkKind    string
+	natsURL     string
+	natsSubject string
+
 	pollInterval time.Duration
 	batchSize    int
 	writeTimeout time.Duration
@@ -43,6 +69,19 @@ type workerConfig struct {
 	retryMax     time.Duration
 	idleWait     time.Duration
 	claimLease   time.Duration
+
+	// retryPolicy decides the delay before a failed publish is retried.
+	// Built from retryBase/retryMax once in loadConfig rather than per
+	// call, since outboxpkg.SimpleBackoff is stateless and there is no
+	// reason to reconstruct it on every failure.
+	retryPolicy outboxpkg.BackoffPolicy
+
+	// listenEnabled switches the idle wait from pure pollInterval ticking to
+	// waking on ClickOutboxRepository.Subscribe's LISTEN/NOTIFY channel, with
+	// listenFallback as a slow ticker safety net for missed notifications
+	// (e.g. a dropped connection during reconnect).
+	listenEnabled  bool
+	listenFallback time.Duration
 }
 
 func main() {
@@ -92,19 +131,11 @@ func main() {
 		logger.Fatal("failed to initialize outbox repository", zap.Error(err))
 	}
 
-	writer := kafka.Writer{
-		Addr:                   kafka.TCP(cfg.kafkaBrokers...),
-		Topic:                  cfg.kafkaTopic,
-		Balancer:               &kafka.LeastBytes{},
-		BatchTimeout:           10 * time.Millisecond,
-		RequiredAcks:           kafka.RequireOne,
-		AllowAutoTopicCreation: true,
+	sink, closeSink, err := buildSink(cfg)
+	if err != nil {
+		logger.Fatal("failed to initialize outbox sink", zap.Error(err))
 	}
-	defer func() {
-		if err := writer.Close(); err != nil {
-			logger.Warn("failed to close kafka writer", zap.Error(err))
-		}
-	}()
+	defer closeSink()
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -116,10 +147,32 @@ func main() {
 		zap.Int("batch_size", cfg.batchSize),
 		zap.Duration("poll_interval", cfg.pollInterval),
 		zap.Duration("claim_lease", cfg.claimLease),
+		zap.Bool("postgres_outbox_listen", cfg.listenEnabled),
+		zap.String("sink", cfg.sinkKind),
+		zap.Int("worker_count", cfg.workerCount),
+		zap.Int("worker_index", cfg.workerIndex),
 	)
 
+	// idleWakeCh fires on every idle wait. By default that's pure
+	// poll-interval ticking; with POSTGRES_OUTBOX_LISTEN and a successful
+	// Subscribe, it's LISTEN/NOTIFY wakeups instead, with a slow ticker
+	// merged in as a safety net for notifications missed during a dropped
+	// connection's reconnect window.
 	ticker := time.NewTicker(cfg.pollInterval)
 	defer ticker.Stop()
+	idleWakeCh := ticker.C
+
+	if cfg.listenEnabled {
+		notifyCh, err := outboxRepo.Subscribe(ctx)
+		if err != nil {
+			logger.Warn("failed to subscribe to outbox notifications, falling back to pure polling", zap.Error(err))
+		} else {
+			fallbackTicker := time.NewTicker(cfg.listenFallback)
+			defer fallbackTicker.Stop()
+			idleWakeCh = mergeWake(ctx, notifyCh, fallbackTicker.C)
+			logger.Info("listening for outbox notifications", zap.Duration("fallback_interval", cfg.listenFallback))
+		}
+	}
 
 	for {
 		select {
@@ -129,7 +182,7 @@ func main() {
 		default:
 		}
 
-		processed, err := processBatch(ctx, outboxRepo, &writer, cfg)
+		processed, err := processBatch(ctx, outboxRepo, sink, cfg)
 		if err != nil {
 			logger.Error("failed to process outbox batch", zap.Error(err))
 		}
@@ -139,7 +192,7 @@ func main() {
 			case <-ctx.Done():
 				logger.Info("outbox worker stopping")
 				return
-			case <-ticker.C:
+			case <-idleWakeCh:
 			}
 			continue
 		}
@@ -158,10 +211,10 @@ func main() {
 func processBatch(
 	ctx context.Context,
 	repo *postgresStorage.ClickOutboxRepository,
-	writer *kafka.Writer,
+	sink outboxpkg.Sink,
 	cfg workerConfig,
 ) (int, error) {
-	eventsBatch, err := repo.ClaimPending(ctx, time.Now().UTC(), int64(cfg.batchSize), cfg.workerID, cfg.claimLease)
+	eventsBatch, err := repo.ClaimPending(ctx, time.Now().UTC(), int64(cfg.batchSize), cfg.workerID, cfg.claimLease, cfg.shard())
 	if err != nil {
 		return 0, err
 	}
@@ -169,50 +222,110 @@ func processBatch(
 		return 0, nil
 	}
 
+	if batchSink, ok := sink.(outboxpkg.BatchSink); ok && cfg.kafkaAcks == kafka.RequireAll {
+		return processBatchAtomic(ctx, repo, batchSink, cfg, eventsBatch)
+	}
+
 	processed := 0
 	tracer := otel.Tracer("outbox-worker")
 	for _, ev := range eventsBatch {
-		msgPayload := events.ClickRecorded{
-			EventID:    ev.ID,
-			Slug:       ev.Slug,
-			OccurredAt: ev.OccurredAt.UTC().Format(time.RFC3339Nano),
-		}
-		value, err := json.Marshal(msgPayload)
+		carrier := outboxEventCarrier(ev)
+		parentCtx := otel.GetTextMapPropagator().Extract(ctx, carrier)
+		publishCtx, span := tracer.Start(
+			parentCtx,
+			"outbox.publish.click_recorded",
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(
+				attribute.String("messaging.system", cfg.sinkKind),
+				attribute.String("messaging.destination.name", sinkDestination(cfg)),
+				attribute.String("messaging.operation", "publish"),
+				attribute.String("messaging.message.id", ev.ID),
+			),
+		)
+
+		writeCtx, cancel := context.WithTimeout(publishCtx, cfg.writeTimeout)
+		err := sink.Publish(writeCtx, toOutboxEvent(ev))
+		cancel()
 		if err != nil {
-			logger.Error("failed to marshal outbox event", zap.Error(err), zap.String("event_id", ev.ID))
-			delay := backoffDelay(cfg.retryBase, cfg.retryMax, ev.Attempts+1)
-			_ = repo.MarkRetry(ctx, ev.ID, cfg.workerID, truncateErr(err), time.Now().UTC().Add(delay))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "sink publish failed")
+			delay, _ := cfg.retryPolicy.Next(ev.Attempts+1, err)
+			if markErr := repo.MarkRetry(ctx, ev.ID, cfg.workerID, truncateErr(err), time.Now().UTC().Add(delay)); markErr != nil {
+				span.RecordError(markErr)
+				logger.Error("failed to mark outbox retry", zap.Error(markErr), zap.String("event_id", ev.ID))
+			}
+			logger.Warn("failed to publish outbox event",
+				zap.Error(err),
+				zap.String("event_id", ev.ID),
+				zap.String("slug", ev.Slug),
+				zap.Duration("retry_in", delay),
+			)
+			span.End()
+			continue
+		}
+
+		if err := repo.MarkSent(ctx, ev.ID, cfg.workerID); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "mark sent failed")
+			logger.Error("failed to mark outbox event as sent", zap.Error(err), zap.String("event_id", ev.ID))
+			span.End()
 			continue
 		}
 
+		span.End()
+		processed++
+	}
+
+	return processed, nil
+}
+
+// processBatchAtomic publishes eventsBatch through a single BatchSink call
+// instead of one Publish per event, used when the sink supports it and the
+// configured acks level calls for the extra durability of acks=all. kafka-go
+// unpacks a partial batch failure into one error per message, so only the
+// events that actually failed get MarkRetry'd - the rest go through
+// MarkSent despite having shared the same wire round trip.
+func processBatchAtomic(
+	ctx context.Context,
+	repo *postgresStorage.ClickOutboxRepository,
+	sink outboxpkg.BatchSink,
+	cfg workerConfig,
+	eventsBatch []postgresStorage.OutboxClickEvent,
+) (int, error) {
+	tracer := otel.Tracer("outbox-worker")
+	spans := make([]trace.Span, len(eventsBatch))
+	evs := make([]outboxpkg.Event, len(eventsBatch))
+
+	for i, ev := range eventsBatch {
 		carrier := outboxEventCarrier(ev)
 		parentCtx := otel.GetTextMapPropagator().Extract(ctx, carrier)
-		producerCtx, span := tracer.Start(
+		_, span := tracer.Start(
 			parentCtx,
-			"kafka.publish.click_recorded",
+			"outbox.publish.click_recorded",
 			trace.WithSpanKind(trace.SpanKindProducer),
 			trace.WithAttributes(
-				attribute.String("messaging.system", "kafka"),
-				attribute.String("messaging.destination.name", cfg.kafkaTopic),
+				attribute.String("messaging.system", cfg.sinkKind),
+				attribute.String("messaging.destination.name", sinkDestination(cfg)),
 				attribute.String("messaging.operation", "publish"),
 				attribute.String("messaging.message.id", ev.ID),
-				attribute.String("messaging.kafka.message_key", ev.Slug),
+				attribute.String("messaging.batch.size", strconv.Itoa(len(eventsBatch))),
 			),
 		)
-		otel.GetTextMapPropagator().Inject(producerCtx, carrier)
-
-		writeCtx, cancel := context.WithTimeout(producerCtx, cfg.writeTimeout)
-		err = writer.WriteMessages(writeCtx, kafka.Message{
-			Key:     []byte(ev.Slug),
-			Value:   value,
-			Time:    ev.OccurredAt.UTC(),
-			Headers: carrierToKafkaHeaders(carrier),
-		})
-		cancel()
-		if err != nil {
+		spans[i] = span
+		evs[i] = toOutboxEvent(ev)
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, cfg.writeTimeout)
+	publishErrs := sink.PublishBatch(writeCtx, evs)
+	cancel()
+
+	processed := 0
+	for i, ev := range eventsBatch {
+		span := spans[i]
+		if err := publishErrs[i]; err != nil {
 			span.RecordError(err)
-			span.SetStatus(codes.Error, "kafka publish failed")
-			delay := backoffDelay(cfg.retryBase, cfg.retryMax, ev.Attempts+1)
+			span.SetStatus(codes.Error, "sink publish failed")
+			delay, _ := cfg.retryPolicy.Next(ev.Attempts+1, err)
 			if markErr := repo.MarkRetry(ctx, ev.ID, cfg.workerID, truncateErr(err), time.Now().UTC().Add(delay)); markErr != nil {
 				span.RecordError(markErr)
 				logger.Error("failed to mark outbox retry", zap.Error(markErr), zap.String("event_id", ev.ID))
@@ -242,6 +355,117 @@ func processBatch(
 	return processed, nil
 }
 
+func toOutboxEvent(ev postgresStorage.OutboxClickEvent) outboxpkg.Event {
+	return outboxpkg.Event{
+		ID:          ev.ID,
+		Slug:        ev.Slug,
+		OccurredAt:  ev.OccurredAt,
+		TraceParent: ev.TraceParent,
+		TraceState:  ev.TraceState,
+		Baggage:     ev.Baggage,
+	}
+}
+
+func sinkDestination(cfg workerConfig) string {
+	if cfg.sinkKind == "jetstream" {
+		return cfg.natsSubject
+	}
+	return cfg.kafkaTopic
+}
+
+// buildSink constructs the Sink (or fan-out of Sinks) cfg.sinkKind selects,
+// and a close func releasing whatever connections it opened.
+func buildSink(cfg workerConfig) (outboxpkg.Sink, func(), error) {
+	switch cfg.sinkKind {
+	case "kafka":
+		s := kafkasink.NewSinkWithOptions(cfg.kafkaBrokers, cfg.kafkaTopic, cfg.appName, kafkaSinkOptions(cfg))
+		return s, func() {
+			if err := s.Close(); err != nil {
+				logger.Warn("failed to close kafka sink", zap.Error(err))
+			}
+		}, nil
+	case "jetstream":
+		js, closeNATS, err := connectJetStream(cfg.natsURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return jetstreamsink.NewSink(js, cfg.natsSubject, cfg.appName), closeNATS, nil
+	case "multi":
+		kafkaS := kafkasink.NewSinkWithOptions(cfg.kafkaBrokers, cfg.kafkaTopic, cfg.appName, kafkaSinkOptions(cfg))
+		js, closeNATS, err := connectJetStream(cfg.natsURL)
+		if err != nil {
+			if closeErr := kafkaS.Close(); closeErr != nil {
+				logger.Warn("failed to close kafka sink", zap.Error(closeErr))
+			}
+			return nil, nil, err
+		}
+		jetstreamS := jetstreamsink.NewSink(js, cfg.natsSubject, cfg.appName)
+		return outboxpkg.NewMultiSink(kafkaS, jetstreamS), func() {
+			if err := kafkaS.Close(); err != nil {
+				logger.Warn("failed to close kafka sink", zap.Error(err))
+			}
+			closeNATS()
+		}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown OUTBOX_SINK %q", cfg.sinkKind)
+	}
+}
+
+// shard builds the postgresStorage.OutboxShard this process claims against
+// from its static OUTBOX_WORKER_COUNT/OUTBOX_WORKER_INDEX config.
+func (cfg workerConfig) shard() postgresStorage.OutboxShard {
+	return postgresStorage.OutboxShard{Count: cfg.workerCount, Index: cfg.workerIndex}
+}
+
+func kafkaSinkOptions(cfg workerConfig) kafkasink.Options {
+	return kafkasink.Options{
+		Compression:  cfg.kafkaCompression,
+		RequiredAcks: cfg.kafkaAcks,
+		BatchBytes:   cfg.kafkaBatchBytes,
+		BatchTimeout: cfg.kafkaLinger,
+		MaxAttempts:  cfg.kafkaMaxAttempts,
+	}
+}
+
+func connectJetStream(url string) (nats.JetStreamContext, func(), error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to NATS: %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("acquire JetStream context: %w", err)
+	}
+	return js, conn.Close, nil
+}
+
+// mergeWake fans notifyCh and fallbackCh into a single channel so the main
+// loop's select only needs one idle-wait case regardless of which one fired.
+func mergeWake(ctx context.Context, notifyCh <-chan struct{}, fallbackCh <-chan time.Time) <-chan struct{} {
+	out := make(chan struct{}, 1)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-notifyCh:
+				if !ok {
+					notifyCh = nil
+					continue
+				}
+			case <-fallbackCh:
+			}
+			select {
+			case out <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return out
+}
+
 func loadConfig() (cfg workerConfig, _ error) {
 	cfg = workerConfig{
 		appEnv:       config.GetEnv("APP_ENV", "production"),
@@ -259,8 +483,34 @@ func loadConfig() (cfg workerConfig, _ error) {
 		retryMax:     config.GetEnvDuration("OUTBOX_RETRY_MAX_DELAY", 30*time.Second),
 		idleWait:     config.GetEnvDuration("OUTBOX_IDLE_WAIT", 50*time.Millisecond),
 		claimLease:   config.GetEnvDuration("OUTBOX_CLAIM_LEASE", 30*time.Second),
+
+		listenEnabled:  config.GetEnvBool("POSTGRES_OUTBOX_LISTEN", false),
+		listenFallback: config.GetEnvDuration("POSTGRES_OUTBOX_LISTEN_FALLBACK", 45*time.Second),
+
+		sinkKind:    config.GetEnv("OUTBOX_SINK", "kafka"),
+		natsURL:     config.GetEnv("NATS_URL", "nats://nats:4222"),
+		natsSubject: config.GetEnv("NATS_SUBJECT", "clicks.recorded"),
+
+		kafkaBatchBytes:  int64(config.GetEnvInt("KAFKA_BATCH_BYTES", 1048576)),
+		kafkaLinger:      time.Duration(config.GetEnvInt("KAFKA_LINGER_MS", 0)) * time.Millisecond,
+		kafkaMaxAttempts: config.GetEnvInt("KAFKA_MAX_ATTEMPTS", 10),
+
+		workerCount: config.GetEnvInt("OUTBOX_WORKER_COUNT", 1),
+		workerIndex: config.GetEnvInt("OUTBOX_WORKER_INDEX", 0),
 	}
 
+	compression, err := parseKafkaCompression(config.GetEnv("KAFKA_COMPRESSION", "snappy"))
+	if err != nil {
+		return workerConfig{}, err
+	}
+	cfg.kafkaCompression = compression
+
+	acks, err := parseKafkaAcks(config.GetEnv("KAFKA_ACKS", "all"))
+	if err != nil {
+		return workerConfig{}, err
+	}
+	cfg.kafkaAcks = acks
+
 	if strings.TrimSpace(cfg.postgresDSN) == "" {
 		return workerConfig{}, fmt.Errorf("DB_DSN must not be empty")
 	}
@@ -285,25 +535,70 @@ func loadConfig() (cfg workerConfig, _ error) {
 	if strings.TrimSpace(cfg.workerID) == "" {
 		return workerConfig{}, fmt.Errorf("OUTBOX_WORKER_ID must not be empty")
 	}
+	if cfg.listenFallback <= 0 {
+		return workerConfig{}, fmt.Errorf("POSTGRES_OUTBOX_LISTEN_FALLBACK must be > 0")
+	}
 	if cfg.claimLease <= 0 {
 		return workerConfig{}, fmt.Errorf("OUTBOX_CLAIM_LEASE must be > 0")
 	}
+	switch cfg.sinkKind {
+	case "kafka", "jetstream", "multi":
+	default:
+		return workerConfig{}, fmt.Errorf("OUTBOX_SINK must be one of kafka, jetstream, multi (got %q)", cfg.sinkKind)
+	}
+	if (cfg.sinkKind == "jetstream" || cfg.sinkKind == "multi") && strings.TrimSpace(cfg.natsURL) == "" {
+		return workerConfig{}, fmt.Errorf("NATS_URL must not be empty when OUTBOX_SINK is jetstream or multi")
+	}
+	if cfg.kafkaBatchBytes <= 0 {
+		return workerConfig{}, fmt.Errorf("KAFKA_BATCH_BYTES must be > 0")
+	}
+	if cfg.kafkaMaxAttempts <= 0 {
+		return workerConfig{}, fmt.Errorf("KAFKA_MAX_ATTEMPTS must be > 0")
+	}
+	if cfg.workerCount <= 0 {
+		return workerConfig{}, fmt.Errorf("OUTBOX_WORKER_COUNT must be > 0")
+	}
+	if cfg.workerIndex < 0 || cfg.workerIndex >= cfg.workerCount {
+		return workerConfig{}, fmt.Errorf("OUTBOX_WORKER_INDEX must be in [0, OUTBOX_WORKER_COUNT)")
+	}
+
+	cfg.retryPolicy = outboxpkg.NewSimpleBackoff(cfg.retryBase, cfg.retryMax, 0)
 
 	return cfg, nil
 }
 
-func backoffDelay(base, max time.Duration, attempt int) time.Duration {
-	delay := base
-	for i := 0; i < attempt; i++ {
-		delay *= 2
-		if delay >= max {
-			return max
-		}
+// parseKafkaCompression maps KAFKA_COMPRESSION's accepted values to
+// kafka-go's Compression codec, with "none" as the uncompressed zero value.
+func parseKafkaCompression(raw string) (kafka.Compression, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("KAFKA_COMPRESSION must be one of none, gzip, snappy, lz4, zstd (got %q)", raw)
 	}
-	if delay > max {
-		return max
+}
+
+// parseKafkaAcks maps KAFKA_ACKS to kafka-go's RequiredAcks. There's no
+// "none" option here (unlike kafka.RequireNone) since an outbox publish
+// that isn't acknowledged at all can't be distinguished from a successful
+// one, which would defeat MarkSent/MarkRetry's whole purpose.
+func parseKafkaAcks(raw string) (kafka.RequiredAcks, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "one":
+		return kafka.RequireOne, nil
+	case "", "all":
+		return kafka.RequireAll, nil
+	default:
+		return 0, fmt.Errorf("KAFKA_ACKS must be one of one, all (got %q)", raw)
 	}
-	return delay
 }
 
 func truncateErr(err error) string {
@@ -330,17 +625,3 @@ func outboxEventCarrier(ev postgresStorage.OutboxClickEvent) propagation.MapCarr
 	}
 	return carrier
 }
-
-func carrierToKafkaHeaders(carrier propagation.MapCarrier) []kafka.Header {
-	headers := make([]kafka.Header, 0, len(carrier))
-	for key, value := range carrier {
-		if strings.TrimSpace(value) == "" {
-			continue
-		}
-		headers = append(headers, kafka.Header{
-			Key:   key,
-			Value: []byte(value),
-		})
-	}
-	return headers
-}
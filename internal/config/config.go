@@ -1,22 +1,34 @@
 package config
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"strconv"
-	"strings"
+	"reflect"
+	"time"
 
+	kafkainfra "github.com/IgorGrieder/encurtador-url/internal/infrastructure/kafka"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
 	"github.com/joho/godotenv"
+	"go.uber.org/zap"
 )
 
 type Config struct {
-	App       AppConfig
-	Server    ServerConfig
-	MongoDB   MongoDBConfig
-	Redis     RedisConfig
-	Shortener ShortenerConfig
-	Security  SecurityConfig
-	OTel      OTelConfig
+	App           AppConfig
+	Server        ServerConfig
+	MongoDB       MongoDBConfig
+	Redis         RedisConfig
+	Shortener     ShortenerConfig
+	Security      SecurityConfig
+	OTel          OTelConfig
+	Storage       StorageConfig
+	Postgres      PostgresConfig
+	OutboxDrainer OutboxDrainerConfig
+	ClickStats    ClickStatsConfig
+
+	// sources records the provider chain Load assembled this Config from,
+	// so Watch can keep re-resolving it on its own. A Config built by hand
+	// (e.g. in tests) has a nil sources and a nil Watch channel.
+	sources *configSources
 }
 
 type AppConfig struct {
@@ -27,8 +39,9 @@ type AppConfig struct {
 }
 
 type ServerConfig struct {
-	Port string
-	Host string
+	Port     string
+	Host     string
+	GRPCPort string
 }
 
 type MongoDBConfig struct {
@@ -36,6 +49,28 @@ type MongoDBConfig struct {
 	Database string
 }
 
+// StorageConfig selects which repository implementations initStorage wires
+// up for LinkRepository/StatsRepository/ClickOutboxRepository.
+type StorageConfig struct {
+	Backend string // "postgres" or "mongo"
+}
+
+type PostgresConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Database string
+	SSLMode  string
+}
+
+func (p PostgresConfig) DSN() string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
+		p.User, p.Password, p.Host, p.Port, p.Database, p.SSLMode,
+	)
+}
+
 type RedisConfig struct {
 	Addr     string
 	Password string
@@ -46,53 +81,258 @@ type ShortenerConfig struct {
 	BaseURL        string
 	SlugLength     int
 	RedirectStatus int // 301 or 302
+	Slugger        SluggerConfig
+}
+
+// SluggerConfig selects and parameterizes the slug-generation strategy. See
+// internal/processing/links.CryptoSlugger and the Redis-backed
+// CounterSlugger/SqidsSlugger in internal/storage/redis.
+type SluggerConfig struct {
+	Strategy   string // "crypto", "counter", or "sqids"
+	CounterKey string
+	SqidsSalt  string
 }
 
 type SecurityConfig struct {
-	APIKeys []string
+	APIKeys    []APIKeyConfig
+	JWT        JWTConfig
+	CreateRate CreateRateConfig
+	RateLimit  RateLimitConfig
+}
+
+// APIKeyConfig pairs a configured API key with the scopes it authorizes
+// (e.g. "links:create"), so API-key and JWT auth share the same
+// authorization model. See middleware.RequireScope.
+type APIKeyConfig struct {
+	Key    string
+	Scopes []string
+}
+
+// JWTConfig parameterizes middleware.JWTMiddleware's JWKS-based bearer
+// token verification. JWKSURL empty disables JWT auth entirely.
+type JWTConfig struct {
+	JWKSURL string
+}
+
+// CreateRateConfig parameterizes the rate limiter applied specifically to
+// the link-creation endpoint (separate from the general
+// SecurityConfig.RateLimit applied across all routes).
+type CreateRateConfig struct {
+	Strategy          string // "fixed" or "sliding"
+	RequestsPerMinute int
+}
+
+// RateLimitConfig selects and parameterizes the rate-limiting strategy
+// applied to incoming requests. See internal/storage/redis for the
+// fixed-window, sliding-window, and token-bucket implementations.
+type RateLimitConfig struct {
+	Strategy          string // "fixed", "sliding", or "token_bucket"
+	RequestsPerWindow int
+	Window            time.Duration
+	BucketCapacity    int64
+	RefillPerSecond   float64
 }
 
 type OTelConfig struct {
 	Enabled  bool
 	Endpoint string
+
+	// LogsEnabled and LogsEndpoint gate telemetry.InitLogs independently of
+	// the trace exporter above - an operator can turn on log export without
+	// also paying for tracing, or point it at a different collector.
+	// LogsEndpoint falls back to Endpoint when unset, since most
+	// deployments run one collector for both signals.
+	LogsEnabled  bool
+	LogsEndpoint string
+}
+
+// OutboxDrainerConfig parameterizes links.ClickOutboxDrainer's claim
+// batching and worker pool, independent of OutboxDispatcherOptions'
+// defaults-only knobs (see mongo.OutboxDispatcher).
+type OutboxDrainerConfig struct {
+	BatchSize    int
+	WorkerCount  int
+	PollInterval time.Duration
+}
+
+// ClickStatsConfig selects which links.ClickSink
+// BufferedClickStatsRepository flushes through. "mongo" (the default)
+// applies counts straight to the stats collection; "kafka" and "nats"
+// instead publish them for a separate consumer to aggregate, taking the
+// write pressure off Mongo for a high-volume deployment. Only the selected
+// backend's sub-config needs to be set.
+type ClickStatsConfig struct {
+	Backend string
+	Kafka   ClickStatsKafkaConfig
+	NATS    ClickStatsNATSConfig
+}
+
+type ClickStatsKafkaConfig struct {
+	Brokers []string
+	Topic   string
+
+	// Security configures compression/TLS/SASL for this writer, the same
+	// knobs click_consumer's own KAFKA_* settings expose for its reader -
+	// see kafkainfra.Config.
+	Security kafkainfra.Config
 }
 
+type ClickStatsNATSConfig struct {
+	URL     string
+	Subject string
+}
+
+// configSources records how a Config was assembled - its provider chain
+// plus handles on the file/remote providers within it - so Watch can keep
+// re-resolving it on a timer without the caller re-wiring anything.
+type configSources struct {
+	chain        *Chain
+	file         *FileProvider   // nil if CONFIG_FILE wasn't set
+	remote       *ConsulProvider // nil if no remote store was configured
+	pollInterval time.Duration
+}
+
+// Load assembles a Config from a Provider chain, highest precedence first:
+// a remote Consul KV prefix (if CONFIG_REMOTE_CONSUL_ADDR is set), then the
+// process environment (plus whatever .env populated into it), then an
+// optional CONFIG_FILE (flat KEY=value, for baked-in defaults). See Chain's
+// doc comment for why that order.
 func Load() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
 		fmt.Println("Warning: .env file not found, using environment variables")
 	}
 
+	providers := []Provider{envProvider{}}
+
+	var fileProvider *FileProvider
+	if path := GetEnv("CONFIG_FILE", ""); path != "" {
+		fp, err := NewFileProvider(path)
+		if err != nil {
+			return nil, fmt.Errorf("load CONFIG_FILE: %w", err)
+		}
+		fileProvider = fp
+		providers = append(providers, fp)
+	}
+
+	var remoteProvider *ConsulProvider
+	if addr := GetEnv("CONFIG_REMOTE_CONSUL_ADDR", ""); addr != "" {
+		prefix := GetEnv("CONFIG_REMOTE_CONSUL_PREFIX", "encurtador-url/config")
+		rp, err := NewConsulProvider(addr, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("load CONFIG_REMOTE_CONSUL_ADDR: %w", err)
+		}
+		remoteProvider = rp
+		providers = append([]Provider{rp}, providers...)
+	}
+
+	chain := NewChain(providers...)
+
+	cfg, err := buildFromChain(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.sources = &configSources{
+		chain:        chain,
+		file:         fileProvider,
+		remote:       remoteProvider,
+		pollInterval: chain.Duration("CONFIG_RELOAD_INTERVAL", 15*time.Second),
+	}
+	return cfg, nil
+}
+
+// buildFromChain resolves and validates a Config from chain. Both Load and
+// Watch's reload loop funnel through this so the field list and validation
+// rules are defined exactly once.
+func buildFromChain(chain *Chain) (*Config, error) {
 	cfg := &Config{
 		App: AppConfig{
-			Name:     getEnv("APP_NAME", "encurtador-url"),
-			Version:  getEnv("APP_VERSION", "0.1.0"),
-			Env:      getEnv("APP_ENV", "development"),
-			LogLevel: getEnv("LOG_LEVEL", "info"),
+			Name:     chain.String("APP_NAME", "encurtador-url"),
+			Version:  chain.String("APP_VERSION", "0.1.0"),
+			Env:      chain.String("APP_ENV", "development"),
+			LogLevel: chain.String("LOG_LEVEL", "info"),
 		},
 		Server: ServerConfig{
-			Port: getEnv("APP_PORT", "8080"),
-			Host: getEnv("APP_HOST", "localhost"),
+			Port:     chain.String("APP_PORT", "8080"),
+			Host:     chain.String("APP_HOST", "localhost"),
+			GRPCPort: chain.String("GRPC_PORT", "9090"),
 		},
 		MongoDB: MongoDBConfig{
-			URI:      getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-			Database: getEnv("MONGODB_DATABASE", "encurtador"),
+			URI:      chain.String("MONGODB_URI", "mongodb://localhost:27017"),
+			Database: chain.String("MONGODB_DATABASE", "encurtador"),
 		},
 		Redis: RedisConfig{
-			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvInt("REDIS_DB", 0),
+			Addr:     chain.String("REDIS_ADDR", "localhost:6379"),
+			Password: chain.String("REDIS_PASSWORD", ""),
+			DB:       chain.Int("REDIS_DB", 0),
 		},
 		Shortener: ShortenerConfig{
-			BaseURL:        getEnv("SHORTENER_BASE_URL", "http://localhost:8080"),
-			SlugLength:     getEnvInt("SLUG_LENGTH", 6),
-			RedirectStatus: getEnvInt("REDIRECT_STATUS", 302),
+			BaseURL:        chain.String("SHORTENER_BASE_URL", "http://localhost:8080"),
+			SlugLength:     chain.Int("SLUG_LENGTH", 6),
+			RedirectStatus: chain.Int("REDIRECT_STATUS", 302),
+			Slugger: SluggerConfig{
+				Strategy:   chain.String("SLUGGER_STRATEGY", "crypto"),
+				CounterKey: chain.String("SLUGGER_COUNTER_KEY", "slug:counter"),
+				SqidsSalt:  chain.String("SLUGGER_SQIDS_SALT", ""),
+			},
 		},
 		Security: SecurityConfig{
-			APIKeys: getEnvSlice("API_KEYS", nil),
+			APIKeys: chain.APIKeys("API_KEYS", nil),
+			JWT: JWTConfig{
+				JWKSURL: chain.String("JWT_JWKS_URL", ""),
+			},
+			CreateRate: CreateRateConfig{
+				Strategy:          chain.String("RATE_LIMITER_ALGORITHM", "fixed"),
+				RequestsPerMinute: chain.Int("CREATE_RATE_REQUESTS_PER_MINUTE", 30),
+			},
+			RateLimit: RateLimitConfig{
+				Strategy:          chain.String("SECURITY_RATE_STRATEGY", "fixed"),
+				RequestsPerWindow: chain.Int("SECURITY_RATE_REQUESTS", 60),
+				Window:            chain.Duration("SECURITY_RATE_WINDOW", time.Minute),
+				BucketCapacity:    int64(chain.Int("SECURITY_RATE_BUCKET_CAPACITY", 60)),
+				RefillPerSecond:   chain.Float("SECURITY_RATE_REFILL_PER_SECOND", 1),
+			},
 		},
 		OTel: OTelConfig{
-			Enabled:  getEnvBool("OTEL_ENABLED", false),
-			Endpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			Enabled:      chain.Bool("OTEL_ENABLED", false),
+			Endpoint:     chain.String("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			LogsEnabled:  chain.Bool("OTEL_LOGS_ENABLED", false),
+			LogsEndpoint: chain.String("OTEL_LOGS_ENDPOINT", ""),
+		},
+		Storage: StorageConfig{
+			Backend: chain.String("STORAGE_BACKEND", "mongo"),
+		},
+		Postgres: PostgresConfig{
+			Host:     chain.String("POSTGRES_HOST", "localhost"),
+			Port:     chain.String("POSTGRES_PORT", "5432"),
+			User:     chain.String("POSTGRES_USER", "postgres"),
+			Password: chain.String("POSTGRES_PASSWORD", ""),
+			Database: chain.String("POSTGRES_DATABASE", "encurtador"),
+			SSLMode:  chain.String("POSTGRES_SSLMODE", "disable"),
+		},
+		OutboxDrainer: OutboxDrainerConfig{
+			BatchSize:    chain.Int("OUTBOX_DRAINER_BATCH_SIZE", 200),
+			WorkerCount:  chain.Int("OUTBOX_DRAINER_WORKER_COUNT", 4),
+			PollInterval: chain.Duration("OUTBOX_DRAINER_POLL_INTERVAL", 250*time.Millisecond),
+		},
+		ClickStats: ClickStatsConfig{
+			Backend: chain.String("CLICK_STATS_BACKEND", "mongo"),
+			Kafka: ClickStatsKafkaConfig{
+				Brokers: chain.StringSlice("CLICK_STATS_KAFKA_BROKERS", nil),
+				Topic:   chain.String("CLICK_STATS_KAFKA_TOPIC", "clicks.counts"),
+				Security: kafkainfra.Config{
+					Compression:      chain.String("CLICK_STATS_KAFKA_COMPRESSION", ""),
+					SecurityProtocol: chain.String("CLICK_STATS_KAFKA_SECURITY_PROTOCOL", "plaintext"),
+					SASLMechanism:    chain.String("CLICK_STATS_KAFKA_SASL_MECHANISM", ""),
+					SASLUsername:     chain.String("CLICK_STATS_KAFKA_SASL_USERNAME", ""),
+					SASLPassword:     chain.String("CLICK_STATS_KAFKA_SASL_PASSWORD", ""),
+					TLSCAFile:        chain.String("CLICK_STATS_KAFKA_TLS_CA_FILE", ""),
+				},
+			},
+			NATS: ClickStatsNATSConfig{
+				URL:     chain.String("CLICK_STATS_NATS_URL", "nats://localhost:4222"),
+				Subject: chain.String("CLICK_STATS_NATS_SUBJECT", "clicks.counts"),
+			},
 		},
 	}
 
@@ -102,50 +342,107 @@ func Load() (*Config, error) {
 	if cfg.Shortener.SlugLength < 4 || cfg.Shortener.SlugLength > 32 {
 		return nil, fmt.Errorf("SLUG_LENGTH must be between 4 and 32 (got %d)", cfg.Shortener.SlugLength)
 	}
+	switch cfg.Security.RateLimit.Strategy {
+	case "fixed", "sliding", "token_bucket":
+	default:
+		return nil, fmt.Errorf("SECURITY_RATE_STRATEGY must be one of fixed|sliding|token_bucket (got %q)", cfg.Security.RateLimit.Strategy)
+	}
+	switch cfg.Security.CreateRate.Strategy {
+	case "fixed", "sliding":
+	default:
+		return nil, fmt.Errorf("RATE_LIMITER_ALGORITHM must be one of fixed|sliding (got %q)", cfg.Security.CreateRate.Strategy)
+	}
+	switch cfg.Shortener.Slugger.Strategy {
+	case "crypto", "counter", "sqids":
+	default:
+		return nil, fmt.Errorf("SLUGGER_STRATEGY must be one of crypto|counter|sqids (got %q)", cfg.Shortener.Slugger.Strategy)
+	}
+	switch cfg.Storage.Backend {
+	case "postgres", "mongo":
+	default:
+		return nil, fmt.Errorf("STORAGE_BACKEND must be one of postgres|mongo (got %q)", cfg.Storage.Backend)
+	}
+	switch cfg.ClickStats.Kafka.Security.SecurityProtocol {
+	case "plaintext", "ssl", "sasl_ssl":
+	default:
+		return nil, fmt.Errorf("CLICK_STATS_KAFKA_SECURITY_PROTOCOL must be one of plaintext|ssl|sasl_ssl (got %q)", cfg.ClickStats.Kafka.Security.SecurityProtocol)
+	}
+	switch cfg.ClickStats.Backend {
+	case "mongo", "kafka", "nats":
+	default:
+		return nil, fmt.Errorf("CLICK_STATS_BACKEND must be one of mongo|kafka|nats (got %q)", cfg.ClickStats.Backend)
+	}
+	if cfg.OutboxDrainer.BatchSize <= 0 {
+		return nil, fmt.Errorf("OUTBOX_DRAINER_BATCH_SIZE must be > 0 (got %d)", cfg.OutboxDrainer.BatchSize)
+	}
+	if cfg.OutboxDrainer.WorkerCount <= 0 {
+		return nil, fmt.Errorf("OUTBOX_DRAINER_WORKER_COUNT must be > 0 (got %d)", cfg.OutboxDrainer.WorkerCount)
+	}
+	if cfg.OutboxDrainer.PollInterval <= 0 {
+		return nil, fmt.Errorf("OUTBOX_DRAINER_POLL_INTERVAL must be > 0 (got %s)", cfg.OutboxDrainer.PollInterval)
+	}
 
 	return cfg, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Watch polls c's sources (the file's mtime, the remote store) every
+// pollInterval and emits a new snapshot whenever the resolved Config
+// changes and validates. A reload that fails validation is discarded - the
+// caller keeps running on the last good Config - and counted via
+// ReloadErrorsTotal instead of being sent. The returned channel is closed
+// when ctx is done. Calling Watch on a Config with no sources (e.g. one
+// built directly in a test) returns an already-closed channel.
+func (c *Config) Watch(ctx context.Context) <-chan *Config {
+	ch := make(chan *Config, 1)
+	if c.sources == nil {
+		close(ch)
+		return ch
 	}
-	return defaultValue
-}
 
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if i, err := strconv.Atoi(value); err == nil {
-			return i
-		}
-	}
-	return defaultValue
-}
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(c.sources.pollInterval)
+		defer ticker.Stop()
 
-func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if b, err := strconv.ParseBool(value); err == nil {
-			return b
-		}
-	}
-	return defaultValue
-}
+		current := c
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if c.sources.file != nil {
+					if _, err := c.sources.file.Reload(); err != nil {
+						logger.Warn("config reload: file source failed, keeping previous config", zap.Error(err))
+						ReloadErrorsTotal.Inc()
+						continue
+					}
+				}
+				if c.sources.remote != nil {
+					if err := c.sources.remote.refresh(); err != nil {
+						logger.Warn("config reload: remote source failed, keeping previous config", zap.Error(err))
+						ReloadErrorsTotal.Inc()
+						continue
+					}
+				}
 
-func getEnvSlice(key string, defaultValue []string) []string {
-	value := strings.TrimSpace(os.Getenv(key))
-	if value == "" {
-		return defaultValue
-	}
-	parts := strings.Split(value, ",")
-	out := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed != "" {
-			out = append(out, trimmed)
+				next, err := buildFromChain(c.sources.chain)
+				if err != nil {
+					logger.Warn("config reload: validation failed, keeping previous config", zap.Error(err))
+					ReloadErrorsTotal.Inc()
+					continue
+				}
+				next.sources = c.sources
+				if reflect.DeepEqual(next, current) {
+					continue
+				}
+
+				current = next
+				select {
+				case ch <- next:
+				default:
+				}
+			}
 		}
-	}
-	if len(out) == 0 {
-		return defaultValue
-	}
-	return out
+	}()
+	return ch
 }
@@ -3,7 +3,6 @@ package config
 import (
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -24,8 +23,8 @@ func GetEnvInt(key string, fallback int) int {
 	if value == "" {
 		return fallback
 	}
-	parsed, err := strconv.Atoi(value)
-	if err != nil {
+	parsed, ok := parseInt(value)
+	if !ok {
 		return fallback
 	}
 	return parsed
@@ -38,25 +37,31 @@ func GetEnvDuration(key string, fallback time.Duration) time.Duration {
 	if value == "" {
 		return fallback
 	}
-	d, err := time.ParseDuration(value)
-	if err != nil {
+	d, ok := parseDuration(value)
+	if !ok {
 		return fallback
 	}
 	return d
 }
 
+// GetEnvBool returns the environment variable parsed as a bool, or fallback
+// on missing/invalid values.
+func GetEnvBool(key string, fallback bool) bool {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	parsed, ok := parseBool(value)
+	if !ok {
+		return fallback
+	}
+	return parsed
+}
+
 // SplitCSV splits a comma-separated string into a slice, trimming whitespace
 // and discarding empty entries.
 func SplitCSV(raw string) []string {
-	parts := strings.Split(raw, ",")
-	out := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed != "" {
-			out = append(out, trimmed)
-		}
-	}
-	return out
+	return parseCSV(raw)
 }
 
 // DefaultPostgresDSN builds a PostgreSQL DSN from individual DB_* environment
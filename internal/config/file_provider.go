@@ -0,0 +1,106 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FileProvider reads KEY=value pairs from a flat file (the same format as
+// a .env file - one "KEY=value" per line, "#" comments, blank lines
+// ignored). It's a Provider in its own right and also tracks the file's
+// mtime so Config.Watch can tell when to re-read it.
+type FileProvider struct {
+	path string
+
+	mu     sync.RWMutex
+	values map[string]string
+	modAt  int64
+}
+
+// NewFileProvider reads path once and returns a FileProvider over its
+// contents. A missing file is not an error - it behaves as an empty
+// provider, since CONFIG_FILE is optional.
+func NewFileProvider(path string) (*FileProvider, error) {
+	fp := &FileProvider{path: path}
+	if _, err := fp.Reload(); err != nil {
+		return nil, err
+	}
+	return fp, nil
+}
+
+func (fp *FileProvider) Get(key string) (string, bool) {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+	v, ok := fp.values[key]
+	return v, ok
+}
+
+// Reload re-reads the file if its mtime has advanced since the last read,
+// returning whether it actually changed.
+func (fp *FileProvider) Reload() (bool, error) {
+	info, err := os.Stat(fp.path)
+	if os.IsNotExist(err) {
+		fp.mu.Lock()
+		changed := fp.values != nil
+		fp.values = map[string]string{}
+		fp.modAt = 0
+		fp.mu.Unlock()
+		return changed, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat config file %s: %w", fp.path, err)
+	}
+
+	modAt := info.ModTime().UnixNano()
+	fp.mu.RLock()
+	unchanged := fp.values != nil && modAt == fp.modAt
+	fp.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	values, err := parseEnvFile(fp.path)
+	if err != nil {
+		return false, err
+	}
+
+	fp.mu.Lock()
+	fp.values = values
+	fp.modAt = modAt
+	fp.mu.Unlock()
+	return true, nil
+}
+
+func parseEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open config file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read config file %s: %w", path, err)
+	}
+	return values, nil
+}
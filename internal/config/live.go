@@ -0,0 +1,41 @@
+package config
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Live holds the current Config behind an atomic.Pointer, so the handful
+// of subsystems that need to pick up a change without a redeploy (the
+// create-rate limiter's RPM, the redirect status code, API keys, ...) can
+// read Current() on every use instead of closing over a value snapshotted
+// at startup.
+type Live struct {
+	ptr atomic.Pointer[Config]
+}
+
+// NewLive wraps initial in a Live. initial is served until Run (or a
+// manual Store) replaces it.
+func NewLive(initial *Config) *Live {
+	l := &Live{}
+	l.ptr.Store(initial)
+	return l
+}
+
+// Current returns the most recently stored Config. Safe for concurrent use.
+func (l *Live) Current() *Config {
+	return l.ptr.Load()
+}
+
+// Store atomically replaces the current Config.
+func (l *Live) Store(cfg *Config) {
+	l.ptr.Store(cfg)
+}
+
+// Run subscribes to seed.Watch and stores every snapshot it emits until ctx
+// is done. Launch it as a goroutine right after building l from seed.
+func (l *Live) Run(ctx context.Context, seed *Config) {
+	for next := range seed.Watch(ctx) {
+		l.Store(next)
+	}
+}
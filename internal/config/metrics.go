@@ -0,0 +1,15 @@
+package config
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ReloadErrorsTotal counts Config.Watch reloads discarded because the
+// newly-resolved snapshot failed validation or a source couldn't be read,
+// so a bad edit to a config file/remote key shows up as an alertable
+// metric instead of silently doing nothing.
+var ReloadErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "config_reload_errors_total",
+	Help: "Total number of config reloads discarded due to a source or validation error.",
+})
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseInt, parseDuration, parseBool, and parseFloat back both the plain
+// GetEnv* helpers (env.go) and Chain's typed getters (provider.go), so the
+// two don't redefine the same strconv/time.ParseDuration calls twice.
+
+func parseInt(raw string) (int, bool) {
+	v, err := strconv.Atoi(raw)
+	return v, err == nil
+}
+
+func parseDuration(raw string) (time.Duration, bool) {
+	v, err := time.ParseDuration(raw)
+	return v, err == nil
+}
+
+func parseBool(raw string) (bool, bool) {
+	v, err := strconv.ParseBool(raw)
+	return v, err == nil
+}
+
+func parseFloat(raw string) (float64, bool) {
+	v, err := strconv.ParseFloat(raw, 64)
+	return v, err == nil
+}
+
+// parseCSV splits a comma-separated string into trimmed, non-empty entries.
+func parseCSV(raw string) []string {
+	parts := strings.Split(raw, ",")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
+
+// parseAPIKeys parses a comma-separated list of API keys, each optionally
+// carrying a "|"-separated scope set after a colon, e.g.
+// "key1:links:create|links:stats,key2:links:stats".
+func parseAPIKeys(raw string) []APIKeyConfig {
+	out := make([]APIKeyConfig, 0)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		keyPart, scopePart, hasScopes := strings.Cut(part, ":")
+		keyPart = strings.TrimSpace(keyPart)
+		if keyPart == "" {
+			continue
+		}
+
+		cfg := APIKeyConfig{Key: keyPart}
+		if hasScopes {
+			for _, s := range strings.Split(scopePart, "|") {
+				if s = strings.TrimSpace(s); s != "" {
+					cfg.Scopes = append(cfg.Scopes, s)
+				}
+			}
+		}
+		out = append(out, cfg)
+	}
+	return out
+}
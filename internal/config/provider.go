@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// Provider is a single key-value source config.Load consults: the process
+// environment, a config file, or a remote KV store (Consul, etcd).
+type Provider interface {
+	// Get returns the raw string value for key and whether it was present.
+	Get(key string) (string, bool)
+}
+
+// envProvider reads directly from the process environment.
+type envProvider struct{}
+
+func (envProvider) Get(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// Chain resolves a key by checking its providers in order and returning the
+// first one that has it. Precedence is therefore "earlier wins" - build the
+// chain with your highest-priority source first.
+//
+// config.Load's default chain is, highest precedence first: remote (Consul
+// KV, if CONFIG_REMOTE_CONSUL_ADDR is set) > process env > file (if
+// CONFIG_FILE is set). Remote wins over env because it's the one source an
+// operator can push a change to without touching any single instance; env
+// wins over file because file only supplies defaults a deployment bakes in
+// ahead of time.
+type Chain struct {
+	providers []Provider
+}
+
+// NewChain builds a Chain from providers in precedence order, highest first.
+func NewChain(providers ...Provider) *Chain {
+	return &Chain{providers: providers}
+}
+
+func (c *Chain) Get(key string) (string, bool) {
+	for _, p := range c.providers {
+		if v, ok := p.Get(key); ok && v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (c *Chain) String(key, fallback string) string {
+	if v, ok := c.Get(key); ok {
+		return v
+	}
+	return fallback
+}
+
+func (c *Chain) Int(key string, fallback int) int {
+	v, ok := c.Get(key)
+	if !ok {
+		return fallback
+	}
+	parsed, ok := parseInt(v)
+	if !ok {
+		return fallback
+	}
+	return parsed
+}
+
+func (c *Chain) Duration(key string, fallback time.Duration) time.Duration {
+	v, ok := c.Get(key)
+	if !ok {
+		return fallback
+	}
+	parsed, ok := parseDuration(v)
+	if !ok {
+		return fallback
+	}
+	return parsed
+}
+
+func (c *Chain) Bool(key string, fallback bool) bool {
+	v, ok := c.Get(key)
+	if !ok {
+		return fallback
+	}
+	parsed, ok := parseBool(v)
+	if !ok {
+		return fallback
+	}
+	return parsed
+}
+
+func (c *Chain) Float(key string, fallback float64) float64 {
+	v, ok := c.Get(key)
+	if !ok {
+		return fallback
+	}
+	parsed, ok := parseFloat(v)
+	if !ok {
+		return fallback
+	}
+	return parsed
+}
+
+func (c *Chain) StringSlice(key string, fallback []string) []string {
+	v, ok := c.Get(key)
+	if !ok {
+		return fallback
+	}
+	parsed := parseCSV(v)
+	if len(parsed) == 0 {
+		return fallback
+	}
+	return parsed
+}
+
+func (c *Chain) APIKeys(key string, fallback []APIKeyConfig) []APIKeyConfig {
+	v, ok := c.Get(key)
+	if !ok {
+		return fallback
+	}
+	parsed := parseAPIKeys(v)
+	if len(parsed) == 0 {
+		return fallback
+	}
+	return parsed
+}
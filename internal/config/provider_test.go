@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type mapProvider map[string]string
+
+func (m mapProvider) Get(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func TestChainPrecedence(t *testing.T) {
+	t.Run("earlier provider wins", func(t *testing.T) {
+		chain := NewChain(mapProvider{"KEY": "first"}, mapProvider{"KEY": "second"})
+		if got, _ := chain.Get("KEY"); got != "first" {
+			t.Errorf("got %q, want %q", got, "first")
+		}
+	})
+
+	t.Run("falls through to a later provider when earlier lacks the key", func(t *testing.T) {
+		chain := NewChain(mapProvider{}, mapProvider{"KEY": "second"})
+		if got, _ := chain.Get("KEY"); got != "second" {
+			t.Errorf("got %q, want %q", got, "second")
+		}
+	})
+
+	t.Run("String returns fallback when no provider has the key", func(t *testing.T) {
+		chain := NewChain(mapProvider{})
+		if got := chain.String("MISSING", "fb"); got != "fb" {
+			t.Errorf("got %q, want %q", got, "fb")
+		}
+	})
+
+	t.Run("Int parses through the chain", func(t *testing.T) {
+		chain := NewChain(mapProvider{"N": "42"})
+		if got := chain.Int("N", 0); got != 42 {
+			t.Errorf("got %d, want 42", got)
+		}
+	})
+}
+
+func TestFileProviderReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.env")
+
+	write := func(contents string) {
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+
+	write("FOO=bar\n")
+	fp, err := NewFileProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileProvider: %v", err)
+	}
+	if got, ok := fp.Get("FOO"); !ok || got != "bar" {
+		t.Errorf("got (%q, %v), want (%q, true)", got, ok, "bar")
+	}
+
+	t.Run("Reload picks up a change once mtime advances", func(t *testing.T) {
+		// Advance mtime explicitly instead of sleeping: some filesystems
+		// only have 1s mtime resolution.
+		future := time.Now().Add(time.Second)
+		write("FOO=baz\n")
+		if err := os.Chtimes(path, future, future); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+
+		changed, err := fp.Reload()
+		if err != nil {
+			t.Fatalf("Reload: %v", err)
+		}
+		if !changed {
+			t.Error("Reload returned changed=false, want true")
+		}
+		if got, _ := fp.Get("FOO"); got != "baz" {
+			t.Errorf("got %q, want %q", got, "baz")
+		}
+	})
+
+	t.Run("missing file is treated as empty, not an error", func(t *testing.T) {
+		fp, err := NewFileProvider(filepath.Join(dir, "does-not-exist.env"))
+		if err != nil {
+			t.Fatalf("NewFileProvider: %v", err)
+		}
+		if _, ok := fp.Get("ANYTHING"); ok {
+			t.Error("expected no keys from a missing file")
+		}
+	})
+}
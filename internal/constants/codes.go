@@ -15,6 +15,12 @@ const (
 	CodeInvalidURL   = "INVALID_URL"
 	CodeLinkExpired  = "LINK_EXPIRED"
 	CodeLinkNotFound = "LINK_NOT_FOUND"
+	CodeInvalidSlug  = "INVALID_SLUG"
+	CodeSlugTaken    = "SLUG_TAKEN"
+
+	// Idempotency-Key codes
+	CodeIdempotencyConflict = "IDEMPOTENCY_CONFLICT"
+	CodeIdempotencyInFlight = "IDEMPOTENCY_IN_FLIGHT"
 
 	// Success codes
 	CodeLinkCreated = "LINK_CREATED"
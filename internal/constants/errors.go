@@ -40,6 +40,11 @@ var (
 		Message: MsgUnauthorized,
 		Status:  http.StatusUnauthorized,
 	}
+	ErrForbidden = APIError{
+		Code:    CodeForbidden,
+		Message: MsgForbidden,
+		Status:  http.StatusForbidden,
+	}
 
 	// Shortener-specific errors
 	ErrInvalidURL = APIError{
@@ -52,4 +57,30 @@ var (
 		Message: MsgLinkNotFound,
 		Status:  http.StatusNotFound,
 	}
+	ErrInvalidSlug = APIError{
+		Code:    CodeInvalidSlug,
+		Message: MsgInvalidSlug,
+		Status:  http.StatusBadRequest,
+	}
+	ErrSlugTaken = APIError{
+		Code:    CodeSlugTaken,
+		Message: MsgSlugTaken,
+		Status:  http.StatusConflict,
+	}
+	ErrRateLimited = APIError{
+		Code:    CodeRateLimited,
+		Message: MsgRateLimited,
+		Status:  http.StatusTooManyRequests,
+	}
+
+	ErrIdempotencyConflict = APIError{
+		Code:    CodeIdempotencyConflict,
+		Message: MsgIdempotencyConflict,
+		Status:  http.StatusConflict,
+	}
+	ErrIdempotencyInFlight = APIError{
+		Code:    CodeIdempotencyInFlight,
+		Message: MsgIdempotencyInFlight,
+		Status:  http.StatusTooEarly,
+	}
 )
@@ -8,10 +8,17 @@ const (
 	MsgInternalError      = "An internal error occurred"
 	MsgNotFound           = "Resource not found"
 	MsgUnauthorized       = "Unauthorized"
+	MsgForbidden          = "Insufficient scope for this operation"
 	MsgRateLimited        = "Rate limit exceeded"
 
 	// Shortener-specific messages
 	MsgInvalidURL   = "Invalid URL (must be http or https)"
 	MsgLinkNotFound = "Link not found"
 	MsgLinkExpired  = "Link expired"
+	MsgInvalidSlug  = "Custom slug must be 3-40 letters, digits, underscores or hyphens, and not a reserved word"
+	MsgSlugTaken    = "That slug is already taken"
+
+	// Idempotency-Key messages
+	MsgIdempotencyConflict = "Idempotency-Key was reused with a different request body"
+	MsgIdempotencyInFlight = "A request with this Idempotency-Key is still being processed"
 )
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -12,11 +13,16 @@ type Postgres struct {
 	Pool *pgxpool.Pool
 }
 
+// ConnectPostgres connects to Postgres with OpenTelemetry instrumentation,
+// the pgx equivalent of ConnectMongo's otelmongo.NewMonitor wiring: every
+// query the pool runs is traced automatically instead of repositories
+// having to start a span for each one by hand.
 func ConnectPostgres(ctx context.Context, dsn string) (*Postgres, error) {
 	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse postgres config: %w", err)
 	}
+	poolConfig.ConnConfig.Tracer = otelpgx.NewTracer()
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type loggerContextKey struct{}
+
+// NewContext stores l in ctx for FromContext to retrieve. Most callers want
+// WithFields instead; NewContext is for carrying an already-built logger
+// (for example, a request-scoped one) into a context that otherwise
+// wouldn't have one, such as a context.Background() handed to a goroutine
+// that outlives the request.
+func NewContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// WithFields returns a context carrying a logger that's FromContext(ctx)
+// (or the global Log, or a no-op logger if neither is set) with fields
+// added, so every FromContext call downstream includes them automatically.
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(fields...))
+}
+
+// FromContext returns the logger stored in ctx by WithFields/NewContext
+// (or the global Log, or a no-op logger if Init hasn't run - so callers
+// never need a nil check), with trace_id/span_id fields added when ctx
+// carries a valid OpenTelemetry span. That's what lets an OTLP log record
+// emitted through this logger be correlated back to the trace/span that
+// was active when it was written.
+func FromContext(ctx context.Context) *zap.Logger {
+	l := loggerFromContext(ctx)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		l = l.With(
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return l
+}
+
+func loggerFromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(loggerContextKey{}).(*zap.Logger); ok {
+		return l
+	}
+	if Log != nil {
+		return Log
+	}
+	return zap.NewNop()
+}
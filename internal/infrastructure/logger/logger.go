@@ -8,8 +8,23 @@ import (
 // Log is the global logger instance
 var Log *zap.Logger
 
-// Init initializes the Zap logger with JSON output
+// Options configures optional extras for InitWithOptions.
+type Options struct {
+	// OTelCore, if set, receives a copy of every log record alongside the
+	// normal stdout JSON encoder, so a deployment with OTEL_LOGS_ENABLED
+	// gets the same records in its OTLP collector. See
+	// telemetry.InitLogs.
+	OTelCore zapcore.Core
+}
+
+// Init initializes the Zap logger with JSON output.
 func Init(env string) error {
+	return InitWithOptions(env, Options{})
+}
+
+// InitWithOptions is Init with opts.OTelCore additionally tee'd into every
+// log record.
+func InitWithOptions(env string, opts Options) error {
 	config := zap.Config{
 		Level:       zap.NewAtomicLevelAt(zap.InfoLevel),
 		Development: env == "development",
@@ -38,6 +53,12 @@ func Init(env string) error {
 		return err
 	}
 
+	if opts.OTelCore != nil {
+		Log = Log.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(c, opts.OTelCore)
+		}))
+	}
+
 	zap.ReplaceGlobals(Log)
 	return nil
 }
@@ -0,0 +1,74 @@
+package telemetry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// These cover the click ingestion pipeline end to end - enqueue/drop happen
+// in BufferedClickStatsRepository.IncDaily, flushed/flush_duration in its
+// loop, and redirect_latency in LinksHandler.Redirect - so an operator can
+// see queue pressure and redirect latency on the same dashboard.
+var (
+	ClicksEnqueuedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "clicks_enqueued_total",
+			Help: "Total number of click events accepted onto the buffered stats queue.",
+		},
+	)
+
+	// ClicksOverflowTotal is labeled by outcome - "drop_newest" (the
+	// incoming event), "drop_oldest" (the queue's oldest event, evicted to
+	// make room), "blocked_timeout" (Block gave up waiting), "sampled_drop"
+	// (Sample rolled against the event), or "flush_failed" (a buffered
+	// recorder's flush to the sink kept failing and the batch was dropped)
+	// - so an operator can tell which outcome is firing without
+	// cross-referencing config.
+	ClicksOverflowTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "clicks_overflow_total",
+			Help: "Total number of click events lost to queue overflow, by outcome.",
+		},
+		[]string{"outcome"},
+	)
+
+	ClicksFlushedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "clicks_flushed_total",
+			Help: "Total number of click events written to storage by a buffered stats flush.",
+		},
+	)
+
+	FlushDurationSeconds = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "flush_duration_seconds",
+			Help:    "Duration of a buffered click stats flush to storage.",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+	)
+
+	RedirectLatencySeconds = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "redirect_latency_seconds",
+			Help:    "Duration of LinksHandler.Redirect from slug lookup to response written.",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+	)
+
+	// HotCacheHitsTotal and HotCacheMissesTotal track links.HotCache's hit
+	// rate, the in-process cache Service.Resolve checks before falling back
+	// to LinkRepository.FindBySlug.
+	HotCacheHitsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hot_cache_hits_total",
+			Help: "Total number of Service.Resolve lookups served from the in-process hot-slug cache.",
+		},
+	)
+
+	HotCacheMissesTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hot_cache_misses_total",
+			Help: "Total number of Service.Resolve lookups not found (or expired) in the in-process hot-slug cache.",
+		},
+	)
+)
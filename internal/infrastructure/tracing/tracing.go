@@ -0,0 +1,74 @@
+// Package tracing gives the service and repository layers a uniform way to
+// produce OpenTelemetry spans, mirroring what otelhttp/otelmongo/otelpgx
+// already do automatically for the HTTP, Mongo, and Postgres transports.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxStatementLen = 512
+
+// StartSpan starts a span named "<component>.<operation>" (e.g.
+// "postgres.links.Insert"), records fn's error as the span's status, and
+// ends the span before returning - the same record-error/set-status/End
+// sequence cmd/outbox_worker already hand-rolls around its Kafka publish
+// span, pulled out so repositories and Service don't have to repeat it.
+func StartSpan(ctx context.Context, component, operation string, attrs []attribute.KeyValue, fn func(ctx context.Context) error) error {
+	tracer := otel.Tracer(component)
+	ctx, span := tracer.Start(ctx, component+"."+operation, trace.WithAttributes(attrs...))
+	defer span.End()
+
+	if err := fn(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// SlugAttr tags a span with the slug it operated on.
+func SlugAttr(slug string) attribute.KeyValue {
+	return attribute.String("link.slug", slug)
+}
+
+// DBSystemAttr tags a repository span with the backing store it queried,
+// following OpenTelemetry's db.system semantic convention.
+func DBSystemAttr(system string) attribute.KeyValue {
+	return attribute.String("db.system", system)
+}
+
+// BatchSizeAttr tags an outbox span with the number of events it claimed
+// or processed.
+func BatchSizeAttr(n int) attribute.KeyValue {
+	return attribute.Int("outbox.batch_size", n)
+}
+
+// DayKeyAttr tags a click-stats span with the YYYYMMDD day key a count was
+// recorded or flushed against.
+func DayKeyAttr(day int32) attribute.KeyValue {
+	return attribute.Int64("click.day_key", int64(day))
+}
+
+// DroppedAttr tags a click-stats span with the running total of events
+// dropped because the buffered queue was full.
+func DroppedAttr(n int64) attribute.KeyValue {
+	return attribute.Int64("click.dropped", n)
+}
+
+// RedactStatement builds a db.statement attribute from a query string.
+// Every query in this tree is a static literal parameterized with $N/?
+// placeholders rather than interpolated values, so there's no bound data
+// to strip - the only thing worth guarding against is an unbounded
+// statement blowing up span size, hence the length cap.
+func RedactStatement(stmt string) attribute.KeyValue {
+	if len(stmt) > maxStatementLen {
+		stmt = stmt[:maxStatementLen] + "...(truncated)"
+	}
+	return attribute.String("db.statement", stmt)
+}
@@ -0,0 +1,267 @@
+// Package consumer wraps kafka-go's reader-based consumer group support
+// behind a small Handler interface, so a binary that wants to consume a
+// topic only has to decode a message and say what to do with it - group
+// membership, offset commits, retry backoff, and dead-letter forwarding are
+// handled once here instead of once per consumer binary.
+package consumer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/outbox"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// DLQHeader is added to every message forwarded to the dead-letter topic,
+// carrying the error that exhausted the Handler's retry budget.
+const DLQHeader = "x-dlq-reason"
+
+// Message is the consumer's transport-agnostic view of a fetched record,
+// decoupling Handler implementations from kafka-go's own Message type the
+// same way outbox.Event decouples Sink implementations from the storage
+// layer's native types.
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []kafka.Header
+	Time      time.Time
+}
+
+// Handler processes one decoded message. A non-nil error schedules a retry
+// per Config.RetryPolicy, up to its attempt budget, after which the message
+// is forwarded to Config.DLQTopic instead.
+type Handler interface {
+	Handle(ctx context.Context, msg Message) error
+}
+
+// Config configures a Consumer.
+type Config struct {
+	Brokers []string
+	Topic   string
+	GroupID string
+
+	// DLQTopic receives messages whose Handler never succeeded within
+	// RetryPolicy's attempt budget, with their original headers plus
+	// DLQHeader. Left empty, exhausted messages are just logged (there
+	// would otherwise be nowhere durable to put them).
+	DLQTopic string
+
+	// RetryPolicy decides the delay and give-up point between Handler
+	// attempts. A nil value defaults to outbox.NewSimpleBackoff(250ms, 5s,
+	// 5) - plain exponential backoff with jitter, so a burst of messages
+	// failing together (e.g. a downstream dependency blip) doesn't retry in
+	// lockstep.
+	RetryPolicy outbox.BackoffPolicy
+
+	MinBytes int
+	MaxBytes int
+	MaxWait  time.Duration
+}
+
+// Consumer reads Topic as part of GroupID, dispatching each fetched message
+// to a Handler with retry and (optionally) dead-letter forwarding on
+// exhaustion.
+type Consumer struct {
+	reader  *kafka.Reader
+	dlq     *kafka.Writer
+	handler Handler
+	policy  outbox.BackoffPolicy
+	tracer  trace.Tracer
+}
+
+// New builds a Consumer. The caller owns calling Run and Close.
+func New(cfg Config, handler Handler) *Consumer {
+	if cfg.MinBytes <= 0 {
+		cfg.MinBytes = 1
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 10e6
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = 500 * time.Millisecond
+	}
+	if cfg.RetryPolicy == nil {
+		cfg.RetryPolicy = outbox.NewSimpleBackoff(250*time.Millisecond, 5*time.Second, 5)
+	}
+
+	c := &Consumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     cfg.Brokers,
+			Topic:       cfg.Topic,
+			GroupID:     cfg.GroupID,
+			MinBytes:    cfg.MinBytes,
+			MaxBytes:    cfg.MaxBytes,
+			MaxWait:     cfg.MaxWait,
+			StartOffset: kafka.FirstOffset,
+		}),
+		handler: handler,
+		policy:  cfg.RetryPolicy,
+		tracer:  otel.Tracer("messaging-consumer"),
+	}
+	if strings.TrimSpace(cfg.DLQTopic) != "" {
+		c.dlq = &kafka.Writer{
+			Addr:                   kafka.TCP(cfg.Brokers...),
+			Topic:                  cfg.DLQTopic,
+			Balancer:               &kafka.Hash{},
+			AllowAutoTopicCreation: true,
+		}
+	}
+	return c
+}
+
+// Run fetches and dispatches messages until ctx is canceled or the reader
+// returns an unrecoverable error.
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+
+		c.process(ctx, msg)
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil {
+			logger.Error("failed to commit kafka offset",
+				zap.Error(err),
+				zap.String("topic", msg.Topic),
+				zap.Int("partition", msg.Partition),
+				zap.Int64("offset", msg.Offset),
+			)
+		}
+	}
+}
+
+// process dispatches msg to Handler, retrying per the configured policy and
+// forwarding to the dead-letter topic if the retry budget is exhausted.
+// Offsets are always committed by the caller afterward - a message that
+// ends up dead-lettered should not block the partition behind it.
+func (c *Consumer) process(ctx context.Context, msg kafka.Message) {
+	consumeCtx := kafkaHeadersToContext(ctx, msg.Headers)
+	cmsg := Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   msg.Headers,
+		Time:      msg.Time,
+	}
+
+	attempt := 0
+	for {
+		attempt++
+		spanCtx, span := c.tracer.Start(
+			consumeCtx,
+			"kafka.consume."+msg.Topic,
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				attribute.String("messaging.system", "kafka"),
+				attribute.String("messaging.destination.name", msg.Topic),
+				attribute.String("messaging.operation", "process"),
+				attribute.Int("messaging.kafka.partition", msg.Partition),
+				attribute.Int64("messaging.kafka.offset", msg.Offset),
+				attribute.Int("messaging.retry.attempt", attempt),
+			),
+		)
+
+		err := c.handler.Handle(spanCtx, cmsg)
+		if err == nil {
+			span.End()
+			return
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "handler failed")
+		span.End()
+
+		delay, terminal := c.policy.Next(attempt, err)
+		if terminal {
+			logger.Error("handler exhausted retries, forwarding to dead-letter topic",
+				zap.Error(err),
+				zap.String("topic", msg.Topic),
+				zap.Int("partition", msg.Partition),
+				zap.Int64("offset", msg.Offset),
+			)
+			c.sendToDLQ(ctx, msg, err)
+			return
+		}
+
+		logger.Warn("handler failed, retrying",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", delay),
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Consumer) sendToDLQ(ctx context.Context, msg kafka.Message, cause error) {
+	if c.dlq == nil {
+		return
+	}
+
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers, kafka.Header{Key: DLQHeader, Value: []byte(cause.Error())})
+
+	if err := c.dlq.WriteMessages(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+		Time:    msg.Time,
+	}); err != nil {
+		logger.Error("failed to forward message to dead-letter topic",
+			zap.Error(err),
+			zap.String("dlq_topic", c.dlq.Topic),
+		)
+	}
+}
+
+// Close releases the underlying reader and (if configured) dead-letter
+// writer.
+func (c *Consumer) Close() error {
+	err := c.reader.Close()
+	if c.dlq != nil {
+		if dlqErr := c.dlq.Close(); dlqErr != nil && err == nil {
+			err = dlqErr
+		}
+	}
+	return err
+}
+
+// kafkaHeadersToContext extracts a W3C trace context propagated through
+// Kafka message headers, the inverse of the outbox Kafka sink's
+// carrierToKafkaHeaders.
+func kafkaHeadersToContext(parent context.Context, headers []kafka.Header) context.Context {
+	carrier := propagation.MapCarrier{}
+	for _, header := range headers {
+		key := strings.ToLower(strings.TrimSpace(header.Key))
+		if key == "" {
+			continue
+		}
+		carrier.Set(key, string(header.Value))
+	}
+	return otel.GetTextMapPropagator().Extract(parent, carrier)
+}
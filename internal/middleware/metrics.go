@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var apiKeyRequestsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "api_key_requests_total",
+		Help: "Total number of requests rate-limited per API key, labeled by key ID and outcome",
+	},
+	[]string{"key_id", "outcome"},
+)
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LimitDecision mirrors redis.LimitDecision so this package's rate limiter
+// can sit behind the same Limiter shape without importing internal/storage/redis
+// into its exported API.
+type LimitDecision struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	RetryAfter int64
+}
+
+// Limiter is implemented by each rate-limiting backend KeyRateLimitMiddleware
+// can run against: InMemoryTokenBucketLimiter by default, or a Redis-backed
+// one for multi-instance deployments.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (LimitDecision, error)
+}
+
+// InMemoryTokenBucketLimiter is the default per-key limiter: a token bucket
+// per API key held in process memory. Fine for a single instance; deployments
+// that run more than one replica should supply a Redis-backed Limiter instead.
+type InMemoryTokenBucketLimiter struct {
+	capacity     float64
+	refillPerSec float64
+	now          func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucketState
+}
+
+type bucketState struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+func NewInMemoryTokenBucketLimiter(capacity int64, refillPerSec float64) *InMemoryTokenBucketLimiter {
+	if capacity <= 0 {
+		capacity = 60
+	}
+	if refillPerSec <= 0 {
+		refillPerSec = 1
+	}
+	return &InMemoryTokenBucketLimiter{
+		capacity:     float64(capacity),
+		refillPerSec: refillPerSec,
+		now:          time.Now,
+		buckets:      make(map[string]*bucketState),
+	}
+}
+
+func (l *InMemoryTokenBucketLimiter) Allow(_ context.Context, key string) (LimitDecision, error) {
+	if key == "" {
+		key = "unknown"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	state, ok := l.buckets[key]
+	if !ok {
+		state = &bucketState{tokens: l.capacity, lastSeen: now}
+		l.buckets[key] = state
+	}
+
+	elapsed := now.Sub(state.lastSeen).Seconds()
+	state.tokens = math.Min(l.capacity, state.tokens+elapsed*l.refillPerSec)
+	state.lastSeen = now
+
+	if state.tokens < 1 {
+		retryAfter := int64(math.Ceil(1 / l.refillPerSec))
+		if retryAfter <= 0 {
+			retryAfter = 1
+		}
+		return LimitDecision{
+			Allowed:    false,
+			Limit:      int64(l.capacity),
+			Remaining:  0,
+			RetryAfter: retryAfter,
+		}, nil
+	}
+
+	state.tokens--
+	return LimitDecision{
+		Allowed:   true,
+		Limit:     int64(l.capacity),
+		Remaining: int64(state.tokens),
+	}, nil
+}
+
+// RedisLimiter is the subset of redis.Limiter this package depends on, kept
+// as a local mirror so internal/middleware doesn't import internal/storage/redis
+// just to accept an optional backend.
+type RedisLimiter interface {
+	Allow(ctx context.Context, key string) (RedisLimitDecision, error)
+}
+
+// RedisLimitDecision mirrors redis.LimitDecision field-for-field.
+type RedisLimitDecision struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	RetryAfter int64
+}
+
+// redisLimiterAdapter adapts a RedisLimiter to this package's Limiter
+// interface.
+type redisLimiterAdapter struct {
+	backend RedisLimiter
+}
+
+// NewRedisBackedLimiter wraps a Redis-backed limiter (see
+// internal/storage/redis.Limiter) so KeyRateLimitMiddleware can run against
+// it instead of InMemoryTokenBucketLimiter, for deployments with more than
+// one instance.
+func NewRedisBackedLimiter(backend RedisLimiter) Limiter {
+	return redisLimiterAdapter{backend: backend}
+}
+
+func (a redisLimiterAdapter) Allow(ctx context.Context, key string) (LimitDecision, error) {
+	d, err := a.backend.Allow(ctx, key)
+	if err != nil {
+		return LimitDecision{}, err
+	}
+	return LimitDecision{
+		Allowed:    d.Allowed,
+		Limit:      d.Limit,
+		Remaining:  d.Remaining,
+		RetryAfter: d.RetryAfter,
+	}, nil
+}
+
+// KeyRateLimitMiddleware enforces limiter per caller API key, emitting the
+// standard X-RateLimit-* headers and an apiKeyRequestsTotal counter labeled
+// by key ID and outcome. Requests with no resolved caller (AuthMiddleware
+// didn't run, or ran open) are passed through unmetered.
+func KeyRateLimitMiddleware(limiter Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller, ok := CallerFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision, err := limiter.Allow(r.Context(), caller.ID)
+			if err != nil {
+				apiKeyRequestsTotal.WithLabelValues(caller.ID, "error").Inc()
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(decision.Limit, 10))
+			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(decision.Remaining, 10))
+
+			if !decision.Allowed {
+				w.Header().Set("Retry-After", strconv.FormatInt(decision.RetryAfter, 10))
+				apiKeyRequestsTotal.WithLabelValues(caller.ID, "rate_limited").Inc()
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			apiKeyRequestsTotal.WithLabelValues(caller.ID, "allowed").Inc()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
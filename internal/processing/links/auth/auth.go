@@ -0,0 +1,166 @@
+// Package auth issues and verifies the JWTs links.Service uses to resolve a
+// caller's identity for ownership checks, independent of
+// internal/transport/http/middleware's JWKS-based bearer verification,
+// which trusts an external IdP rather than signing tokens itself.
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnauthorized is returned for a token that doesn't parse, isn't signed
+// by a known key, or has expired/isn't valid yet - anything that means "we
+// don't know who this caller is". ErrForbidden covers the opposite case:
+// the caller is known but isn't allowed to do this.
+var ErrUnauthorized = errors.New("auth: invalid or unverifiable token")
+
+// ErrForbidden is returned when a verified caller's claims don't authorize
+// the action attempted.
+var ErrForbidden = errors.New("auth: caller not authorized for this resource")
+
+// Claims is what Verifier hands back and Service reads to authorize a
+// request: who issued the token, who it identifies - Subject is the owner
+// id CreateLink stamps onto Link.OwnerSubject - and what it's scoped to do.
+type Claims struct {
+	Issuer  string
+	Subject string
+	Scopes  []string
+}
+
+// HasScope reports whether scope is among the claims' granted scopes.
+func (c Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenClaims is the JWT wire format Sign/Verify read and write, embedding
+// the registered iss/sub/exp/nbf/iat claims plus a space-separated scope
+// string - the same shape transport/http/middleware's JWTMiddleware reads
+// off externally-issued tokens.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+// Signer issues a token for claims, valid for ttl from now.
+type Signer interface {
+	Sign(claims Claims, ttl time.Duration) (string, error)
+}
+
+// HMACSigner issues HS256 tokens under kid, for a deployment that shares one
+// secret between issuer and verifier.
+type HMACSigner struct {
+	kid    string
+	secret []byte
+}
+
+// NewHMACSigner builds an HMACSigner that stamps kid into every token's
+// header so a verifier with multiple configured secrets knows which one to
+// check against.
+func NewHMACSigner(kid string, secret []byte) *HMACSigner {
+	return &HMACSigner{kid: kid, secret: secret}
+}
+
+func (s *HMACSigner) Sign(claims Claims, ttl time.Duration) (string, error) {
+	return sign(jwt.SigningMethodHS256, s.kid, s.secret, claims, ttl)
+}
+
+// RSASigner issues RS256 tokens under kid, for a deployment that wants
+// verification to only ever need the public half of the key.
+type RSASigner struct {
+	kid string
+	key *rsa.PrivateKey
+}
+
+// NewRSASigner builds an RSASigner that stamps kid into every token's
+// header, the RS256 counterpart to NewHMACSigner.
+func NewRSASigner(kid string, key *rsa.PrivateKey) *RSASigner {
+	return &RSASigner{kid: kid, key: key}
+}
+
+func (s *RSASigner) Sign(claims Claims, ttl time.Duration) (string, error) {
+	return sign(jwt.SigningMethodRS256, s.kid, s.key, claims, ttl)
+}
+
+func sign(method jwt.SigningMethod, kid string, key any, claims Claims, ttl time.Duration) (string, error) {
+	now := time.Now().UTC()
+	token := jwt.NewWithClaims(method, tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    claims.Issuer,
+			Subject:   claims.Subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Scope: strings.Join(claims.Scopes, " "),
+	})
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
+// KeySet resolves a kid to the key it was signed with - a []byte secret for
+// HS256, an *rsa.PublicKey for RS256 - so Verifier can hold several keys at
+// once and an issuer can rotate onto a new kid before retiring the old one.
+type KeySet map[string]any
+
+// Verifier checks a token against keys, requiring the token's signing
+// method match the resolved key's type so a token can't downgrade its own
+// verification (e.g. an HMAC token claiming to be checked against an RSA
+// public key).
+type Verifier struct {
+	keys KeySet
+}
+
+// NewVerifier builds a Verifier over keys.
+func NewVerifier(keys KeySet) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// Verify parses and verifies raw, checking its kid against v's KeySet and
+// its exp/nbf against the current time (both enforced by jwt.ParseWithClaims
+// itself). Any failure collapses to ErrUnauthorized - callers don't need to
+// distinguish a malformed token from an expired one.
+func (v *Verifier) Verify(raw string) (Claims, error) {
+	claims := &tokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("auth: token missing kid header")
+		}
+		key, ok := v.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("auth: no key found for kid %q", kid)
+		}
+
+		switch key.(type) {
+		case []byte:
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("auth: unexpected signing method %q for an HMAC key", t.Method.Alg())
+			}
+		case *rsa.PublicKey:
+			if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, fmt.Errorf("auth: unexpected signing method %q for an RSA key", t.Method.Alg())
+			}
+		}
+		return key, nil
+	})
+	if err != nil {
+		return Claims{}, ErrUnauthorized
+	}
+
+	out := Claims{Issuer: claims.Issuer, Subject: claims.Subject}
+	if claims.Scope != "" {
+		out.Scopes = strings.Fields(claims.Scope)
+	}
+	return out, nil
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHMACSignerAndVerifier(t *testing.T) {
+	signer := NewHMACSigner("kid-1", []byte("super-secret"))
+	verifier := NewVerifier(KeySet{"kid-1": []byte("super-secret")})
+
+	t.Run("round trip preserves claims", func(t *testing.T) {
+		token, err := signer.Sign(Claims{Issuer: "links", Subject: "owner-1", Scopes: []string{"links:read", "links:write"}}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.Issuer != "links" || claims.Subject != "owner-1" {
+			t.Errorf("got claims %+v, want issuer=links subject=owner-1", claims)
+		}
+		if !claims.HasScope("links:read") || !claims.HasScope("links:write") {
+			t.Errorf("got scopes %v, want links:read and links:write", claims.Scopes)
+		}
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		token, err := signer.Sign(Claims{Subject: "owner-1"}, -time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := verifier.Verify(token); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("got %v, want ErrUnauthorized", err)
+		}
+	})
+
+	t.Run("unknown kid is rejected", func(t *testing.T) {
+		other := NewVerifier(KeySet{"kid-2": []byte("a-different-secret")})
+		token, err := signer.Sign(Claims{Subject: "owner-1"}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := other.Verify(token); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("got %v, want ErrUnauthorized", err)
+		}
+	})
+
+	t.Run("wrong secret is rejected", func(t *testing.T) {
+		other := NewVerifier(KeySet{"kid-1": []byte("wrong-secret")})
+		token, err := signer.Sign(Claims{Subject: "owner-1"}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := other.Verify(token); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("got %v, want ErrUnauthorized", err)
+		}
+	})
+}
+
+func TestRSASignerAndVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer := NewRSASigner("kid-rsa", key)
+	verifier := NewVerifier(KeySet{"kid-rsa": &key.PublicKey})
+
+	token, err := signer.Sign(Claims{Subject: "owner-1"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "owner-1" {
+		t.Errorf("got subject %q, want owner-1", claims.Subject)
+	}
+}
+
+func TestVerifyRejectsAlgorithmDowngrade(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("HMAC token against an RSA key slot", func(t *testing.T) {
+		hmacSigner := NewHMACSigner("kid-shared", []byte("super-secret"))
+		verifier := NewVerifier(KeySet{"kid-shared": &key.PublicKey})
+
+		token, err := hmacSigner.Sign(Claims{Subject: "owner-1"}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := verifier.Verify(token); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("got %v, want ErrUnauthorized", err)
+		}
+	})
+
+	t.Run("RSA token against an HMAC key slot", func(t *testing.T) {
+		rsaSigner := NewRSASigner("kid-shared", key)
+		verifier := NewVerifier(KeySet{"kid-shared": []byte("super-secret")})
+
+		token, err := rsaSigner.Sign(Claims{Subject: "owner-1"}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := verifier.Verify(token); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("got %v, want ErrUnauthorized", err)
+		}
+	})
+}
+
+func TestVerifyRejectsGarbageToken(t *testing.T) {
+	verifier := NewVerifier(KeySet{"kid-1": []byte("super-secret")})
+	if _, err := verifier.Verify("not-a-jwt"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got %v, want ErrUnauthorized", err)
+	}
+}
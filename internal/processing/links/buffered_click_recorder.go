@@ -0,0 +1,249 @@
+package links
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/telemetry"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// BufferedClickRecorder is a ClickOutboxRepository that coalesces
+// Service.RecordClick's EnqueueClick calls in memory instead of hitting
+// storage once per redirect: EnqueueClick just sends on a bounded channel,
+// and a single background goroutine groups pending clicks by (slug, day)
+// and periodically flushes the compacted counts through a ClickSink's
+// multi-row upsert. It's the storage-agnostic counterpart of what a
+// deployment's own buffered stats repository already does at the storage
+// layer (see mongo.BufferedClickStatsRepository) - use this one when the
+// configured ClickSink's backing store doesn't provide its own buffering.
+type BufferedClickRecorder struct {
+	sink ClickSink
+
+	queue        chan clickEvent
+	flushEvery   time.Duration
+	maxBatch     int
+	flushTimeout time.Duration
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+type clickEvent struct {
+	slug string
+	day  string // YYYY-MM-DD (UTC)
+}
+
+type slugDateKey struct {
+	slug string
+	day  string
+}
+
+// BufferedClickRecorderOptions configures BufferedClickRecorder. Zero values
+// fall back to sensible defaults - see NewBufferedClickRecorder.
+type BufferedClickRecorderOptions struct {
+	// QueueSize bounds how many not-yet-flushed clicks EnqueueClick can
+	// buffer before it starts dropping. Defaults to 100_000.
+	QueueSize int
+	// FlushInterval is the longest a click waits in the buffer before being
+	// flushed, even if MaxBatchEvents hasn't been reached. Defaults to
+	// 250ms.
+	FlushInterval time.Duration
+	// MaxBatchEvents flushes early once this many clicks have accumulated
+	// since the last flush, so a traffic spike doesn't wait out the full
+	// FlushInterval. Defaults to 50_000.
+	MaxBatchEvents int
+	// FlushTimeout bounds a single flush's call to ClickSink.Flush.
+	// Defaults to 2s.
+	FlushTimeout time.Duration
+}
+
+// NewBufferedClickRecorder builds a BufferedClickRecorder flushing through
+// sink and starts its background flush loop; call Close to drain and stop
+// it.
+func NewBufferedClickRecorder(sink ClickSink, opts BufferedClickRecorderOptions) *BufferedClickRecorder {
+	const (
+		defaultQueueSize      = 100_000
+		defaultFlushInterval  = 250 * time.Millisecond
+		defaultMaxBatchEvents = 50_000
+		defaultFlushTimeout   = 2 * time.Second
+	)
+
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.MaxBatchEvents <= 0 {
+		opts.MaxBatchEvents = defaultMaxBatchEvents
+	}
+	if opts.FlushTimeout <= 0 {
+		opts.FlushTimeout = defaultFlushTimeout
+	}
+
+	r := &BufferedClickRecorder{
+		sink:         sink,
+		queue:        make(chan clickEvent, opts.QueueSize),
+		flushEvery:   opts.FlushInterval,
+		maxBatch:     opts.MaxBatchEvents,
+		flushTimeout: opts.FlushTimeout,
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+
+	go r.loop()
+	return r
+}
+
+// EnqueueClick implements ClickOutboxRepository: it never blocks the
+// redirect it's called from, dropping the click (and counting it in
+// telemetry.ClicksOverflowTotal) if the buffer is already full rather than
+// waiting for room.
+func (r *BufferedClickRecorder) EnqueueClick(ctx context.Context, slug string, occurredAt time.Time) error {
+	if slug == "" {
+		return nil
+	}
+
+	ev := clickEvent{slug: slug, day: occurredAt.UTC().Format(time.DateOnly)}
+
+	select {
+	case r.queue <- ev:
+		telemetry.ClicksEnqueuedTotal.Inc()
+	default:
+		telemetry.ClicksOverflowTotal.WithLabelValues("drop_newest").Inc()
+	}
+	return nil
+}
+
+// Close stops the flush loop, draining whatever's buffered and flushing it
+// one last time, then closes the underlying sink. It respects ctx so a hung
+// flush or a slow sink Close can't block shutdown forever.
+func (r *BufferedClickRecorder) Close(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+
+	select {
+	case <-r.doneCh:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return r.sink.Close(ctx)
+}
+
+func (r *BufferedClickRecorder) loop() {
+	defer close(r.doneCh)
+
+	ticker := time.NewTicker(r.flushEvery)
+	defer ticker.Stop()
+
+	pending := make(map[slugDateKey]int64)
+	var events int
+
+	flush := func() {
+		if events == 0 {
+			return
+		}
+		batchSize := events
+
+		// One retry absorbs a transient sink blip (a Mongo/Postgres timeout)
+		// without losing the batch; if the retry also fails there's nowhere
+		// durable to put these counts, so they're dropped and surfaced below
+		// rather than silently lost.
+		var err error
+		for attempt := 0; attempt < 2; attempt++ {
+			ctx, cancel := context.WithTimeout(context.Background(), r.flushTimeout)
+			err = r.flush(ctx, pending)
+			cancel()
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			logger.Error("buffered click flush failed, dropping batch", zap.Error(err), zap.Int("batch_size", batchSize))
+			telemetry.ClicksOverflowTotal.WithLabelValues("flush_failed").Inc()
+		}
+
+		pending = make(map[slugDateKey]int64)
+		events = 0
+	}
+
+	record := func(ev clickEvent) {
+		pending[slugDateKey{slug: ev.slug, day: ev.day}]++
+		events++
+		if events >= r.maxBatch {
+			flush()
+		}
+	}
+
+	drain := func() {
+		for {
+			select {
+			case ev := <-r.queue:
+				record(ev)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case ev := <-r.queue:
+			record(ev)
+		case <-ticker.C:
+			flush()
+		case <-r.stopCh:
+			drain()
+			flush()
+			return
+		}
+	}
+}
+
+// flush compacts pending into one ClickCount per (slug, day) and hands the
+// batch to r.sink in a single call, wrapped in a span and the
+// clicks_flushed_total/flush_duration_seconds metrics that
+// mongo.BufferedClickStatsRepository's equivalent flush already feeds, so
+// both recorders show up on the same dashboard regardless of which one a
+// deployment runs.
+func (r *BufferedClickRecorder) flush(ctx context.Context, pending map[slugDateKey]int64) error {
+	start := time.Now()
+	counts := toClickCounts(pending)
+	batchSize := len(counts)
+
+	err := tracing.StartSpan(ctx, tracerName, "BufferedClickRecorder.Flush", []attribute.KeyValue{
+		tracing.BatchSizeAttr(batchSize),
+	}, func(ctx context.Context) error {
+		return r.sink.Flush(ctx, counts)
+	})
+
+	telemetry.FlushDurationSeconds.Observe(time.Since(start).Seconds())
+	if err == nil {
+		telemetry.ClicksFlushedTotal.Add(float64(batchSize))
+	}
+	return err
+}
+
+func toClickCounts(pending map[slugDateKey]int64) []ClickCount {
+	if len(pending) == 0 {
+		return nil
+	}
+
+	flushedAt := time.Now().UTC()
+	counts := make([]ClickCount, 0, len(pending))
+	for key, count := range pending {
+		counts = append(counts, ClickCount{
+			Slug:      key.slug,
+			Date:      key.day,
+			Count:     count,
+			FlushedAt: flushedAt,
+		})
+	}
+	return counts
+}
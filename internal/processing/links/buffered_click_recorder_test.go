@@ -0,0 +1,114 @@
+package links
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClickSink struct {
+	mu     sync.Mutex
+	counts map[slugDateKey]int64
+	closed bool
+}
+
+func newFakeClickSink() *fakeClickSink {
+	return &fakeClickSink{counts: make(map[slugDateKey]int64)}
+}
+
+func (s *fakeClickSink) Flush(ctx context.Context, counts []ClickCount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range counts {
+		s.counts[slugDateKey{slug: c.Slug, day: c.Date}] += c.Count
+	}
+	return nil
+}
+
+func (s *fakeClickSink) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeClickSink) total() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sum int64
+	for _, n := range s.counts {
+		sum += n
+	}
+	return sum
+}
+
+func TestBufferedClickRecorder_ConcurrentEnqueuesAggregateExactlyAfterClose(t *testing.T) {
+	sink := newFakeClickSink()
+	r := NewBufferedClickRecorder(sink, BufferedClickRecorderOptions{
+		QueueSize:      20_000,
+		FlushInterval:  10 * time.Millisecond,
+		MaxBatchEvents: 1_000,
+	})
+
+	const n = 10_000
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			if err := r.EnqueueClick(context.Background(), "abc", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+				t.Errorf("EnqueueClick: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := sink.total(); got != n {
+		t.Errorf("expected %d clicks flushed, got %d", n, got)
+	}
+	if !sink.closed {
+		t.Error("expected Close to close the underlying sink")
+	}
+}
+
+func TestBufferedClickRecorder_EnqueueClickEmptySlugIsNoop(t *testing.T) {
+	sink := newFakeClickSink()
+	r := NewBufferedClickRecorder(sink, BufferedClickRecorderOptions{})
+
+	if err := r.EnqueueClick(context.Background(), "", time.Now()); err != nil {
+		t.Fatalf("EnqueueClick: %v", err)
+	}
+
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := sink.total(); got != 0 {
+		t.Errorf("expected no clicks flushed, got %d", got)
+	}
+}
+
+// TestBufferedClickRecorder_DropsWhenQueueFull builds a recorder by hand
+// with its flush loop never started, so a full queue is deterministic
+// instead of racing the background consumer that normally drains it.
+func TestBufferedClickRecorder_DropsWhenQueueFull(t *testing.T) {
+	r := &BufferedClickRecorder{
+		sink:  newFakeClickSink(),
+		queue: make(chan clickEvent, 1),
+	}
+
+	if err := r.EnqueueClick(context.Background(), "abc", time.Now()); err != nil {
+		t.Fatalf("EnqueueClick: %v", err)
+	}
+	if err := r.EnqueueClick(context.Background(), "def", time.Now()); err != nil {
+		t.Fatalf("EnqueueClick: %v", err)
+	}
+
+	if got := len(r.queue); got != 1 {
+		t.Errorf("expected the full queue to stay at capacity 1, got %d", got)
+	}
+}
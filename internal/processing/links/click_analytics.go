@@ -0,0 +1,167 @@
+package links
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RecordClickWithOptions is RecordClick plus rich per-click analytics: when
+// a ClickEventRepository is configured, it additionally builds a ClickEvent
+// from opts (referrer host, UA-derived browser/OS, a GeoResolver-derived
+// country code, and a daily-salted visitor hash) and inserts it. The
+// IncDaily path RecordClick always takes is untouched, so a deployment
+// without an EventRepository configured behaves exactly as before this
+// existed.
+func (s *Service) RecordClickWithOptions(ctx context.Context, slug string, opts RecordClickOptions) error {
+	if err := s.RecordClick(ctx, slug); err != nil {
+		return err
+	}
+
+	slug = strings.TrimSpace(slug)
+	if slug == "" || s.eventRepo == nil {
+		return nil
+	}
+
+	now := s.now().UTC()
+	browser, os := parseUserAgent(opts.UserAgent)
+
+	var countryCode string
+	if s.geoResolver != nil && strings.TrimSpace(opts.IP) != "" {
+		cc, err := s.geoResolver.Lookup(ctx, opts.IP)
+		if err == nil {
+			countryCode = cc
+		}
+	}
+
+	event := ClickEvent{
+		Slug:         slug,
+		OccurredAt:   now,
+		ReferrerHost: referrerHost(opts.Referer),
+		Browser:      browser,
+		OS:           os,
+		CountryCode:  countryCode,
+		VisitorHash:  s.hashVisitor(opts.IP, now),
+	}
+
+	return s.eventRepo.InsertEvent(ctx, event)
+}
+
+// hashVisitor derives a per-day pseudonym for an IP: the same visitor hashes
+// the same for every click on a given UTC day, so BreakdownByCountry/ByUA
+// can de-duplicate repeat visits, but the hash changes the next day and
+// never round-trips back to the IP. The optional visitorSalt secret (absent
+// by default) pins the per-day salt to this deployment so a third party who
+// only has the hash can't correlate it against another site's hashes for
+// the same day.
+func (s *Service) hashVisitor(ip string, at time.Time) string {
+	ip = strings.TrimSpace(ip)
+	if ip == "" {
+		return ""
+	}
+
+	h := sha256.New()
+	h.Write(s.visitorSalt)
+	h.Write([]byte{0})
+	h.Write([]byte(at.Format(time.DateOnly)))
+	h.Write([]byte{0})
+	h.Write([]byte(ip))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// referrerHost extracts the host from a Referer header value, discarding
+// the path/query so TopReferrers aggregates by site rather than by page. An
+// unparseable or empty referrer yields "".
+func referrerHost(referer string) string {
+	referer = strings.TrimSpace(referer)
+	if referer == "" {
+		return ""
+	}
+	u, err := url.Parse(referer)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// parseUserAgent picks a best-guess browser/OS pair out of ua using the
+// same substring-matching pragmatism as the rest of this codebase's MVP
+// parsing (see middleware.clientIP): good enough to group a stats
+// breakdown by, not a byte-for-byte UA grammar parser.
+func parseUserAgent(ua string) (browser, os string) {
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"), strings.Contains(ua, "Opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/") && strings.Contains(ua, "Version/"):
+		browser = "Safari"
+	}
+
+	switch {
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		os = "iOS"
+	case strings.Contains(ua, "Mac OS X"):
+		os = "macOS"
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	}
+
+	return browser, os
+}
+
+// GetStatsBreakdown is GetStats's sibling for referrer/country/UA
+// dimensions: it reads straight off ClickEventRepository instead of
+// StatsRepository's daily counters, so it requires EventRepository to be
+// configured - ErrEventStorageDisabled otherwise. limit caps TopReferrers,
+// with the same zero-means-default convention as GetStats' callers use
+// elsewhere (a non-positive limit falls back to 10).
+func (s *Service) GetStatsBreakdown(ctx context.Context, slug string, from, to time.Time, limit int) (*StatsBreakdown, error) {
+	if s.eventRepo == nil {
+		return nil, ErrEventStorageDisabled
+	}
+
+	if _, err := s.GetLink(ctx, slug); err != nil {
+		return nil, err
+	}
+
+	from = from.UTC()
+	to = to.UTC()
+	if to.Before(from) {
+		return nil, ErrInvalidRange
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	topReferrers, err := s.eventRepo.TopReferrers(ctx, slug, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	byCountry, err := s.eventRepo.BreakdownByCountry(ctx, slug, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byUA, err := s.eventRepo.BreakdownByUA(ctx, slug, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsBreakdown{
+		TopReferrers: topReferrers,
+		ByCountry:    byCountry,
+		ByUA:         byUA,
+	}, nil
+}
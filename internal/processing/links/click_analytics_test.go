@@ -0,0 +1,204 @@
+package links
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type mockEventRepo struct {
+	insertFn             func(ctx context.Context, event ClickEvent) error
+	topReferrersFn       func(ctx context.Context, slug string, from, to time.Time, limit int) ([]ReferrerCount, error)
+	breakdownByCountryFn func(ctx context.Context, slug string, from, to time.Time) ([]CountryCount, error)
+	breakdownByUAFn      func(ctx context.Context, slug string, from, to time.Time) ([]UACount, error)
+}
+
+func (m *mockEventRepo) InsertEvent(ctx context.Context, event ClickEvent) error {
+	return m.insertFn(ctx, event)
+}
+func (m *mockEventRepo) TopReferrers(ctx context.Context, slug string, from, to time.Time, limit int) ([]ReferrerCount, error) {
+	return m.topReferrersFn(ctx, slug, from, to, limit)
+}
+func (m *mockEventRepo) BreakdownByCountry(ctx context.Context, slug string, from, to time.Time) ([]CountryCount, error) {
+	return m.breakdownByCountryFn(ctx, slug, from, to)
+}
+func (m *mockEventRepo) BreakdownByUA(ctx context.Context, slug string, from, to time.Time) ([]UACount, error) {
+	return m.breakdownByUAFn(ctx, slug, from, to)
+}
+
+type mockGeoResolver struct {
+	lookupFn func(ctx context.Context, ip string) (string, error)
+}
+
+func (m *mockGeoResolver) Lookup(ctx context.Context, ip string) (string, error) {
+	return m.lookupFn(ctx, ip)
+}
+
+func TestRecordClickWithOptions_NilEventRepoIsNoop(t *testing.T) {
+	or := &mockOutboxRepo{enqueueFn: func(context.Context, string, time.Time) error { return nil }}
+	svc := newTestService(&mockLinkRepo{}, &mockStatsRepo{}, or, &mockSlugger{})
+
+	opts := RecordClickOptions{IP: "203.0.113.1", UserAgent: "Mozilla/5.0 Chrome/100.0", Referer: "https://example.com/page"}
+	if err := svc.RecordClickWithOptions(context.Background(), "abc", opts); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
+
+func TestRecordClickWithOptions_InsertsEvent(t *testing.T) {
+	var got ClickEvent
+	er := &mockEventRepo{
+		insertFn: func(_ context.Context, event ClickEvent) error {
+			got = event
+			return nil
+		},
+	}
+	gr := &mockGeoResolver{lookupFn: func(context.Context, string) (string, error) { return "US", nil }}
+
+	svc := NewServiceWithOptions(&mockLinkRepo{}, &mockStatsRepo{}, nil, &mockSlugger{}, 6, ServiceOptions{
+		EventRepository: er,
+		GeoResolver:     gr,
+	})
+	svc.now = func() time.Time { return time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC) }
+
+	opts := RecordClickOptions{IP: "203.0.113.1", UserAgent: "Mozilla/5.0 (Windows NT 10.0) Chrome/100.0", Referer: "https://example.com/page?q=1"}
+	if err := svc.RecordClickWithOptions(context.Background(), "abc", opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Slug != "abc" {
+		t.Errorf("got slug %q, want abc", got.Slug)
+	}
+	if got.ReferrerHost != "example.com" {
+		t.Errorf("got referrer host %q, want example.com", got.ReferrerHost)
+	}
+	if got.Browser != "Chrome" || got.OS != "Windows" {
+		t.Errorf("got browser/os %q/%q, want Chrome/Windows", got.Browser, got.OS)
+	}
+	if got.CountryCode != "US" {
+		t.Errorf("got country %q, want US", got.CountryCode)
+	}
+	if got.VisitorHash == "" {
+		t.Error("expected a non-empty visitor hash")
+	}
+}
+
+func TestHashVisitor_RotatesDaily(t *testing.T) {
+	svc := NewServiceWithOptions(&mockLinkRepo{}, &mockStatsRepo{}, nil, &mockSlugger{}, 6, ServiceOptions{})
+
+	day1 := time.Date(2025, 1, 15, 23, 59, 0, 0, time.UTC)
+	day2 := time.Date(2025, 1, 16, 0, 1, 0, 0, time.UTC)
+
+	h1 := svc.hashVisitor("203.0.113.1", day1)
+	h2 := svc.hashVisitor("203.0.113.1", day2)
+	h3 := svc.hashVisitor("203.0.113.1", day1)
+
+	if h1 == h2 {
+		t.Error("expected the hash to change across a day boundary")
+	}
+	if h1 != h3 {
+		t.Error("expected the hash to be stable within the same day")
+	}
+	if svc.hashVisitor("", day1) != "" {
+		t.Error("expected an empty IP to hash to empty")
+	}
+}
+
+func TestGetStatsBreakdown_DisabledWithoutEventRepo(t *testing.T) {
+	svc := newTestService(&mockLinkRepo{
+		findBySlugFn: func(context.Context, string) (*Link, error) { return &Link{Slug: "abc"}, nil },
+	}, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	_, err := svc.GetStatsBreakdown(context.Background(), "abc", time.Now(), time.Now(), 10)
+	if err != ErrEventStorageDisabled {
+		t.Fatalf("expected ErrEventStorageDisabled, got: %v", err)
+	}
+}
+
+func TestGetStatsBreakdown_ReturnsAggregates(t *testing.T) {
+	lr := &mockLinkRepo{
+		findBySlugFn: func(context.Context, string) (*Link, error) { return &Link{Slug: "abc"}, nil },
+	}
+	er := &mockEventRepo{
+		topReferrersFn: func(context.Context, string, time.Time, time.Time, int) ([]ReferrerCount, error) {
+			return []ReferrerCount{{Host: "example.com", Count: 3}}, nil
+		},
+		breakdownByCountryFn: func(context.Context, string, time.Time, time.Time) ([]CountryCount, error) {
+			return []CountryCount{{CountryCode: "US", Count: 2}}, nil
+		},
+		breakdownByUAFn: func(context.Context, string, time.Time, time.Time) ([]UACount, error) {
+			return []UACount{{Browser: "Chrome", OS: "Windows", Count: 1}}, nil
+		},
+	}
+
+	svc := NewServiceWithOptions(lr, &mockStatsRepo{}, nil, &mockSlugger{}, 6, ServiceOptions{EventRepository: er})
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	got, err := svc.GetStatsBreakdown(context.Background(), "abc", from, to, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.TopReferrers) != 1 || got.TopReferrers[0].Host != "example.com" {
+		t.Errorf("got top referrers %+v", got.TopReferrers)
+	}
+	if len(got.ByCountry) != 1 || got.ByCountry[0].CountryCode != "US" {
+		t.Errorf("got by country %+v", got.ByCountry)
+	}
+	if len(got.ByUA) != 1 || got.ByUA[0].Browser != "Chrome" {
+		t.Errorf("got by UA %+v", got.ByUA)
+	}
+}
+
+func TestGetStatsBreakdown_InvalidRange(t *testing.T) {
+	lr := &mockLinkRepo{
+		findBySlugFn: func(context.Context, string) (*Link, error) { return &Link{Slug: "abc"}, nil },
+	}
+	er := &mockEventRepo{}
+	svc := NewServiceWithOptions(lr, &mockStatsRepo{}, nil, &mockSlugger{}, 6, ServiceOptions{EventRepository: er})
+
+	from := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := svc.GetStatsBreakdown(context.Background(), "abc", from, to, 10); err != ErrInvalidRange {
+		t.Fatalf("expected ErrInvalidRange, got: %v", err)
+	}
+}
+
+func TestParseUserAgent(t *testing.T) {
+	cases := []struct {
+		ua          string
+		wantBrowser string
+		wantOS      string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0 Safari/537.36", "Chrome", "Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Safari/605.1.15", "Safari", "macOS"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:102.0) Gecko/20100101 Firefox/102.0", "Firefox", "Linux"},
+		{"Mozilla/5.0 (Linux; Android 12; Pixel 6) AppleWebKit/537.36 Chrome/100.0 Mobile Safari/537.36", "Chrome", "Android"},
+		{"", "", ""},
+	}
+
+	for _, c := range cases {
+		browser, os := parseUserAgent(c.ua)
+		if browser != c.wantBrowser || os != c.wantOS {
+			t.Errorf("parseUserAgent(%q) = (%q, %q), want (%q, %q)", c.ua, browser, os, c.wantBrowser, c.wantOS)
+		}
+	}
+}
+
+func TestReferrerHost(t *testing.T) {
+	cases := []struct {
+		referer string
+		want    string
+	}{
+		{"https://example.com/page?q=1", "example.com"},
+		{"", ""},
+		{"not a url", ""},
+	}
+
+	for _, c := range cases {
+		if got := referrerHost(c.referer); got != c.want {
+			t.Errorf("referrerHost(%q) = %q, want %q", c.referer, got, c.want)
+		}
+	}
+}
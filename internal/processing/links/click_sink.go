@@ -0,0 +1,34 @@
+package links
+
+import (
+	"context"
+	"time"
+)
+
+// ClickCount is one slug/day aggregate a BufferedClickStatsRepository flush
+// produces - one entry per unique (slug, day) pair in the batch, already
+// compacted from however many individual clicks landed during the flush
+// window rather than one message per click.
+type ClickCount struct {
+	Slug      string
+	Date      string // YYYY-MM-DD (UTC)
+	Count     int64
+	FlushedAt time.Time
+}
+
+// ClickSink durably applies one flush's worth of ClickCounts. MongoSink
+// (internal/storage/mongo) is the default, applying each count straight to
+// the stats collection the same way BufferedClickStatsRepository always
+// did before ClickSink existed; the Kafka/NATS sinks under
+// internal/processing/links/clicksink instead publish the increments for a
+// separate consumer process to own aggregation, so a high-volume
+// deployment can take the buffer's write pressure off Mongo entirely.
+type ClickSink interface {
+	Flush(ctx context.Context, counts []ClickCount) error
+
+	// Close releases the sink's resources (a Kafka/NATS connection; a no-op
+	// for MongoSink, which shares the caller's Mongo connection). It
+	// respects ctx the same way BufferedClickStatsRepository.Shutdown
+	// already does, so a slow producer can't hang shutdown forever.
+	Close(ctx context.Context) error
+}
@@ -0,0 +1,100 @@
+// Package kafka publishes flushed click counts to Kafka as an alternative
+// to BufferedClickStatsRepository applying them straight to Mongo.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"github.com/segmentio/kafka-go"
+)
+
+type clickCountMessage struct {
+	Slug      string    `json:"slug"`
+	Date      string    `json:"date"`
+	Count     int64     `json:"count"`
+	FlushedAt time.Time `json:"flushed_at"`
+}
+
+// Sink implements links.ClickSink, publishing each flushed ClickCount as its
+// own message keyed by "slug|date" so log compaction on the topic collapses
+// to one running total per slug/day instead of retaining every flush's
+// delta - a downstream consumer owns summing Count across whatever messages
+// it still sees for a key.
+type Sink struct {
+	writer *kafka.Writer
+}
+
+// Options tunes the underlying kafka.Writer beyond NewSink's defaults -
+// Compression and Transport matter most for a hosted cluster, the same two
+// knobs click_consumer's reader exposes via kafkainfra.Config.
+type Options struct {
+	Compression kafka.Compression
+	Transport   *kafka.Transport
+}
+
+// NewSink builds a Sink with RequiredAcks pinned to RequireAll, matching the
+// outbox Kafka sink's durability choice.
+func NewSink(brokers []string, topic string) *Sink {
+	return NewSinkWithOptions(brokers, topic, Options{})
+}
+
+// NewSinkWithOptions is NewSink with Compression and Transport under the
+// caller's control, for a deployment that needs compression or TLS/SASL to
+// reach its cluster.
+func NewSinkWithOptions(brokers []string, topic string, opts Options) *Sink {
+	return &Sink{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			Compression:            opts.Compression,
+			Transport:              opts.Transport,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (s *Sink) Flush(ctx context.Context, counts []links.ClickCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	msgs := make([]kafka.Message, len(counts))
+	for i, c := range counts {
+		payload, err := json.Marshal(clickCountMessage{
+			Slug:      c.Slug,
+			Date:      c.Date,
+			Count:     c.Count,
+			FlushedAt: c.FlushedAt,
+		})
+		if err != nil {
+			return err
+		}
+		msgs[i] = kafka.Message{
+			Key:   []byte(c.Slug + "|" + c.Date),
+			Value: payload,
+		}
+	}
+
+	return s.writer.WriteMessages(ctx, msgs...)
+}
+
+// Close closes the underlying writer, respecting ctx the same way
+// BufferedClickStatsRepository.Shutdown already does for the buffer's own
+// drain - kafka.Writer.Close has no context of its own, so a hung
+// connection is bounded by ctx instead of blocking shutdown forever.
+func (s *Sink) Close(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() { done <- s.writer.Close() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
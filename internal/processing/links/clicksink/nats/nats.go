@@ -0,0 +1,99 @@
+// Package nats publishes flushed click counts to a NATS JetStream stream as
+// an alternative to BufferedClickStatsRepository applying them straight to
+// Mongo.
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"github.com/nats-io/nats.go"
+)
+
+type clickCountMessage struct {
+	Slug      string    `json:"slug"`
+	Date      string    `json:"date"`
+	Count     int64     `json:"count"`
+	FlushedAt time.Time `json:"flushed_at"`
+}
+
+// Sink implements links.ClickSink over a NATS JetStream stream, one message
+// per flushed ClickCount with a Nats-Msg-Id of "slug|date" - the stream's
+// message deduplication window, like the outbox's jetstream.Sink, is the
+// closest JetStream gets to Kafka's log compaction for collapsing repeated
+// slug/day keys.
+//
+// Unlike jetstream.Sink, which takes an already-connected
+// nats.JetStreamContext owned by its caller, Sink owns its own connection:
+// a ClickSink only gets one Close(ctx) call from
+// BufferedClickStatsRepository.Shutdown, so there's no second cleanup func
+// for a caller to also remember to invoke.
+type Sink struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	subject string
+}
+
+func NewSink(url, subject string) (*Sink, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Sink{conn: conn, js: js, subject: subject}, nil
+}
+
+func (s *Sink) Flush(ctx context.Context, counts []links.ClickCount) error {
+	for _, c := range counts {
+		payload, err := json.Marshal(clickCountMessage{
+			Slug:      c.Slug,
+			Date:      c.Date,
+			Count:     c.Count,
+			FlushedAt: c.FlushedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		future, err := s.js.PublishMsgAsync(&nats.Msg{
+			Subject: s.subject,
+			Data:    payload,
+			Header:  nats.Header{"Nats-Msg-Id": []string{c.Slug + "|" + c.Date}},
+		})
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close drains and closes the sink's own NATS connection, respecting ctx
+// the same way BufferedClickStatsRepository.Shutdown already does.
+func (s *Sink) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.conn.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
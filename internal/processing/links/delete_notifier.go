@@ -0,0 +1,41 @@
+package links
+
+import "sync"
+
+// DeleteNotifier is a tiny in-process pub/sub: Service.DeleteLink publishes
+// a slug once the underlying delete succeeds, and anything caching link
+// data - HotCache, today - can Subscribe to invalidate itself without
+// Service needing to know its subscribers' concrete types.
+type DeleteNotifier struct {
+	mu   sync.Mutex
+	subs []func(slug string)
+}
+
+// Subscribe registers fn to be called with every slug DeleteNotifier
+// publishes, and returns a func that unsubscribes it.
+func (n *DeleteNotifier) Subscribe(fn func(slug string)) (unsubscribe func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.subs = append(n.subs, fn)
+	idx := len(n.subs) - 1
+
+	return func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		n.subs[idx] = nil
+	}
+}
+
+func (n *DeleteNotifier) publish(slug string) {
+	n.mu.Lock()
+	subs := make([]func(string), len(n.subs))
+	copy(subs, n.subs)
+	n.mu.Unlock()
+
+	for _, fn := range subs {
+		if fn != nil {
+			fn(slug)
+		}
+	}
+}
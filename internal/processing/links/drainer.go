@@ -0,0 +1,222 @@
+package links
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/outbox"
+	"go.uber.org/zap"
+)
+
+// OutboxEvent is the drainer's storage-agnostic view of a claimed outbox
+// row. Both the Postgres and Mongo ClickOutboxRepository already expose an
+// OutboxClickEvent shaped like this; DrainRepository implementations adapt
+// their native ID type (string UUID, ObjectID) to OutboxEvent.ID's string.
+type OutboxEvent struct {
+	ID         string
+	Slug       string
+	OccurredAt time.Time
+	Attempts   int
+}
+
+// DrainRepository is the claim side ClickOutboxDrainer needs: claim a
+// batch with worker ownership (SELECT ... FOR UPDATE SKIP LOCKED LIMIT N
+// on Postgres, a findAndModify claim loop on Mongo), then resolve each
+// claimed event to drained, retryable, or poison.
+type DrainRepository interface {
+	ClaimPending(ctx context.Context, now time.Time, limit int, workerID string, lease time.Duration) ([]OutboxEvent, error)
+	MarkDrained(ctx context.Context, id, workerID string) error
+	MarkRetry(ctx context.Context, id, workerID, lastError string, nextAttemptAt time.Time) error
+	MoveToDLQ(ctx context.Context, id, workerID, lastError string) error
+}
+
+// DrainSink applies a group of same-slug, same-day clicks as one commit:
+// incrementing the link's click counter and its daily rollup bucket
+// together, so the two can never drift out of sync.
+type DrainSink interface {
+	ApplySlugBatch(ctx context.Context, slug string, clicks int, day time.Time) error
+}
+
+// DrainerOptions tunes ClickOutboxDrainer's polling, concurrency, and
+// retry behavior.
+type DrainerOptions struct {
+	BatchSize    int
+	Workers      int
+	PollInterval time.Duration
+	MaxAttempts  int
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+	ClaimLease   time.Duration
+	WorkerID     string
+
+	// BackoffPolicy decides retry delay and when to give up on an event. A
+	// nil value (the default) builds an outbox.ExponentialJitterPolicy from
+	// MaxAttempts/RetryBase/RetryMax, preserving this type's original
+	// behavior; set it to use a different policy instead.
+	BackoffPolicy outbox.BackoffPolicy
+}
+
+// ClickOutboxDrainer replaces ClickEventProcessor.Process's one-event-per-
+// transaction model with batched draining: it claims a batch of events,
+// groups them by (slug, day), and fans those groups out across a worker
+// pool, committing each group through DrainSink as a single transaction
+// rather than one transaction per event.
+type ClickOutboxDrainer struct {
+	repo DrainRepository
+	sink DrainSink
+	opts DrainerOptions
+}
+
+func NewClickOutboxDrainer(repo DrainRepository, sink DrainSink, opts DrainerOptions) *ClickOutboxDrainer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 200
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 250 * time.Millisecond
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = time.Second
+	}
+	if opts.RetryMax <= 0 {
+		opts.RetryMax = 30 * time.Second
+	}
+	if opts.ClaimLease <= 0 {
+		opts.ClaimLease = 30 * time.Second
+	}
+	if opts.BackoffPolicy == nil {
+		opts.BackoffPolicy = outbox.NewExponentialJitterPolicy(opts.RetryBase, opts.RetryMax, opts.MaxAttempts)
+	}
+
+	return &ClickOutboxDrainer{repo: repo, sink: sink, opts: opts}
+}
+
+// Run blocks, polling the outbox until ctx is canceled. Launch it as a
+// goroutine from main.go, the same way OutboxDispatcher is launched.
+func (d *ClickOutboxDrainer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.drainOnce(ctx)
+		}
+	}
+}
+
+func (d *ClickOutboxDrainer) drainOnce(ctx context.Context) {
+	start := time.Now()
+
+	events, err := d.repo.ClaimPending(ctx, start.UTC(), d.opts.BatchSize, d.opts.WorkerID, d.opts.ClaimLease)
+	if err != nil {
+		logger.Error("failed to claim outbox batch", zap.Error(err))
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+	drainBatchSize.Observe(float64(len(events)))
+
+	groups := groupBySlugDay(events)
+
+	workers := d.opts.Workers
+	if workers > len(groups) {
+		workers = len(groups)
+	}
+
+	groupCh := make(chan slugDayGroup)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := range groupCh {
+				d.commitGroup(ctx, g)
+			}
+		}()
+	}
+	for _, g := range groups {
+		groupCh <- g
+	}
+	close(groupCh)
+	wg.Wait()
+
+	drainLatencySeconds.Observe(time.Since(start).Seconds())
+}
+
+// slugDayGroup is the unit of work handed to a drainer worker: every event
+// in it shares a slug and an occurredAt day, so DrainSink.ApplySlugBatch
+// can fold them into one click-counter/rollup increment.
+type slugDayGroup struct {
+	slug   string
+	day    time.Time
+	events []OutboxEvent
+}
+
+func groupBySlugDay(events []OutboxEvent) []slugDayGroup {
+	index := make(map[string]int)
+	groups := make([]slugDayGroup, 0, len(events))
+
+	for _, ev := range events {
+		day := dateOnly(ev.OccurredAt.UTC())
+		key := ev.Slug + "|" + day.Format(time.DateOnly)
+
+		if i, ok := index[key]; ok {
+			groups[i].events = append(groups[i].events, ev)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, slugDayGroup{slug: ev.Slug, day: day, events: []OutboxEvent{ev}})
+	}
+
+	return groups
+}
+
+func (d *ClickOutboxDrainer) commitGroup(ctx context.Context, g slugDayGroup) {
+	if err := d.sink.ApplySlugBatch(ctx, g.slug, len(g.events), g.day); err != nil {
+		d.handleGroupFailure(ctx, g, err)
+		return
+	}
+
+	for _, ev := range g.events {
+		if err := d.repo.MarkDrained(ctx, ev.ID, d.opts.WorkerID); err != nil {
+			logger.Error("failed to mark outbox event drained", zap.Error(err), zap.String("slug", g.slug), zap.String("event_id", ev.ID))
+		}
+	}
+}
+
+func (d *ClickOutboxDrainer) handleGroupFailure(ctx context.Context, g slugDayGroup, cause error) {
+	for _, ev := range g.events {
+		attempt := ev.Attempts + 1
+		delay, terminal := d.opts.BackoffPolicy.Next(attempt, cause)
+		if terminal {
+			if err := d.repo.MoveToDLQ(ctx, ev.ID, d.opts.WorkerID, cause.Error()); err != nil {
+				logger.Error("failed to move poison outbox event to DLQ", zap.Error(err), zap.String("slug", g.slug), zap.String("event_id", ev.ID))
+				continue
+			}
+			drainDLQDepth.Inc()
+			outbox.DeadEventsTotal.WithLabelValues("max_attempts").Inc()
+			logger.Warn("outbox event exhausted retries, moved to DLQ",
+				zap.String("slug", g.slug),
+				zap.Int("attempts", attempt),
+				zap.Error(cause),
+			)
+			continue
+		}
+
+		drainRetriesTotal.Inc()
+		if err := d.repo.MarkRetry(ctx, ev.ID, d.opts.WorkerID, cause.Error(), time.Now().UTC().Add(delay)); err != nil {
+			logger.Error("failed to mark outbox event for retry", zap.Error(err), zap.String("slug", g.slug), zap.String("event_id", ev.ID))
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package links
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	drainBatchSize = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "click_outbox_drain_batch_size",
+			Help:    "Number of outbox events claimed per ClickOutboxDrainer batch.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		},
+	)
+
+	drainLatencySeconds = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "click_outbox_drain_latency_seconds",
+			Help:    "Time to claim and commit one ClickOutboxDrainer batch.",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	drainRetriesTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "click_outbox_drain_retries_total",
+			Help: "Total number of outbox events marked for retry by ClickOutboxDrainer.",
+		},
+	)
+
+	drainDLQDepth = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "click_outbox_drain_dlq_total",
+			Help: "Total number of outbox events moved to the dead-letter store by ClickOutboxDrainer.",
+		},
+	)
+)
@@ -0,0 +1,142 @@
+package links
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/telemetry"
+	"golang.org/x/sync/singleflight"
+)
+
+// HotCacheValue is the slim subset of Link a HotCache entry keeps - just
+// enough for Service.Resolve to serve a hit without re-fetching Link's
+// other fields. Capped never goes stale the way a cached ClicksRemaining
+// count would: it just tells Resolve whether to call
+// LinkRepository.ConsumeClick on every hit, not how many clicks are left.
+type HotCacheValue struct {
+	URL       string
+	ExpiresAt *time.Time
+	Capped    bool
+}
+
+type hotCacheEntry struct {
+	value    HotCacheValue
+	storedAt time.Time
+	hits     atomic.Int64
+}
+
+// HotCache is a second-level, in-process cache in front of
+// Service.Resolve's LinkRepository.FindBySlug lookup, for slugs hot enough
+// that skipping the storage round-trip on every redirect is worth the
+// staleness window. Entries expire after TTL regardless of how often
+// they're hit; once the cache holds more than Capacity entries, the
+// least-frequently-hit ones are evicted to make room. A HotCache is safe
+// for concurrent use and zero-value-unready - build one with NewHotCache.
+type HotCache struct {
+	entries  sync.Map // slug -> *hotCacheEntry
+	ttl      time.Duration
+	capacity int
+	size     atomic.Int64
+	group    singleflight.Group
+}
+
+// NewHotCache builds a HotCache holding up to capacity entries for ttl each.
+func NewHotCache(capacity int, ttl time.Duration) *HotCache {
+	return &HotCache{capacity: capacity, ttl: ttl}
+}
+
+// Get returns the cached value for slug, if present and not yet expired.
+func (c *HotCache) Get(slug string) (HotCacheValue, bool) {
+	v, ok := c.entries.Load(slug)
+	if !ok {
+		telemetry.HotCacheMissesTotal.Inc()
+		return HotCacheValue{}, false
+	}
+
+	e := v.(*hotCacheEntry)
+	if time.Since(e.storedAt) > c.ttl {
+		c.delete(slug)
+		telemetry.HotCacheMissesTotal.Inc()
+		return HotCacheValue{}, false
+	}
+
+	e.hits.Add(1)
+	telemetry.HotCacheHitsTotal.Inc()
+	return e.value, true
+}
+
+// GetOrLoad serves slug from cache if present; otherwise it calls load,
+// deduplicated via singleflight so a thundering herd of concurrent misses
+// on the same slug triggers exactly one load, and caches its result.
+func (c *HotCache) GetOrLoad(ctx context.Context, slug string, load func(ctx context.Context) (HotCacheValue, error)) (HotCacheValue, error) {
+	if v, ok := c.Get(slug); ok {
+		return v, nil
+	}
+
+	v, err, _ := c.group.Do(slug, func() (any, error) {
+		value, err := load(ctx)
+		if err != nil {
+			return HotCacheValue{}, err
+		}
+		c.set(slug, value)
+		return value, nil
+	})
+	if err != nil {
+		return HotCacheValue{}, err
+	}
+	return v.(HotCacheValue), nil
+}
+
+func (c *HotCache) set(slug string, value HotCacheValue) {
+	entry := &hotCacheEntry{value: value, storedAt: time.Now()}
+	if _, loaded := c.entries.Swap(slug, entry); !loaded {
+		if c.size.Add(1) > int64(c.capacity) {
+			c.evictLFU()
+		}
+	}
+}
+
+// Invalidate drops slug from the cache immediately, regardless of TTL. See
+// Service's DeleteNotifier, which a HotCache configured via
+// ServiceOptions.HotCache is subscribed to.
+func (c *HotCache) Invalidate(slug string) {
+	c.delete(slug)
+}
+
+func (c *HotCache) delete(slug string) {
+	if _, loaded := c.entries.LoadAndDelete(slug); loaded {
+		c.size.Add(-1)
+	}
+}
+
+// evictLFU scans every entry once and drops the least-frequently-hit ones
+// until the cache is back at capacity. It's an O(n) sweep rather than a
+// heap-maintained LFU, which is fine at HotCache's scale - capacity is
+// expected to be in the thousands of hot slugs, not millions - and keeps
+// the common Get/set path lock-free.
+func (c *HotCache) evictLFU() {
+	type candidate struct {
+		slug string
+		hits int64
+	}
+
+	var candidates []candidate
+	c.entries.Range(func(key, value any) bool {
+		e := value.(*hotCacheEntry)
+		candidates = append(candidates, candidate{key.(string), e.hits.Load()})
+		return true
+	})
+
+	overBy := len(candidates) - c.capacity
+	if overBy <= 0 {
+		return
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].hits < candidates[j].hits })
+	for _, cand := range candidates[:overBy] {
+		c.delete(cand.slug)
+	}
+}
@@ -3,23 +3,72 @@ package links
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links/urlpolicy"
 )
 
 var (
-	ErrNotFound     = errors.New("link not found")
-	ErrExpired      = errors.New("link expired")
-	ErrInvalidURL   = errors.New("invalid url")
-	ErrSlugTaken    = errors.New("slug taken")
-	ErrInvalidRange = errors.New("invalid date range")
+	ErrNotFound            = errors.New("link not found")
+	ErrExpired             = errors.New("link expired")
+	ErrInvalidURL          = errors.New("invalid url")
+	ErrSlugTaken           = errors.New("slug taken")
+	ErrInvalidRange        = errors.New("invalid date range")
+	ErrIdempotencyConflict = errors.New("idempotency key reused with a different request")
+	ErrIdempotencyInFlight = errors.New("idempotency key request still in flight")
+
+	// ErrInvalidCustomSlug is returned when CreateLinkInput.CustomSlug fails
+	// CustomSlugPolicy.Validate - distinct from ErrSlugTaken, which means the
+	// slug was well-formed but already claimed by another link.
+	ErrInvalidCustomSlug = errors.New("invalid custom slug")
+
+	// ErrForbidden is returned by GetStatsForClaims and DeleteLinkForClaims
+	// when a verified caller's subject doesn't match the link's
+	// OwnerSubject - distinct from ErrNotFound, which covers a slug that
+	// doesn't exist at all.
+	ErrForbidden = errors.New("caller does not own this link")
+
+	// ErrEventStorageDisabled is returned by GetStatsBreakdown when Service
+	// has no ClickEventRepository configured - a self-hoster who opted out
+	// of per-click storage still gets daily counts from GetStats, just not
+	// the breakdowns that depend on the raw events.
+	ErrEventStorageDisabled = errors.New("click event storage is not configured")
 )
 
+// URLValidationError is ErrInvalidURL (see Unwrap) with the urlpolicy.Code
+// and detail message that caused CreateLink to reject the URL attached, so
+// a caller that errors.As()s for it can report specifics - blocked IP,
+// disallowed scheme, oversize - instead of a flat "invalid url".
+type URLValidationError struct {
+	Code    urlpolicy.Code
+	Message string
+}
+
+func (e *URLValidationError) Error() string {
+	return fmt.Sprintf("invalid url: %s: %s", e.Code, e.Message)
+}
+
+func (e *URLValidationError) Unwrap() error {
+	return ErrInvalidURL
+}
+
 type LinkRepository interface {
 	Insert(ctx context.Context, link *Link) error
 	FindBySlug(ctx context.Context, slug string) (*Link, error)
 	FindActiveBySlug(ctx context.Context, slug string, at time.Time) (*Link, error)
 	FindActiveBySlugAndIncClick(ctx context.Context, slug string, at time.Time) (*Link, error)
 	DeleteBySlug(ctx context.Context, slug string) (bool, error)
+
+	// ConsumeClick atomically decrements slug's ClicksRemaining budget and
+	// returns what's left, so concurrent redirects on a MaxClicks/SingleUse
+	// link can never push it negative - a single `UPDATE ... SET
+	// clicks_remaining = clicks_remaining - 1 WHERE slug = $1 AND
+	// clicks_remaining > 0 RETURNING clicks_remaining` or equivalent.
+	// remaining is -1 for a link with no cap configured (ClicksRemaining is
+	// nil), which ConsumeClick must still recognize and leave untouched.
+	// ErrExpired is returned once the budget is already exhausted.
+	ConsumeClick(ctx context.Context, slug string) (remaining int64, err error)
 }
 
 type StatsRepository interface {
@@ -32,6 +81,85 @@ type ClickOutboxRepository interface {
 	EnqueueClick(ctx context.Context, slug string, occurredAt time.Time) error
 }
 
+// ClickEventRepository persists the rich per-click events RecordClick
+// builds when Service.GetStatsBreakdown needs more than the daily counter
+// StatsRepository already tracks. It's optional (ServiceOptions.EventRepository)
+// and independent of StatsRepository/ClickOutboxRepository, so a self-hoster
+// who doesn't want per-click data retained can leave it nil and keep the
+// IncDaily path as the only thing RecordClick does.
+type ClickEventRepository interface {
+	InsertEvent(ctx context.Context, event ClickEvent) error
+
+	// TopReferrers ranks the limit most common ReferrerHost values for slug
+	// in [from, to], highest count first.
+	TopReferrers(ctx context.Context, slug string, from, to time.Time, limit int) ([]ReferrerCount, error)
+	BreakdownByCountry(ctx context.Context, slug string, from, to time.Time) ([]CountryCount, error)
+	BreakdownByUA(ctx context.Context, slug string, from, to time.Time) ([]UACount, error)
+}
+
+// GeoResolver resolves an IP to the ISO 3166-1 alpha-2 country code it
+// geolocates to, so RecordClick can populate ClickEvent.CountryCode without
+// Service depending on any one geo database/provider. A nil GeoResolver
+// (the default) leaves CountryCode blank on every event.
+type GeoResolver interface {
+	Lookup(ctx context.Context, ip string) (countryCode string, err error)
+}
+
+// IdempotencyStatus tracks where a Service.CreateLink attempt made under a
+// given Idempotency-Key stands.
+type IdempotencyStatus string
+
+const (
+	IdempotencyPending   IdempotencyStatus = "pending"
+	IdempotencyCompleted IdempotencyStatus = "completed"
+	IdempotencyFailed    IdempotencyStatus = "failed"
+)
+
+// IdempotencyRecord is the row IdempotencyRepository persists per key.
+type IdempotencyRecord struct {
+	Key         string
+	APIKey      string
+	RequestHash string
+	Status      IdempotencyStatus
+	Slug        string
+	CreatedAt   time.Time
+}
+
+// IdempotencyRepository gives Service.CreateLink exactly-once semantics
+// under a client-supplied Idempotency-Key, mirroring the processed-event
+// pattern ClickEventProcessor already uses for clicks: Begin atomically
+// claims a key (inserting a pending row), and Complete/Fail finalize it.
+// A row left "failed" is reclaimable by a later Begin, the same as a key
+// that was never seen, so a client can safely retry after an error.
+// Implementations are expected to apply a TTL to rows so abandoned keys
+// don't accumulate forever.
+type IdempotencyRepository interface {
+	// Begin claims key for the current attempt. claimed is true if this
+	// call inserted the pending row; false means an existing row (any
+	// status other than expired/reclaimed) was returned instead.
+	Begin(ctx context.Context, key, apiKey, requestHash string) (record *IdempotencyRecord, claimed bool, err error)
+	Complete(ctx context.Context, key, slug string) error
+	Fail(ctx context.Context, key string) error
+}
+
+// RollupReader optionally backs GetStats' historical read path with
+// pre-aggregated daily rollup rows (see internal/processing/stats) instead
+// of re-scanning StatsRepository for closed days on every call. A nil
+// RollupReader leaves GetStats reading StatsRepository for the whole range,
+// exactly as before this existed.
+type RollupReader interface {
+	GetDaily(ctx context.Context, slug string, from, to time.Time) ([]DailyCount, error)
+}
+
+// SlugHint carries the parameters a Slugger needs to produce the next
+// candidate during Service.CreateLink's collision-retry loop: the desired
+// length (which grows by one on each retry) and which attempt this is.
+type SlugHint struct {
+	Length  int
+	Attempt int
+}
+
 type Slugger interface {
 	Generate(length int) (string, error)
+	GenerateWithContext(ctx context.Context, hint SlugHint) (string, error)
 }
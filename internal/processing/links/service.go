@@ -2,50 +2,230 @@ package links
 
 import (
 	"context"
-	"net/url"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/tracing"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links/auth"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links/urlpolicy"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const tracerName = "links.service"
+
 type Service struct {
-	linkRepo   LinkRepository
-	statsRepo  StatsRepository
-	slugger    Slugger
-	slugLength int
-	now        func() time.Time
+	linkRepo         LinkRepository
+	statsRepo        StatsRepository
+	outboxRepo       ClickOutboxRepository
+	slugger          Slugger
+	slugLength       int
+	now              func() time.Time
+	rollupReader     RollupReader
+	idempotencyRepo  IdempotencyRepository
+	hotCache         *HotCache
+	deleteNotifier   *DeleteNotifier
+	eventRepo        ClickEventRepository
+	geoResolver      GeoResolver
+	visitorSalt      []byte
+	urlPolicy        urlpolicy.Policy
+	customSlugPolicy CustomSlugPolicy
+}
+
+func NewService(linkRepo LinkRepository, statsRepo StatsRepository, outboxRepo ClickOutboxRepository, slugger Slugger, slugLength int) *Service {
+	return NewServiceWithOptions(linkRepo, statsRepo, outboxRepo, slugger, slugLength, ServiceOptions{})
 }
 
-func NewService(linkRepo LinkRepository, statsRepo StatsRepository, slugger Slugger, slugLength int) *Service {
+// ServiceOptions carries optional collaborators that change Service's
+// behavior without widening NewService's positional signature.
+type ServiceOptions struct {
+	// RollupReader, if set, lets GetStats serve closed days from a
+	// pre-aggregated rollup table (see internal/processing/stats) instead
+	// of always scanning StatsRepository.
+	RollupReader RollupReader
+
+	// IdempotencyRepository, if set, lets CreateLink honor
+	// CreateLinkInput.IdempotencyKey. A nil value (the default) makes an
+	// IdempotencyKey on the input a no-op.
+	IdempotencyRepository IdempotencyRepository
+
+	// HotCache, if set, lets Resolve skip linkRepo.FindBySlug for slugs
+	// still cached from a recent lookup. It's automatically subscribed to
+	// invalidate on DeleteLink, so a deleted slug can't be served stale
+	// from cache.
+	HotCache *HotCache
+
+	// EventRepository, if set, lets RecordClickWithOptions persist a
+	// ClickEvent per click and makes GetStatsBreakdown available. A nil
+	// value (the default) keeps RecordClick's plain IncDaily path as the
+	// only thing a click does, for a deployment that wants to opt out of
+	// per-click storage entirely.
+	EventRepository ClickEventRepository
+
+	// GeoResolver, if set, lets RecordClickWithOptions populate
+	// ClickEvent.CountryCode from the caller's IP. A nil value leaves
+	// CountryCode blank on every event.
+	GeoResolver GeoResolver
+
+	// VisitorHashSecret pins the daily visitor-hash salt
+	// (Service.hashVisitor) to this deployment. Optional - an empty secret
+	// still rotates the hash daily, it just doesn't resist a third party
+	// guessing the salt from the date alone.
+	VisitorHashSecret []byte
+
+	// URLPolicy governs which destination URLs CreateLink accepts - see
+	// urlpolicy.Policy. A nil value (the default) falls back to
+	// urlpolicy.DefaultPolicy(), which blocks private/loopback/metadata
+	// IPs rather than letting the shortener be used as an SSRF proxy.
+	URLPolicy *urlpolicy.Policy
+
+	// CustomSlugPolicy governs which CreateLinkInput.CustomSlug values
+	// CreateLink accepts - see CustomSlugPolicy. A nil value (the default)
+	// falls back to DefaultCustomSlugPolicy().
+	CustomSlugPolicy *CustomSlugPolicy
+}
+
+func NewServiceWithOptions(linkRepo LinkRepository, statsRepo StatsRepository, outboxRepo ClickOutboxRepository, slugger Slugger, slugLength int, opts ServiceOptions) *Service {
 	if slugLength <= 0 {
 		slugLength = 6
 	}
 
-	return &Service{
-		linkRepo:   linkRepo,
-		statsRepo:  statsRepo,
-		slugger:    slugger,
-		slugLength: slugLength,
-		now:        time.Now,
+	policy := urlpolicy.DefaultPolicy()
+	if opts.URLPolicy != nil {
+		policy = *opts.URLPolicy
+	}
+
+	customSlugPolicy := DefaultCustomSlugPolicy()
+	if opts.CustomSlugPolicy != nil {
+		customSlugPolicy = *opts.CustomSlugPolicy
 	}
+
+	s := &Service{
+		linkRepo:         linkRepo,
+		statsRepo:        statsRepo,
+		outboxRepo:       outboxRepo,
+		slugger:          slugger,
+		slugLength:       slugLength,
+		now:              time.Now,
+		rollupReader:     opts.RollupReader,
+		idempotencyRepo:  opts.IdempotencyRepository,
+		hotCache:         opts.HotCache,
+		deleteNotifier:   &DeleteNotifier{},
+		eventRepo:        opts.EventRepository,
+		geoResolver:      opts.GeoResolver,
+		visitorSalt:      opts.VisitorHashSecret,
+		urlPolicy:        policy,
+		customSlugPolicy: customSlugPolicy,
+	}
+
+	if s.hotCache != nil {
+		s.deleteNotifier.Subscribe(s.hotCache.Invalidate)
+	}
+
+	return s
 }
 
 func (s *Service) CreateLink(ctx context.Context, in CreateLinkInput) (*Link, error) {
-	normalizedURL, err := validateAndNormalizeURL(in.URL)
+	key := strings.TrimSpace(in.IdempotencyKey)
+
+	var link *Link
+	err := tracing.StartSpan(ctx, tracerName, "CreateLink", []attribute.KeyValue{
+		attribute.Bool("link.idempotent", key != "" && s.idempotencyRepo != nil),
+	}, func(ctx context.Context) error {
+		var err error
+		if key == "" || s.idempotencyRepo == nil {
+			link, err = s.createLink(ctx, in)
+		} else {
+			link, err = s.createLinkIdempotent(ctx, in, key)
+		}
+		return err
+	})
+	return link, err
+}
+
+// createLinkIdempotent wraps createLink with the processed-event pattern:
+// Begin atomically claims the key, an already-completed row short-circuits
+// to the original link (or ErrIdempotencyConflict if the request changed),
+// and a still-pending row reports ErrIdempotencyInFlight so the client
+// knows to back off and retry rather than race the in-flight attempt.
+func (s *Service) createLinkIdempotent(ctx context.Context, in CreateLinkInput, key string) (*Link, error) {
+	hash := hashCreateLinkInput(in)
+
+	record, claimed, err := s.idempotencyRepo.Begin(ctx, key, strings.TrimSpace(in.APIKey), hash)
 	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		switch record.Status {
+		case IdempotencyCompleted:
+			if record.RequestHash != hash {
+				return nil, ErrIdempotencyConflict
+			}
+			return s.GetLink(ctx, record.Slug)
+		default:
+			return nil, ErrIdempotencyInFlight
+		}
+	}
+
+	link, err := s.createLink(ctx, in)
+	if err != nil {
+		_ = s.idempotencyRepo.Fail(ctx, key)
+		return nil, err
+	}
+	if err := s.idempotencyRepo.Complete(ctx, key, link.Slug); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (s *Service) createLink(ctx context.Context, in CreateLinkInput) (*Link, error) {
+	normalizedURL, err := s.urlPolicy.Validate(ctx, in.URL)
+	if err != nil {
+		var verr *urlpolicy.ValidationError
+		if errors.As(err, &verr) {
+			return nil, &URLValidationError{Code: verr.Code, Message: verr.Message}
+		}
 		return nil, ErrInvalidURL
 	}
 
 	link := &Link{
-		URL:       normalizedURL,
-		Notes:     strings.TrimSpace(in.Notes),
-		CreatedAt: s.now().UTC(),
-		ExpiresAt: in.ExpiresAt,
-		APIKey:    strings.TrimSpace(in.APIKey),
+		URL:          normalizedURL,
+		Notes:        strings.TrimSpace(in.Notes),
+		CreatedAt:    s.now().UTC(),
+		ExpiresAt:    in.ExpiresAt,
+		APIKey:       strings.TrimSpace(in.APIKey),
+		OwnerSubject: strings.TrimSpace(in.OwnerSubject),
+		MaxClicks:    in.MaxClicks,
+		SingleUse:    in.SingleUse,
+	}
+
+	switch {
+	case in.SingleUse:
+		remaining := int64(1)
+		link.ClicksRemaining = &remaining
+	case in.MaxClicks != nil:
+		remaining := *in.MaxClicks
+		link.ClicksRemaining = &remaining
+	}
+
+	if customSlug := strings.TrimSpace(in.CustomSlug); customSlug != "" {
+		if err := s.customSlugPolicy.Validate(customSlug); err != nil {
+			return nil, err
+		}
+		link.Slug = customSlug
+		if err := s.linkRepo.Insert(ctx, link); err != nil {
+			return nil, err
+		}
+		return link, nil
 	}
 
 	const maxAttempts = 10
-	for range maxAttempts {
-		slug, err := s.slugger.Generate(s.slugLength)
+	length := s.slugLength
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		slug, err := s.slugger.GenerateWithContext(ctx, SlugHint{Length: length, Attempt: attempt})
 		if err != nil {
 			return nil, err
 		}
@@ -53,6 +233,7 @@ func (s *Service) CreateLink(ctx context.Context, in CreateLinkInput) (*Link, er
 
 		if err := s.linkRepo.Insert(ctx, link); err != nil {
 			if err == ErrSlugTaken {
+				length++
 				continue
 			}
 			return nil, err
@@ -64,6 +245,59 @@ func (s *Service) CreateLink(ctx context.Context, in CreateLinkInput) (*Link, er
 	return nil, ErrSlugTaken
 }
 
+// CheckSlugAvailable reports whether slug passes CustomSlugPolicy and isn't
+// already claimed, so a UI can probe a vanity slug before submitting
+// CreateLink with it. It still returns a CreateLink-style error (not just
+// false) when slug itself is invalid, so the caller can tell "reserved
+// word" apart from "taken".
+func (s *Service) CheckSlugAvailable(ctx context.Context, slug string) (bool, error) {
+	slug = strings.TrimSpace(slug)
+	if slug == "" {
+		return false, ErrInvalidCustomSlug
+	}
+	if err := s.customSlugPolicy.Validate(slug); err != nil {
+		return false, err
+	}
+
+	_, err := s.linkRepo.FindBySlug(ctx, slug)
+	if err == nil {
+		return false, nil
+	}
+	if err == ErrNotFound {
+		return true, nil
+	}
+	return false, err
+}
+
+// hashCreateLinkInput hashes the fields of in that determine the link it
+// would create, so a repeat Idempotency-Key is only honored when the
+// retried request is actually the same one.
+func hashCreateLinkInput(in CreateLinkInput) string {
+	h := sha256.New()
+	h.Write([]byte(strings.TrimSpace(in.URL)))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimSpace(in.Notes)))
+	h.Write([]byte{0})
+	if in.ExpiresAt != nil {
+		h.Write([]byte(in.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimSpace(in.APIKey)))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimSpace(in.OwnerSubject)))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimSpace(in.CustomSlug)))
+	h.Write([]byte{0})
+	if in.MaxClicks != nil {
+		h.Write([]byte(strconv.FormatInt(*in.MaxClicks, 10)))
+	}
+	h.Write([]byte{0})
+	if in.SingleUse {
+		h.Write([]byte{1})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func (s *Service) GetLink(ctx context.Context, slug string) (*Link, error) {
 	slug = strings.TrimSpace(slug)
 	if slug == "" {
@@ -78,81 +312,202 @@ func (s *Service) GetLink(ctx context.Context, slug string) (*Link, error) {
 	return link, nil
 }
 
+// Resolve looks up slug for a redirect. With a HotCache configured, it
+// serves cached {URL, ExpiresAt} pairs straight from memory instead of
+// going through GetLink on every hit, deduplicating concurrent misses on
+// the same slug via HotCache.GetOrLoad's singleflight. A link with a
+// MaxClicks/SingleUse cap still has its budget decremented on every
+// resolve, cache hit or not - see LinkRepository.ConsumeClick - so a cache
+// hit never lets a capped link outlive its quota.
 func (s *Service) Resolve(ctx context.Context, slug string) (*Link, error) {
-	link, err := s.GetLink(ctx, slug)
-	if err != nil {
-		return nil, err
-	}
+	var link *Link
+	err := tracing.StartSpan(ctx, tracerName, "Resolve", []attribute.KeyValue{tracing.SlugAttr(slug)}, func(ctx context.Context) error {
+		var url string
+		var expiresAt *time.Time
+		var capped bool
 
-	if link.ExpiresAt != nil && s.now().UTC().After(link.ExpiresAt.UTC()) {
-		return nil, ErrExpired
-	}
+		if s.hotCache != nil {
+			cached, err := s.hotCache.GetOrLoad(ctx, slug, func(ctx context.Context) (HotCacheValue, error) {
+				l, err := s.GetLink(ctx, slug)
+				if err != nil {
+					return HotCacheValue{}, err
+				}
+				return HotCacheValue{URL: l.URL, ExpiresAt: l.ExpiresAt, Capped: l.ClicksRemaining != nil}, nil
+			})
+			if err != nil {
+				return err
+			}
+			url, expiresAt, capped = cached.URL, cached.ExpiresAt, cached.Capped
+		} else {
+			l, err := s.GetLink(ctx, slug)
+			if err != nil {
+				return err
+			}
+			url, expiresAt, capped = l.URL, l.ExpiresAt, l.ClicksRemaining != nil
+		}
 
-	return link, nil
+		if expiresAt != nil && s.now().UTC().After(expiresAt.UTC()) {
+			return ErrExpired
+		}
+
+		if capped {
+			if _, err := s.linkRepo.ConsumeClick(ctx, slug); err != nil {
+				return err
+			}
+		}
+
+		link = &Link{Slug: slug, URL: url, ExpiresAt: expiresAt}
+		return nil
+	})
+	return link, err
 }
 
+// RecordClick enqueues a click event for a slug onto outboxRepo rather than
+// writing stats directly, so redirect latency never waits on a stats write:
+// with the transactional outbox, a crash between resolving the link and
+// recording the click can never silently drop it, since the background
+// dispatcher applies it to StatsRepository exactly once; with a
+// BufferedClickRecorder instead, EnqueueClick is itself just a channel send,
+// trading that durability for redirect-time writes cheap enough to run
+// unbuffered at high TPS. A nil outboxRepo (e.g. in tests, or a deployment
+// that opts out of click recording) makes this a no-op.
 func (s *Service) RecordClick(ctx context.Context, slug string) error {
 	if strings.TrimSpace(slug) == "" {
 		return nil
 	}
-	return s.statsRepo.IncDaily(ctx, slug, s.now().UTC())
+	if s.outboxRepo == nil {
+		return nil
+	}
+	return s.outboxRepo.EnqueueClick(ctx, slug, s.now().UTC())
 }
 
-func (s *Service) GetStats(ctx context.Context, slug string, from, to time.Time) ([]DailyCount, error) {
+// GetStats returns the daily click breakdown for [from, to] alongside the
+// link's current ClicksRemaining (nil for an uncapped link).
+func (s *Service) GetStats(ctx context.Context, slug string, from, to time.Time) (*LinkStats, error) {
+	var out *LinkStats
+	err := tracing.StartSpan(ctx, tracerName, "GetStats", []attribute.KeyValue{tracing.SlugAttr(slug)}, func(ctx context.Context) error {
+		link, err := s.GetLink(ctx, slug)
+		if err != nil {
+			return err
+		}
+
+		from = from.UTC()
+		to = to.UTC()
+		if to.Before(from) {
+			return ErrInvalidRange
+		}
+
+		byDate := make(map[string]int64)
+
+		// Days before today are closed and, if a RollupReader is configured,
+		// come from the pre-aggregated rollup table. Today is still live and
+		// always read straight from StatsRepository.
+		liveFrom := from
+		if s.rollupReader != nil {
+			today := dateOnly(s.now().UTC())
+			closedTo := to
+			if !closedTo.Before(today) {
+				closedTo = today.AddDate(0, 0, -1)
+			}
+			if !closedTo.Before(from) {
+				rolled, err := s.rollupReader.GetDaily(ctx, slug, from, closedTo)
+				if err != nil {
+					return err
+				}
+				for _, c := range rolled {
+					byDate[c.Date] = c.Count
+				}
+				liveFrom = closedTo.AddDate(0, 0, 1)
+			}
+		}
+
+		if !liveFrom.After(to) {
+			counts, err := s.statsRepo.GetDaily(ctx, slug, liveFrom, to)
+			if err != nil {
+				return err
+			}
+			for _, c := range counts {
+				byDate[c.Date] = c.Count
+			}
+		}
+
+		result := make([]DailyCount, 0, int(to.Sub(from).Hours()/24)+1)
+		for day := dateOnly(from); !day.After(dateOnly(to)); day = day.AddDate(0, 0, 1) {
+			ds := day.Format(time.DateOnly)
+			result = append(result, DailyCount{
+				Date:  ds,
+				Count: byDate[ds],
+			})
+		}
+
+		out = &LinkStats{Daily: result, ClicksRemaining: link.ClicksRemaining}
+		return nil
+	})
+	return out, err
+}
+
+// GetStatsForClaims is GetStats with an ownership check: a verified caller
+// (claims.Subject, from links/auth.Verifier.Verify) must match the link's
+// OwnerSubject before its stats are returned. A link with no OwnerSubject,
+// or a claims.Subject left empty, has nothing to enforce and is let
+// through - the same fail-open posture internal/middleware.OwnsLink applies
+// to the legacy API-key ownership path.
+func (s *Service) GetStatsForClaims(ctx context.Context, slug string, from, to time.Time, claims auth.Claims) (*LinkStats, error) {
 	link, err := s.GetLink(ctx, slug)
 	if err != nil {
 		return nil, err
 	}
-	_ = link
-
-	from = from.UTC()
-	to = to.UTC()
-	if to.Before(from) {
-		return nil, ErrInvalidRange
-	}
-
-	counts, err := s.statsRepo.GetDaily(ctx, slug, from, to)
-	if err != nil {
+	if err := authorizeOwner(link, claims.Subject); err != nil {
 		return nil, err
 	}
+	return s.GetStats(ctx, slug, from, to)
+}
 
-	byDate := make(map[string]int64, len(counts))
-	for _, c := range counts {
-		byDate[c.Date] = c.Count
+func authorizeOwner(link *Link, subject string) error {
+	if link.OwnerSubject == "" || subject == "" {
+		return nil
 	}
-
-	out := make([]DailyCount, 0, int(to.Sub(from).Hours()/24)+1)
-	for day := dateOnly(from); !day.After(dateOnly(to)); day = day.AddDate(0, 0, 1) {
-		ds := day.Format(time.DateOnly)
-		out = append(out, DailyCount{
-			Date:  ds,
-			Count: byDate[ds],
-		})
+	if link.OwnerSubject != subject {
+		return ErrForbidden
 	}
-
-	return out, nil
+	return nil
 }
 
-func validateAndNormalizeURL(raw string) (string, error) {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return "", ErrInvalidURL
+// DeleteLinkForClaims is DeleteLink with the same ownership check
+// GetStatsForClaims applies: a verified caller (claims.Subject) must match
+// the link's OwnerSubject before it can be deleted. A link with no
+// OwnerSubject, or a claims.Subject left empty, has nothing to enforce and
+// is let through - see authorizeOwner.
+func (s *Service) DeleteLinkForClaims(ctx context.Context, slug string, claims auth.Claims) error {
+	link, err := s.GetLink(ctx, slug)
+	if err != nil {
+		return err
 	}
+	if err := authorizeOwner(link, claims.Subject); err != nil {
+		return err
+	}
+	return s.DeleteLink(ctx, slug)
+}
 
-	u, err := url.Parse(raw)
-	if err != nil {
-		return "", err
+// DeleteLink removes a link and its stats. ErrNotFound covers both an
+// empty slug and a slug the repository doesn't recognize.
+func (s *Service) DeleteLink(ctx context.Context, slug string) error {
+	slug = strings.TrimSpace(slug)
+	if slug == "" {
+		return ErrNotFound
 	}
 
-	if u.Scheme != "http" && u.Scheme != "https" {
-		return "", ErrInvalidURL
+	deleted, err := s.linkRepo.DeleteBySlug(ctx, slug)
+	if err != nil {
+		return err
 	}
-	if strings.TrimSpace(u.Host) == "" {
-		return "", ErrInvalidURL
+	if !deleted {
+		return ErrNotFound
 	}
 
-	u.Fragment = ""
-	return u.String(), nil
+	s.deleteNotifier.publish(slug)
+
+	return s.statsRepo.DeleteBySlug(ctx, slug)
 }
 
 func dateOnly(t time.Time) time.Time {
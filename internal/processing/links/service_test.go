@@ -5,6 +5,12 @@ import (
 	"errors"
 	"testing"
 	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links/auth"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links/urlpolicy"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // --- Hand-written mocks ---
@@ -14,6 +20,7 @@ type mockLinkRepo struct {
 	findBySlugFn   func(ctx context.Context, slug string) (*Link, error)
 	findActiveFn   func(ctx context.Context, slug string, at time.Time) (*Link, error)
 	deleteBySlugFn func(ctx context.Context, slug string) (bool, error)
+	consumeClickFn func(ctx context.Context, slug string) (int64, error)
 }
 
 func (m *mockLinkRepo) Insert(ctx context.Context, link *Link) error {
@@ -31,9 +38,12 @@ func (m *mockLinkRepo) FindActiveBySlugAndIncClick(ctx context.Context, slug str
 func (m *mockLinkRepo) DeleteBySlug(ctx context.Context, slug string) (bool, error) {
 	return m.deleteBySlugFn(ctx, slug)
 }
+func (m *mockLinkRepo) ConsumeClick(ctx context.Context, slug string) (int64, error) {
+	return m.consumeClickFn(ctx, slug)
+}
 
 type mockStatsRepo struct {
-	getDailyFn    func(ctx context.Context, slug string, from, to time.Time) ([]DailyCount, error)
+	getDailyFn     func(ctx context.Context, slug string, from, to time.Time) ([]DailyCount, error)
 	deleteBySlugFn func(ctx context.Context, slug string) error
 }
 
@@ -67,41 +77,40 @@ func (m *mockSlugger) Generate(int) (string, error) {
 	return s, nil
 }
 
-// --- Tests for validateAndNormalizeURL ---
-
-func TestValidateAndNormalizeURL(t *testing.T) {
-	tests := []struct {
-		name    string
-		raw     string
-		want    string
-		wantErr bool
-	}{
-		{"valid https", "https://example.com/path", "https://example.com/path", false},
-		{"valid http", "http://example.com", "http://example.com", false},
-		{"strips fragment", "https://example.com/page#section", "https://example.com/page", false},
-		{"empty string", "", "", true},
-		{"bad scheme ftp", "ftp://example.com", "", true},
-		{"no scheme", "example.com", "", true},
-		{"missing host", "https://", "", true},
-		{"whitespace trimmed", "  https://example.com  ", "https://example.com", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := validateAndNormalizeURL(tt.raw)
-			if tt.wantErr {
-				if err == nil {
-					t.Errorf("expected error for %q", tt.raw)
-				}
-				return
-			}
-			if err != nil {
-				t.Fatalf("unexpected error: %v", err)
-			}
-			if got != tt.want {
-				t.Errorf("got %q, want %q", got, tt.want)
-			}
-		})
+func (m *mockSlugger) GenerateWithContext(context.Context, SlugHint) (string, error) {
+	return m.Generate(0)
+}
+
+// --- Tests for CreateLink's URLPolicy wiring (see urlpolicy package for
+// Policy.Validate's own test coverage) ---
+
+func TestCreateLink_URLPolicyNormalizesURL(t *testing.T) {
+	lr := &mockLinkRepo{insertFn: func(_ context.Context, _ *Link) error { return nil }}
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{slugs: []string{"abc123"}})
+
+	link, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "  https://Example.com/page#section  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.URL != "https://example.com/page" {
+		t.Errorf("got URL %q, want %q", link.URL, "https://example.com/page")
+	}
+}
+
+func TestCreateLink_URLPolicyRejectsDeniedIP(t *testing.T) {
+	lr := &mockLinkRepo{insertFn: func(_ context.Context, _ *Link) error { return nil }}
+	policy := urlpolicy.DefaultPolicy()
+	policy.ResolveHost = false
+	svc := NewServiceWithOptions(lr, &mockStatsRepo{}, nil, &mockSlugger{}, 6, ServiceOptions{URLPolicy: &policy})
+
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "http://169.254.169.254/latest/meta-data/"})
+	if !errors.Is(err, ErrInvalidURL) {
+		t.Fatalf("expected ErrInvalidURL, got: %v", err)
+	}
+
+	var verr *URLValidationError
+	if !errors.As(err, &verr) || verr.Code != urlpolicy.CodeIPDenied {
+		t.Fatalf("expected a URLValidationError with CodeIPDenied, got: %v", err)
 	}
 }
 
@@ -118,8 +127,13 @@ func TestDateOnly(t *testing.T) {
 
 // --- Tests for Service ---
 
+// testURLPolicy leaves every CreateLink test's "https://example.com"-style
+// fixtures free of real DNS lookups: ResolveHost is off, so these tests
+// stay hermetic the way the rest of this file's mocked collaborators do.
+var testURLPolicy = urlpolicy.Policy{MaxURLLength: 2048}
+
 func newTestService(lr *mockLinkRepo, sr *mockStatsRepo, or ClickOutboxRepository, sl *mockSlugger) *Service {
-	svc := NewService(lr, sr, or, sl, 6)
+	svc := NewServiceWithOptions(lr, sr, or, sl, 6, ServiceOptions{URLPolicy: &testURLPolicy})
 	svc.now = func() time.Time {
 		return time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
 	}
@@ -201,6 +215,106 @@ func TestCreateLink_AllRetriesExhausted(t *testing.T) {
 	}
 }
 
+func TestCreateLink_CustomSlugUsedVerbatim(t *testing.T) {
+	lr := &mockLinkRepo{
+		insertFn: func(_ context.Context, link *Link) error {
+			if link.Slug != "launch" {
+				t.Errorf("got slug %q, want %q", link.Slug, "launch")
+			}
+			return nil
+		},
+	}
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	link, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com", CustomSlug: "launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Slug != "launch" {
+		t.Errorf("got slug %q, want %q", link.Slug, "launch")
+	}
+}
+
+func TestCreateLink_CustomSlugRejectsBadPattern(t *testing.T) {
+	svc := newTestService(&mockLinkRepo{}, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com", CustomSlug: "a"})
+	if !errors.Is(err, ErrInvalidCustomSlug) {
+		t.Fatalf("expected ErrInvalidCustomSlug, got: %v", err)
+	}
+}
+
+func TestCreateLink_CustomSlugRejectsReservedWord(t *testing.T) {
+	svc := newTestService(&mockLinkRepo{}, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com", CustomSlug: "admin"})
+	if !errors.Is(err, ErrInvalidCustomSlug) {
+		t.Fatalf("expected ErrInvalidCustomSlug, got: %v", err)
+	}
+}
+
+func TestCreateLink_CustomSlugConflictDoesNotRetry(t *testing.T) {
+	attempts := 0
+	lr := &mockLinkRepo{
+		insertFn: func(_ context.Context, _ *Link) error {
+			attempts++
+			return ErrSlugTaken
+		},
+	}
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com", CustomSlug: "launch"})
+	if !errors.Is(err, ErrSlugTaken) {
+		t.Fatalf("expected ErrSlugTaken, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected exactly one insert attempt (no retry), got %d", attempts)
+	}
+}
+
+func TestCheckSlugAvailable_Free(t *testing.T) {
+	lr := &mockLinkRepo{
+		findBySlugFn: func(_ context.Context, _ string) (*Link, error) {
+			return nil, ErrNotFound
+		},
+	}
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	ok, err := svc.CheckSlugAvailable(context.Background(), "launch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("expected slug to be available")
+	}
+}
+
+func TestCheckSlugAvailable_Taken(t *testing.T) {
+	lr := &mockLinkRepo{
+		findBySlugFn: func(_ context.Context, _ string) (*Link, error) {
+			return &Link{Slug: "launch"}, nil
+		},
+	}
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	ok, err := svc.CheckSlugAvailable(context.Background(), "launch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Errorf("expected slug to be unavailable")
+	}
+}
+
+func TestCheckSlugAvailable_RejectsReservedWord(t *testing.T) {
+	svc := newTestService(&mockLinkRepo{}, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	_, err := svc.CheckSlugAvailable(context.Background(), "api")
+	if !errors.Is(err, ErrInvalidCustomSlug) {
+		t.Fatalf("expected ErrInvalidCustomSlug, got: %v", err)
+	}
+}
+
 func TestGetLink_EmptySlug(t *testing.T) {
 	svc := newTestService(&mockLinkRepo{}, &mockStatsRepo{}, nil, &mockSlugger{})
 
@@ -260,6 +374,107 @@ func TestResolve_DelegatesToRepo(t *testing.T) {
 	}
 }
 
+func TestResolve_UncappedLinkNeverConsumesClick(t *testing.T) {
+	lr := &mockLinkRepo{
+		findBySlugFn: func(_ context.Context, _ string) (*Link, error) {
+			return &Link{Slug: "abc", URL: "https://example.com"}, nil
+		},
+		consumeClickFn: func(_ context.Context, _ string) (int64, error) {
+			t.Fatal("ConsumeClick should not be called for an uncapped link")
+			return 0, nil
+		},
+	}
+
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	if _, err := svc.Resolve(context.Background(), "abc"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolve_CappedLinkConsumesClick(t *testing.T) {
+	remaining := int64(2)
+	consumed := false
+	lr := &mockLinkRepo{
+		findBySlugFn: func(_ context.Context, _ string) (*Link, error) {
+			return &Link{Slug: "abc", URL: "https://example.com", ClicksRemaining: &remaining}, nil
+		},
+		consumeClickFn: func(_ context.Context, slug string) (int64, error) {
+			consumed = true
+			if slug != "abc" {
+				t.Errorf("got slug %q, want %q", slug, "abc")
+			}
+			return 1, nil
+		},
+	}
+
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	if _, err := svc.Resolve(context.Background(), "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if !consumed {
+		t.Errorf("expected ConsumeClick to be called for a capped link")
+	}
+}
+
+func TestResolve_ExhaustedBudgetReturnsExpired(t *testing.T) {
+	zero := int64(0)
+	lr := &mockLinkRepo{
+		findBySlugFn: func(_ context.Context, _ string) (*Link, error) {
+			return &Link{Slug: "abc", URL: "https://example.com", ClicksRemaining: &zero}, nil
+		},
+		consumeClickFn: func(_ context.Context, _ string) (int64, error) {
+			return 0, ErrExpired
+		},
+	}
+
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	_, err := svc.Resolve(context.Background(), "abc")
+	if !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired, got: %v", err)
+	}
+}
+
+func TestCreateLink_SingleUseSeedsOneClickRemaining(t *testing.T) {
+	lr := &mockLinkRepo{
+		insertFn: func(_ context.Context, link *Link) error {
+			if link.ClicksRemaining == nil || *link.ClicksRemaining != 1 {
+				t.Errorf("expected ClicksRemaining of 1, got %v", link.ClicksRemaining)
+			}
+			return nil
+		},
+	}
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{slugs: []string{"abc123"}})
+
+	link, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com", SingleUse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !link.SingleUse {
+		t.Errorf("expected SingleUse to be true")
+	}
+}
+
+func TestCreateLink_MaxClicksSeedsClicksRemaining(t *testing.T) {
+	maxClicks := int64(5)
+	lr := &mockLinkRepo{
+		insertFn: func(_ context.Context, link *Link) error {
+			if link.ClicksRemaining == nil || *link.ClicksRemaining != 5 {
+				t.Errorf("expected ClicksRemaining of 5, got %v", link.ClicksRemaining)
+			}
+			return nil
+		},
+	}
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{slugs: []string{"abc123"}})
+
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com", MaxClicks: &maxClicks})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestRecordClick_NilOutbox(t *testing.T) {
 	svc := newTestService(&mockLinkRepo{}, &mockStatsRepo{}, nil, &mockSlugger{})
 
@@ -326,10 +541,11 @@ func TestGetStats_GapFilling(t *testing.T) {
 	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	to := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
 
-	counts, err := svc.GetStats(context.Background(), "abc", from, to)
+	stats, err := svc.GetStats(context.Background(), "abc", from, to)
 	if err != nil {
 		t.Fatal(err)
 	}
+	counts := stats.Daily
 
 	if len(counts) != 3 {
 		t.Fatalf("expected 3 days, got %d", len(counts))
@@ -347,6 +563,71 @@ func TestGetStats_GapFilling(t *testing.T) {
 	if counts[2].Date != "2025-01-03" || counts[2].Count != 3 {
 		t.Errorf("day 2: got %+v", counts[2])
 	}
+	if stats.ClicksRemaining != nil {
+		t.Errorf("expected nil ClicksRemaining for an uncapped link, got %v", *stats.ClicksRemaining)
+	}
+}
+
+func TestGetStatsForClaims_WrongSubjectForbidden(t *testing.T) {
+	lr := &mockLinkRepo{
+		findBySlugFn: func(_ context.Context, _ string) (*Link, error) {
+			return &Link{Slug: "abc", OwnerSubject: "owner-1"}, nil
+		},
+	}
+
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	_, err := svc.GetStatsForClaims(context.Background(), "abc", from, to, auth.Claims{Subject: "owner-2"})
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got: %v", err)
+	}
+}
+
+func TestGetStatsForClaims_MatchingSubjectAllowed(t *testing.T) {
+	lr := &mockLinkRepo{
+		findBySlugFn: func(_ context.Context, _ string) (*Link, error) {
+			return &Link{Slug: "abc", OwnerSubject: "owner-1"}, nil
+		},
+	}
+	sr := &mockStatsRepo{
+		getDailyFn: func(_ context.Context, _ string, _, _ time.Time) ([]DailyCount, error) {
+			return nil, nil
+		},
+	}
+
+	svc := newTestService(lr, sr, nil, &mockSlugger{})
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := svc.GetStatsForClaims(context.Background(), "abc", from, to, auth.Claims{Subject: "owner-1"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
+
+func TestGetStatsForClaims_NoOwnerSubjectAllowsAnyCaller(t *testing.T) {
+	lr := &mockLinkRepo{
+		findBySlugFn: func(_ context.Context, _ string) (*Link, error) {
+			return &Link{Slug: "abc"}, nil
+		},
+	}
+	sr := &mockStatsRepo{
+		getDailyFn: func(_ context.Context, _ string, _, _ time.Time) ([]DailyCount, error) {
+			return nil, nil
+		},
+	}
+
+	svc := newTestService(lr, sr, nil, &mockSlugger{})
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := svc.GetStatsForClaims(context.Background(), "abc", from, to, auth.Claims{Subject: "anyone"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
 }
 
 func TestDeleteLink_NotFound(t *testing.T) {
@@ -372,3 +653,29 @@ func TestDeleteLink_EmptySlug(t *testing.T) {
 		t.Fatalf("expected ErrNotFound for empty slug, got: %v", err)
 	}
 }
+
+func TestResolve_RecordsSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	lr := &mockLinkRepo{
+		findActiveFn: func(_ context.Context, slug string, _ time.Time) (*Link, error) {
+			return &Link{Slug: slug, URL: "https://example.com"}, nil
+		},
+	}
+	svc := newTestService(lr, &mockStatsRepo{}, nil, &mockSlugger{})
+
+	if _, err := svc.Resolve(context.Background(), "xyz"); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got, want := spans[0].Name(), "links.service.Resolve"; got != want {
+		t.Errorf("got span name %q, want %q", got, want)
+	}
+}
@@ -1,11 +1,18 @@
 package links
 
 import (
+	"context"
 	"crypto/rand"
 )
 
 const base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
+// maxUnbiasedByte is the largest multiple of len(base62Alphabet) that fits
+// in a byte. Bytes at or above it are rejected and re-rolled so that
+// byte%len(base62Alphabet) stays uniform instead of favoring the low end
+// of the alphabet.
+const maxUnbiasedByte = 256 - (256 % len(base62Alphabet))
+
 type CryptoSlugger struct{}
 
 func NewCryptoSlugger() *CryptoSlugger { return &CryptoSlugger{} }
@@ -15,16 +22,28 @@ func (s *CryptoSlugger) Generate(length int) (string, error) {
 		length = 6
 	}
 
-	buf := make([]byte, length)
-	if _, err := rand.Read(buf); err != nil {
-		return "", err
-	}
-
 	out := make([]byte, length)
-	for i := range buf {
-		out[i] = base62Alphabet[int(buf[i])%len(base62Alphabet)]
+	buf := make([]byte, 1)
+	for i := range out {
+		for {
+			if _, err := rand.Read(buf); err != nil {
+				return "", err
+			}
+			if int(buf[0]) < maxUnbiasedByte {
+				out[i] = base62Alphabet[int(buf[0])%len(base62Alphabet)]
+				break
+			}
+		}
 	}
 
 	return string(out), nil
 }
 
+// GenerateWithContext satisfies Slugger; crypto/rand never blocks on ctx, so
+// this just forwards to Generate with the hinted length.
+func (s *CryptoSlugger) GenerateWithContext(ctx context.Context, hint SlugHint) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return s.Generate(hint.Length)
+}
@@ -0,0 +1,47 @@
+package links
+
+import (
+	"regexp"
+	"strings"
+)
+
+var defaultCustomSlugPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,40}$`)
+
+// defaultReservedSlugs blocks custom slugs that would shadow this service's
+// own top-level routes (see internal/transport/http/router.go) rather than
+// resolve to a redirect.
+var defaultReservedSlugs = []string{"api", "stats", "health", "admin", "metrics", "graphql", "internal"}
+
+// CustomSlugPolicy governs which CreateLinkInput.CustomSlug values
+// Service.CreateLink accepts. A zero-value ServiceOptions.CustomSlugPolicy
+// falls back to DefaultCustomSlugPolicy, mirroring how ServiceOptions.URLPolicy
+// falls back to urlpolicy.DefaultPolicy.
+type CustomSlugPolicy struct {
+	Pattern  *regexp.Regexp
+	Reserved map[string]struct{}
+}
+
+// DefaultCustomSlugPolicy requires 3-40 characters of letters, digits,
+// underscores and hyphens, and rejects slugs that collide with this
+// service's own route prefixes.
+func DefaultCustomSlugPolicy() CustomSlugPolicy {
+	reserved := make(map[string]struct{}, len(defaultReservedSlugs))
+	for _, s := range defaultReservedSlugs {
+		reserved[s] = struct{}{}
+	}
+	return CustomSlugPolicy{Pattern: defaultCustomSlugPattern, Reserved: reserved}
+}
+
+// Validate reports whether slug is an acceptable custom slug, returning
+// ErrInvalidCustomSlug if it fails the pattern or is reserved. It never
+// returns ErrSlugTaken - that's reserved for an otherwise-valid slug already
+// claimed by another link, which only linkRepo.Insert can detect.
+func (p CustomSlugPolicy) Validate(slug string) error {
+	if p.Pattern != nil && !p.Pattern.MatchString(slug) {
+		return ErrInvalidCustomSlug
+	}
+	if _, reserved := p.Reserved[strings.ToLower(slug)]; reserved {
+		return ErrInvalidCustomSlug
+	}
+	return nil
+}
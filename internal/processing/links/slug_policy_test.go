@@ -0,0 +1,43 @@
+package links
+
+import "testing"
+
+func TestCustomSlugPolicy_Validate(t *testing.T) {
+	p := DefaultCustomSlugPolicy()
+
+	tests := []struct {
+		name    string
+		slug    string
+		wantErr bool
+	}{
+		{"valid lowercase", "launch", false},
+		{"valid with digits and hyphen", "launch-2026", false},
+		{"valid with underscore", "my_link", false},
+		{"too short", "ab", true},
+		{"too long", stringOfLen(41), true},
+		{"max length allowed", stringOfLen(40), false},
+		{"disallowed character", "launch!", true},
+		{"reserved word", "api", true},
+		{"reserved word case-insensitive", "API", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Validate(tt.slug)
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected an error for slug %q", tt.slug)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error for slug %q: %v", tt.slug, err)
+			}
+		})
+	}
+}
+
+func stringOfLen(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = 'a'
+	}
+	return string(b)
+}
@@ -1,6 +1,7 @@
 package links
 
 import (
+	"context"
 	"strings"
 	"testing"
 )
@@ -63,4 +64,22 @@ func TestCryptoSluggerGenerate(t *testing.T) {
 			seen[slug] = struct{}{}
 		}
 	})
+
+	t.Run("GenerateWithContext honors hinted length", func(t *testing.T) {
+		slug, err := s.GenerateWithContext(context.Background(), SlugHint{Length: 12})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(slug) != 12 {
+			t.Errorf("got length %d, want 12", len(slug))
+		}
+	})
+
+	t.Run("GenerateWithContext respects canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := s.GenerateWithContext(ctx, SlugHint{Length: 8}); err == nil {
+			t.Error("expected error for canceled context")
+		}
+	})
 }
@@ -9,7 +9,27 @@ type Link struct {
 	CreatedAt time.Time
 	ExpiresAt *time.Time
 	APIKey    string
-	Clicks    int64
+
+	// OwnerSubject is the JWT subject (see links/auth) that created this
+	// link, if CreateLinkInput.OwnerSubject was set. A link created without
+	// one (the common case for the legacy APIKey-only path) has no owner
+	// JWT can enforce - see authorizeOwner.
+	OwnerSubject string
+
+	Clicks int64
+
+	// MaxClicks, if set, caps the number of times this link may resolve
+	// before Service.Resolve starts returning ErrExpired. SingleUse is
+	// shorthand for a cap of one. Neither is enforced directly off these
+	// fields - they just seed ClicksRemaining at creation; the live budget
+	// lives there and in linkRepo, decremented atomically by ConsumeClick.
+	MaxClicks *int64
+	SingleUse bool
+
+	// ClicksRemaining is nil for a link with no MaxClicks/SingleUse cap
+	// (unlimited resolves), and otherwise the number of resolves left
+	// before Resolve returns ErrExpired - see LinkRepository.ConsumeClick.
+	ClicksRemaining *int64
 }
 
 type DailyCount struct {
@@ -17,9 +37,94 @@ type DailyCount struct {
 	Count int64  `json:"count"`
 }
 
+// ClickEvent is one resolved redirect, captured beyond the daily counter
+// when a ClickEventRepository is configured. VisitorHash lets
+// BreakdownByCountry/BreakdownByUA de-duplicate repeat visits from the same
+// IP without ClickEventRepository ever storing the IP itself - see
+// Service.hashVisitor.
+type ClickEvent struct {
+	Slug         string
+	OccurredAt   time.Time
+	ReferrerHost string
+	Browser      string
+	OS           string
+	CountryCode  string
+	VisitorHash  string
+}
+
+// RecordClickOptions carries the raw HTTP request context RecordClick needs
+// to populate a ClickEvent - the handler's job, not Service's, to read off
+// *http.Request. Every field is optional; a zero value just means that
+// dimension of the resulting ClickEvent is left blank.
+type RecordClickOptions struct {
+	IP        string
+	UserAgent string
+	Referer   string
+}
+
+// ReferrerCount is one row of Service.GetStatsBreakdown's TopReferrers,
+// ranking distinct referrer hosts by click volume.
+type ReferrerCount struct {
+	Host  string `json:"host"`
+	Count int64  `json:"count"`
+}
+
+// CountryCount is one row of Service.GetStatsBreakdown's ByCountry.
+type CountryCount struct {
+	CountryCode string `json:"country_code"`
+	Count       int64  `json:"count"`
+}
+
+// UACount is one row of Service.GetStatsBreakdown's ByUA, a single
+// browser/OS combination and how many clicks matched it.
+type UACount struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Count   int64  `json:"count"`
+}
+
+// StatsBreakdown is Service.GetStatsBreakdown's result: the same range a
+// GetStats call would cover, sliced by referrer, country, and user agent
+// instead of by day.
+type StatsBreakdown struct {
+	TopReferrers []ReferrerCount `json:"top_referrers"`
+	ByCountry    []CountryCount  `json:"by_country"`
+	ByUA         []UACount       `json:"by_ua"`
+}
+
+// LinkStats is Service.GetStats' result: the daily breakdown for the
+// requested range, alongside the link's current ClicksRemaining (nil for a
+// link with no MaxClicks/SingleUse cap).
+type LinkStats struct {
+	Daily           []DailyCount `json:"daily"`
+	ClicksRemaining *int64       `json:"clicks_remaining,omitempty"`
+}
+
 type CreateLinkInput struct {
 	URL       string
 	Notes     string
 	ExpiresAt *time.Time
 	APIKey    string
+
+	// OwnerSubject, if set, is the verified JWT subject (links/auth.Claims.Subject)
+	// that's creating this link, stored onto Link.OwnerSubject so a later
+	// GetStatsForClaims call can enforce that only this subject may read it.
+	OwnerSubject string
+
+	// IdempotencyKey, if set, makes CreateLink safe to retry: a second call
+	// with the same key and an identical request returns the link created
+	// by the first, instead of minting a second slug. See IdempotencyRepository.
+	IdempotencyKey string
+
+	// CustomSlug, if set, makes CreateLink use this slug instead of
+	// generating one - validated against CustomSlugPolicy and inserted once,
+	// without the auto-generated path's collision-retry loop. A conflict
+	// returns ErrSlugTaken immediately. See Service.CheckSlugAvailable.
+	CustomSlug string
+
+	// MaxClicks and SingleUse seed the created Link's ClicksRemaining
+	// budget - see Link.MaxClicks. SingleUse is equivalent to a MaxClicks
+	// of 1; setting both just makes the cap explicit in the request.
+	MaxClicks *int64
+	SingleUse bool
 }
@@ -0,0 +1,215 @@
+// Package urlpolicy validates and normalizes the destination URLs
+// Service.CreateLink is asked to shorten, refusing ones that could turn the
+// shortener into an SSRF vector against its own network - a cloud metadata
+// endpoint, loopback, or a private range - rather than trusting scheme and
+// a non-empty host alone.
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// Code is a stable, switchable reason Validate rejected a URL. Message
+// carries the human-readable detail; Code is what a caller should branch
+// on, since Message's wording isn't part of any compatibility guarantee.
+type Code string
+
+const (
+	CodeInvalidURL         Code = "invalid_url"
+	CodeTooLong            Code = "url_too_long"
+	CodeSchemeNotAllowed   Code = "scheme_not_allowed"
+	CodeUserinfoNotAllowed Code = "userinfo_not_allowed"
+	CodeMissingHost        Code = "missing_host"
+	CodeHostNotAllowed     Code = "host_not_allowed"
+	CodeHostDenied         Code = "host_denied"
+	CodeIPDenied           Code = "ip_denied"
+	CodeDNSFailure         Code = "dns_failure"
+)
+
+// ValidationError is what Validate returns on rejection.
+type ValidationError struct {
+	Code    Code
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("urlpolicy: %s: %s", e.Code, e.Message)
+}
+
+// HostResolver is the subset of *net.Resolver Validate needs to check a
+// hostname's resolved IPs against DeniedCIDRs - closing the gap a
+// hostname-only check leaves open for DNS rebinding. net.DefaultResolver
+// satisfies it; tests substitute a fake to avoid a real lookup.
+type HostResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Policy is Service's SSRF guard: every CreateLink call runs its candidate
+// URL through Validate before the link is stored.
+type Policy struct {
+	// DeniedCIDRs blocks any URL whose host - or, with ResolveHost, any IP
+	// it resolves to - falls inside one of these ranges.
+	DeniedCIDRs []*net.IPNet
+
+	// AllowedHosts, if non-empty, makes this an allowlist: only these hosts
+	// (case-insensitive exact match, checked after IDNA encoding) may be
+	// shortened, and DeniedHosts is ignored.
+	AllowedHosts []string
+
+	// DeniedHosts blocks specific hostnames outright, regardless of what
+	// they resolve to.
+	DeniedHosts []string
+
+	// MaxURLLength rejects a URL longer than this many bytes before any
+	// parsing happens. Zero means unbounded.
+	MaxURLLength int
+
+	// ResolveHost, if true, resolves a non-IP-literal host via Resolver
+	// (net.DefaultResolver if nil) and rejects the URL if any resolved IP
+	// falls in DeniedCIDRs.
+	ResolveHost bool
+	Resolver    HostResolver
+}
+
+// DefaultPolicy is what Service uses when ServiceOptions.URLPolicy is nil:
+// http/https only, a 2048-byte cap, and DeniedCIDRs covering RFC1918,
+// loopback, link-local (which also covers the AWS/GCP metadata address,
+// 169.254.169.254), IPv6 ULA, 0.0.0.0/8, and the shared NAT range
+// 100.64.0.0/10 - with ResolveHost on, so a hostname that resolves into one
+// of these ranges is caught the same as an IP literal would be.
+func DefaultPolicy() Policy {
+	return Policy{
+		DeniedCIDRs:  defaultDeniedCIDRs(),
+		MaxURLLength: 2048,
+		ResolveHost:  true,
+	}
+}
+
+func defaultDeniedCIDRs() []*net.IPNet {
+	literals := []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+	}
+	cidrs := make([]*net.IPNet, 0, len(literals))
+	for _, l := range literals {
+		_, n, err := net.ParseCIDR(l)
+		if err != nil {
+			panic("urlpolicy: invalid default CIDR literal " + l)
+		}
+		cidrs = append(cidrs, n)
+	}
+	return cidrs
+}
+
+// Validate parses, checks, and normalizes raw against p: lowercases and
+// IDNA-encodes the host, strips a redundant default port (:80 on http,
+// :443 on https) and the fragment, and rejects a URL carrying userinfo. On
+// success it returns the normalized URL string; on rejection, a
+// *ValidationError.
+func (p Policy) Validate(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", &ValidationError{Code: CodeInvalidURL, Message: "url is empty"}
+	}
+	if p.MaxURLLength > 0 && len(raw) > p.MaxURLLength {
+		return "", &ValidationError{Code: CodeTooLong, Message: fmt.Sprintf("url exceeds %d bytes", p.MaxURLLength)}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", &ValidationError{Code: CodeInvalidURL, Message: err.Error()}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", &ValidationError{Code: CodeSchemeNotAllowed, Message: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+	if u.User != nil {
+		return "", &ValidationError{Code: CodeUserinfoNotAllowed, Message: "url must not contain userinfo"}
+	}
+
+	host := strings.ToLower(strings.TrimSpace(u.Hostname()))
+	if host == "" {
+		return "", &ValidationError{Code: CodeMissingHost, Message: "url has no host"}
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		encoded, err := idna.Lookup.ToASCII(host)
+		if err != nil {
+			return "", &ValidationError{Code: CodeInvalidURL, Message: "invalid host: " + err.Error()}
+		}
+		host = encoded
+	}
+
+	if len(p.AllowedHosts) > 0 {
+		if !containsFold(p.AllowedHosts, host) {
+			return "", &ValidationError{Code: CodeHostNotAllowed, Message: fmt.Sprintf("host %q is not allowlisted", host)}
+		}
+	} else if containsFold(p.DeniedHosts, host) {
+		return "", &ValidationError{Code: CodeHostDenied, Message: fmt.Sprintf("host %q is denied", host)}
+	}
+
+	if ip != nil {
+		if p.ipDenied(ip) {
+			return "", &ValidationError{Code: CodeIPDenied, Message: fmt.Sprintf("ip %s is denied", ip)}
+		}
+	} else if p.ResolveHost {
+		resolver := p.Resolver
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return "", &ValidationError{Code: CodeDNSFailure, Message: err.Error()}
+		}
+		for _, addr := range addrs {
+			if p.ipDenied(addr.IP) {
+				return "", &ValidationError{Code: CodeIPDenied, Message: fmt.Sprintf("host %q resolves to denied ip %s", host, addr.IP)}
+			}
+		}
+	}
+
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port == "" {
+		u.Host = host
+	} else {
+		u.Host = net.JoinHostPort(host, port)
+	}
+	u.Fragment = ""
+
+	return u.String(), nil
+}
+
+func (p Policy) ipDenied(ip net.IP) bool {
+	for _, n := range p.DeniedCIDRs {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
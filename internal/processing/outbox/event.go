@@ -0,0 +1,68 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/events"
+)
+
+// Event is a claimed outbox row in the shape every Sink needs to publish
+// it: the click payload plus the trace context EnqueueClick captured at
+// insert time, so BuildCloudEvent can carry it to downstream consumers
+// without them needing transport-level headers.
+type Event struct {
+	ID          string
+	Slug        string
+	OccurredAt  time.Time
+	TraceParent string
+	TraceState  string
+	Baggage     string
+}
+
+// CloudEvent is a CloudEvents 1.0 JSON envelope. TraceParent and TraceState
+// are the CloudEvents distributed-tracing extension attributes; Baggage
+// rides alongside them the same way, so a consumer can resume the
+// originating trace without access to whatever transport (Kafka headers,
+// a NATS message, ...) carried this envelope.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	ID              string          `json:"id"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+	TraceParent     string          `json:"traceparent,omitempty"`
+	TraceState      string          `json:"tracestate,omitempty"`
+	Baggage         string          `json:"baggage,omitempty"`
+}
+
+const cloudEventType = "com.encurtador-url.click.recorded"
+
+// BuildCloudEvent wraps ev as a CloudEvent. source identifies the publishing
+// service (e.g. "encurtador-url-outbox-worker") and becomes the envelope's
+// source attribute.
+func BuildCloudEvent(ev Event, source string) (CloudEvent, error) {
+	data, err := json.Marshal(events.ClickRecorded{
+		EventID:    ev.ID,
+		Slug:       ev.Slug,
+		OccurredAt: ev.OccurredAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return CloudEvent{}, err
+	}
+
+	return CloudEvent{
+		SpecVersion:     "1.0",
+		Type:            cloudEventType,
+		Source:          source,
+		ID:              ev.ID,
+		Time:            ev.OccurredAt.UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            data,
+		TraceParent:     ev.TraceParent,
+		TraceState:      ev.TraceState,
+		Baggage:         ev.Baggage,
+	}, nil
+}
@@ -0,0 +1,18 @@
+package outbox
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DeadEventsTotal is shared across every outbox worker that gives up on an
+// event (links.ClickOutboxDrainer, mongo.OutboxDispatcher), labeled by why,
+// so operators can alert on poison messages regardless of which worker
+// produced them.
+var DeadEventsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "outbox_events_dead_total",
+		Help: "Total number of outbox events routed to the dead-letter store, by reason.",
+	},
+	[]string{"reason"},
+)
@@ -0,0 +1,32 @@
+package outbox
+
+import "context"
+
+// MultiSink fans an event out to every configured Sink concurrently and
+// only reports success once all of them have acked, so a caller that gates
+// MarkSent on Publish's error never marks an event sent until every
+// downstream has it. If more than one Sink fails, the first error observed
+// is returned; the rest are dropped since MarkRetry only needs one.
+type MultiSink struct {
+	sinks []Sink
+}
+
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	return &MultiSink{sinks: sinks}
+}
+
+func (m *MultiSink) Publish(ctx context.Context, ev Event) error {
+	results := make(chan error, len(m.sinks))
+	for _, s := range m.sinks {
+		s := s
+		go func() { results <- s.Publish(ctx, ev) }()
+	}
+
+	var firstErr error
+	for range m.sinks {
+		if err := <-results; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
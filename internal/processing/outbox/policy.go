@@ -0,0 +1,138 @@
+// Package outbox holds retry and dead-letter policy shared by the outbox
+// workers in internal/processing/links and internal/storage/mongo, so
+// retry cadence isn't redefined (and drift) in every place an outbox
+// worker lives.
+package outbox
+
+import (
+	"math/rand"
+	"time"
+)
+
+// BackoffPolicy decides, for an event about to be retried, how long to wait
+// before the next attempt, or whether it has exhausted its retries and
+// should be routed to the dead-letter store instead.
+type BackoffPolicy interface {
+	// Next returns the delay before attempts should be tried again, and
+	// whether attempts has exceeded the policy's retry budget (terminal).
+	// When terminal is true, delay is meaningless and the caller should
+	// give up instead of scheduling another retry.
+	Next(attempts int, lastErr error) (delay time.Duration, terminal bool)
+}
+
+// ExponentialJitterPolicy implements decorrelated jitter: each retry's
+// delay is drawn uniformly from [Base, prev*3), capped at Cap, where prev
+// is the delay the previous attempt would have produced. This spreads out
+// retries of a batch that fails together better than plain exponential
+// backoff with full jitter, at the cost of being less predictable.
+type ExponentialJitterPolicy struct {
+	Base        time.Duration
+	Cap         time.Duration
+	MaxAttempts int
+}
+
+// NewExponentialJitterPolicy builds a policy with base=250ms, cap=5m
+// defaults when base/capDelay are left zero.
+func NewExponentialJitterPolicy(base, capDelay time.Duration, maxAttempts int) *ExponentialJitterPolicy {
+	if base <= 0 {
+		base = 250 * time.Millisecond
+	}
+	if capDelay <= 0 {
+		capDelay = 5 * time.Minute
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	return &ExponentialJitterPolicy{Base: base, Cap: capDelay, MaxAttempts: maxAttempts}
+}
+
+func (p *ExponentialJitterPolicy) Next(attempts int, _ error) (time.Duration, bool) {
+	if attempts >= p.MaxAttempts {
+		return 0, true
+	}
+
+	prev := p.Base
+	for i := 1; i < attempts; i++ {
+		prev *= 3
+		if prev >= p.Cap {
+			prev = p.Cap
+			break
+		}
+	}
+
+	upper := prev * 3
+	if upper > p.Cap {
+		upper = p.Cap
+	}
+	if upper <= p.Base {
+		return p.Base, false
+	}
+
+	delay := p.Base + time.Duration(rand.Int63n(int64(upper-p.Base)+1))
+	if delay > p.Cap {
+		delay = p.Cap
+	}
+	return delay, false
+}
+
+// SimpleBackoff implements plain exponential backoff - delay doubles with
+// each attempt up to Cap - with up to +/-20% jitter layered on top so many
+// events failing in the same batch don't all retry at exactly the same
+// instant. It's a cheaper, more predictable alternative to
+// ExponentialJitterPolicy's decorrelated jitter for callers (the outbox
+// worker's own retry loop, a Kafka consumer's message-handler retries) that
+// just want "doubling, but not synchronized".
+type SimpleBackoff struct {
+	Base time.Duration
+	Cap  time.Duration
+	// MaxAttempts is the retry budget; 0 means unlimited, i.e. Next never
+	// reports terminal. That's what the outbox worker wants - it has no
+	// give-up-and-DLQ step of its own at this layer - while a caller that
+	// does want one (e.g. a consumer forwarding to a dead-letter topic) can
+	// set it.
+	MaxAttempts int
+}
+
+// NewSimpleBackoff builds a policy with base=250ms, cap=5m defaults when
+// base/capDelay are left zero. maxAttempts of 0 or less means unlimited
+// retries.
+func NewSimpleBackoff(base, capDelay time.Duration, maxAttempts int) *SimpleBackoff {
+	if base <= 0 {
+		base = 250 * time.Millisecond
+	}
+	if capDelay <= 0 {
+		capDelay = 5 * time.Minute
+	}
+	return &SimpleBackoff{Base: base, Cap: capDelay, MaxAttempts: maxAttempts}
+}
+
+func (p *SimpleBackoff) Next(attempts int, _ error) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && attempts >= p.MaxAttempts {
+		return 0, true
+	}
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := p.Base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= p.Cap {
+			delay = p.Cap
+			break
+		}
+	}
+	if delay > p.Cap {
+		delay = p.Cap
+	}
+
+	jitter := time.Duration((rand.Float64()*2 - 1) * float64(delay) * 0.2)
+	delay += jitter
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > p.Cap {
+		delay = p.Cap
+	}
+	return delay, false
+}
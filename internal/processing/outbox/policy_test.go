@@ -0,0 +1,103 @@
+package outbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExponentialJitterPolicy_Terminal(t *testing.T) {
+	p := NewExponentialJitterPolicy(250*time.Millisecond, 5*time.Minute, 3)
+
+	cases := []struct {
+		attempts int
+		terminal bool
+	}{
+		{1, false},
+		{2, false},
+		{3, true},
+		{4, true},
+	}
+
+	for _, tc := range cases {
+		_, terminal := p.Next(tc.attempts, errors.New("boom"))
+		if terminal != tc.terminal {
+			t.Errorf("attempts=%d: got terminal=%v, want %v", tc.attempts, terminal, tc.terminal)
+		}
+	}
+}
+
+func TestExponentialJitterPolicy_DelayBounds(t *testing.T) {
+	p := NewExponentialJitterPolicy(250*time.Millisecond, 5*time.Minute, 10)
+
+	for attempt := 1; attempt < 10; attempt++ {
+		delay, terminal := p.Next(attempt, nil)
+		if terminal {
+			t.Fatalf("attempt=%d: unexpectedly terminal", attempt)
+		}
+		if delay < p.Base || delay > p.Cap {
+			t.Errorf("attempt=%d: delay %s out of bounds [%s, %s]", attempt, delay, p.Base, p.Cap)
+		}
+	}
+}
+
+func TestExponentialJitterPolicy_Defaults(t *testing.T) {
+	p := NewExponentialJitterPolicy(0, 0, 0)
+	if p.Base != 250*time.Millisecond {
+		t.Errorf("got default Base %s, want 250ms", p.Base)
+	}
+	if p.Cap != 5*time.Minute {
+		t.Errorf("got default Cap %s, want 5m", p.Cap)
+	}
+	if p.MaxAttempts != 5 {
+		t.Errorf("got default MaxAttempts %d, want 5", p.MaxAttempts)
+	}
+}
+
+func TestSimpleBackoff_DelayBounds(t *testing.T) {
+	p := NewSimpleBackoff(time.Second, 30*time.Second, 0)
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay, terminal := p.Next(attempt, nil)
+		if terminal {
+			t.Fatalf("attempt=%d: unexpectedly terminal with MaxAttempts=0", attempt)
+		}
+		if delay < 0 || delay > p.Cap {
+			t.Errorf("attempt=%d: delay %s out of bounds [0, %s]", attempt, delay, p.Cap)
+		}
+	}
+}
+
+func TestSimpleBackoff_Terminal(t *testing.T) {
+	p := NewSimpleBackoff(time.Second, 30*time.Second, 3)
+
+	cases := []struct {
+		attempts int
+		terminal bool
+	}{
+		{1, false},
+		{2, false},
+		{3, true},
+		{4, true},
+	}
+
+	for _, tc := range cases {
+		_, terminal := p.Next(tc.attempts, errors.New("boom"))
+		if terminal != tc.terminal {
+			t.Errorf("attempts=%d: got terminal=%v, want %v", tc.attempts, terminal, tc.terminal)
+		}
+	}
+}
+
+func TestSimpleBackoff_Defaults(t *testing.T) {
+	p := NewSimpleBackoff(0, 0, 0)
+	if p.Base != 250*time.Millisecond {
+		t.Errorf("got default Base %s, want 250ms", p.Base)
+	}
+	if p.Cap != 5*time.Minute {
+		t.Errorf("got default Cap %s, want 5m", p.Cap)
+	}
+	if p.MaxAttempts != 0 {
+		t.Errorf("got default MaxAttempts %d, want 0 (unlimited)", p.MaxAttempts)
+	}
+}
@@ -0,0 +1,21 @@
+package outbox
+
+import "context"
+
+// Sink is a durable log a claimed outbox event can be published to, as an
+// alternative (or in addition) to a worker applying it straight to stats -
+// e.g. Kafka or NATS JetStream, for downstream consumers that want the raw
+// click stream rather than pre-aggregated counts.
+type Sink interface {
+	Publish(ctx context.Context, ev Event) error
+}
+
+// BatchSink is an optional capability a Sink can implement to publish a
+// whole claimed batch in one round trip instead of one call per event,
+// returning one error per event in the same order as evs. processBatch in
+// the outbox worker uses this opportunistically (via a type assertion)
+// when the configured sink supports it and the durability setting calls
+// for it, e.g. Kafka's acks=all.
+type BatchSink interface {
+	PublishBatch(ctx context.Context, evs []Event) []error
+}
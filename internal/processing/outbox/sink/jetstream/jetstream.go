@@ -0,0 +1,54 @@
+// Package jetstream publishes outbox events to a NATS JetStream stream.
+package jetstream
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/outbox"
+	"github.com/nats-io/nats.go"
+)
+
+// Sink publishes outbox events to JetStream as CloudEvents JSON via
+// PublishMsgAsync, using a Nats-Msg-Id header set to the outbox event's id
+// so the stream's own message deduplication window (configured on the
+// stream itself) gives at-least-once delivery the same idempotency the
+// kafka Sink gets from a stable partition key plus a deduping consumer.
+type Sink struct {
+	js      nats.JetStreamContext
+	subject string
+	source  string
+}
+
+func NewSink(js nats.JetStreamContext, subject, source string) *Sink {
+	return &Sink{js: js, subject: subject, source: source}
+}
+
+func (s *Sink) Publish(ctx context.Context, ev outbox.Event) error {
+	ce, err := outbox.BuildCloudEvent(ev, s.source)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(ce)
+	if err != nil {
+		return err
+	}
+
+	future, err := s.js.PublishMsgAsync(&nats.Msg{
+		Subject: s.subject,
+		Data:    payload,
+		Header:  nats.Header{"Nats-Msg-Id": []string{ev.ID}},
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-future.Ok():
+		return nil
+	case err := <-future.Err():
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
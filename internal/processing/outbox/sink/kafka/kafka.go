@@ -0,0 +1,169 @@
+// Package kafka publishes outbox events to Kafka.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/outbox"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Sink publishes outbox events to Kafka as CloudEvents JSON, keyed by slug
+// so all of a link's clicks land on the same partition and a downstream
+// consumer sees them in order.
+type Sink struct {
+	writer *kafka.Writer
+	source string
+}
+
+// Options tunes the underlying kafka.Writer beyond NewSink's defaults.
+// Compression and RequiredAcks matter most for a high-volume click stream:
+// compression cuts network/disk cost, and RequiredAcks trades latency for
+// durability.
+type Options struct {
+	Compression  kafka.Compression
+	RequiredAcks kafka.RequiredAcks
+	BatchBytes   int64
+	BatchTimeout time.Duration
+	MaxAttempts  int
+}
+
+// NewSink builds a Sink with RequiredAcks pinned to RequireAll (every ISR
+// replica), the closest kafka-go gets to the Java client's idempotent
+// producer guarantee - kafka-go has no enable.idempotence knob of its own,
+// so exactly-once delivery still depends on a downstream consumer deduping
+// by the CloudEvent id, which is the outbox row's own id and so is stable
+// across retries.
+func NewSink(brokers []string, topic, source string) *Sink {
+	return NewSinkWithOptions(brokers, topic, source, Options{RequiredAcks: kafka.RequireAll})
+}
+
+// NewSinkWithOptions is NewSink with Compression, RequiredAcks, BatchBytes,
+// BatchTimeout, and MaxAttempts under the caller's control, for deployments
+// that want to trade some durability for throughput (e.g. RequireOne
+// instead of RequireAll) or tune batching for a high-volume topic.
+func NewSinkWithOptions(brokers []string, topic, source string, opts Options) *Sink {
+	return &Sink{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           opts.RequiredAcks,
+			Compression:            opts.Compression,
+			BatchBytes:             opts.BatchBytes,
+			BatchTimeout:           opts.BatchTimeout,
+			MaxAttempts:            opts.MaxAttempts,
+			AllowAutoTopicCreation: true,
+		},
+		source: source,
+	}
+}
+
+func (s *Sink) Publish(ctx context.Context, ev outbox.Event) error {
+	msg, err := s.toMessage(ev)
+	if err != nil {
+		return err
+	}
+	return s.writer.WriteMessages(ctx, msg)
+}
+
+// PublishBatch writes every event in evs in a single WriteMessages call,
+// returning one error per event in the same order as evs. kafka-go reports
+// a partial failure as a kafka.WriteErrors, one entry per message (nil for
+// the ones that made it); errors.As unpacks that case so the caller can
+// retry only the events that actually failed instead of the whole batch.
+// A non-WriteErrors error means the call failed before per-message results
+// were known (e.g. a connection error), so it's reported against every
+// event.
+func (s *Sink) PublishBatch(ctx context.Context, evs []outbox.Event) []error {
+	errs := make([]error, len(evs))
+	msgs := make([]kafka.Message, len(evs))
+	for i, ev := range evs {
+		msg, err := s.toMessage(ev)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		msgs[i] = msg
+	}
+
+	err := s.writer.WriteMessages(ctx, msgs...)
+	if err == nil {
+		return errs
+	}
+
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		for i, werr := range writeErrs {
+			if werr != nil {
+				errs[i] = werr
+			}
+		}
+		return errs
+	}
+
+	for i := range errs {
+		if errs[i] == nil {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+func (s *Sink) toMessage(ev outbox.Event) (kafka.Message, error) {
+	ce, err := outbox.BuildCloudEvent(ev, s.source)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	payload, err := json.Marshal(ce)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	return kafka.Message{
+		Key:     []byte(ev.Slug),
+		Value:   payload,
+		Time:    ev.OccurredAt.UTC(),
+		Headers: carrierToKafkaHeaders(eventCarrier(ev)),
+	}, nil
+}
+
+// eventCarrier rebuilds the W3C trace carrier from an event's own
+// traceparent/tracestate/baggage fields - the same fields BuildCloudEvent
+// already writes into the CloudEvent body - so it can also be propagated
+// through Kafka headers for consumers that recover trace context that way
+// instead of parsing the envelope.
+func eventCarrier(ev outbox.Event) propagation.MapCarrier {
+	carrier := propagation.MapCarrier{}
+	if v := strings.TrimSpace(ev.TraceParent); v != "" {
+		carrier.Set("traceparent", v)
+	}
+	if v := strings.TrimSpace(ev.TraceState); v != "" {
+		carrier.Set("tracestate", v)
+	}
+	if v := strings.TrimSpace(ev.Baggage); v != "" {
+		carrier.Set("baggage", v)
+	}
+	return carrier
+}
+
+// carrierToKafkaHeaders turns a W3C trace carrier into Kafka message
+// headers, the inverse of internal/messaging/consumer's
+// kafkaHeadersToContext - so a consumer group on the other end can recover
+// the producer's trace context via otel.GetTextMapPropagator().Extract.
+func carrierToKafkaHeaders(carrier propagation.MapCarrier) []kafka.Header {
+	headers := make([]kafka.Header, 0, len(carrier))
+	for key, value := range carrier {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+	return headers
+}
+
+// Close releases the underlying Kafka writer's connections.
+func (s *Sink) Close() error {
+	return s.writer.Close()
+}
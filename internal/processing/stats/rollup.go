@@ -0,0 +1,38 @@
+// Package stats runs the background rollup that pre-aggregates daily click
+// counts so links.Service.GetStats can read closed days straight from a
+// rollup table instead of re-scanning StatsRepository on every call.
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+)
+
+// Source is the subset of links.StatsRepository the Scheduler reads counts
+// from when computing a rollup batch.
+type Source interface {
+	GetDaily(ctx context.Context, slug string, from, to time.Time) ([]links.DailyCount, error)
+}
+
+// SlugSource enumerates the slugs a rollup run should consider, e.g. ones
+// created or clicked since the given time. It's deliberately independent of
+// links.LinkRepository so backends can serve it from whatever index is
+// cheapest (Mongo: distinct slug over a recency filter; Postgres: a plain
+// range query) without widening the core repository contract.
+type SlugSource interface {
+	RecentSlugs(ctx context.Context, since time.Time) ([]string, error)
+}
+
+// RollupRepository persists pre-aggregated daily click counts and the
+// per-slug watermark an incremental Scheduler run advanced to. Its GetDaily
+// method has the same signature as links.StatsRepository.GetDaily so it
+// satisfies links.RollupReader directly: Service.GetStats can read from it
+// with no translation layer.
+type RollupRepository interface {
+	UpsertDaily(ctx context.Context, slug string, counts []links.DailyCount) error
+	GetDaily(ctx context.Context, slug string, from, to time.Time) ([]links.DailyCount, error)
+	Watermark(ctx context.Context, slug string) (time.Time, bool, error)
+	SetWatermark(ctx context.Context, slug string, at time.Time) error
+}
@@ -0,0 +1,191 @@
+package stats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+var (
+	rollupLastRunTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "click_rollup_last_run_timestamp_seconds",
+		Help: "Unix timestamp of the last completed rollup run, across all slugs",
+	})
+	rollupWatermarkLagSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "click_rollup_watermark_lag_seconds",
+		Help: "Seconds between now and the oldest per-slug watermark advanced in the last run",
+	})
+)
+
+// Lease is the leader-election primitive Scheduler runs under so that only
+// one replica performs a rollup at a time. A Postgres advisory lock or a
+// Mongo findAndModify lease doc both satisfy this; redisStorage.LeaderLease
+// (used by the outbox dispatcher) would too, but isn't assumed here since
+// a deployment may run the rollup against a backend with no Redis at all.
+type Lease interface {
+	TryAcquireOrRenew(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+// SchedulerOptions tunes the rollup cadence and how far back each run looks.
+type SchedulerOptions struct {
+	// Cadence is a standard 5-field cron expression. Defaults to hourly.
+	Cadence string
+	// Window bounds how far behind a slug's watermark a single run will
+	// catch up, so one run after a long outage doesn't try to rescan a
+	// link's entire history. Defaults to 30 days.
+	Window time.Duration
+}
+
+// Scheduler periodically rolls up closed days of click counts from Source
+// into RollupRepository, keyed by slug with a per-slug watermark so repeat
+// runs only recompute what's changed since the last one. It runs under
+// Lease so multiple replicas don't double-count.
+type Scheduler struct {
+	source Source
+	sink   RollupRepository
+	slugs  SlugSource
+	lease  Lease
+
+	cadence string
+	window  time.Duration
+	now     func() time.Time
+
+	mu      sync.RWMutex
+	lastRun time.Time
+	oldest  time.Time
+}
+
+func NewScheduler(source Source, sink RollupRepository, slugs SlugSource, lease Lease, opts SchedulerOptions) *Scheduler {
+	if opts.Cadence == "" {
+		opts.Cadence = "0 * * * *"
+	}
+	if opts.Window <= 0 {
+		opts.Window = 30 * 24 * time.Hour
+	}
+
+	return &Scheduler{
+		source:  source,
+		sink:    sink,
+		slugs:   slugs,
+		lease:   lease,
+		cadence: opts.Cadence,
+		window:  opts.Window,
+		now:     time.Now,
+	}
+}
+
+// Run blocks, driving rollup runs on the configured cron cadence until ctx
+// is canceled. Launch it as a goroutine from main.go.
+func (s *Scheduler) Run(ctx context.Context) error {
+	c := cron.New()
+	if _, err := c.AddFunc(s.cadence, func() { s.runOnce(ctx) }); err != nil {
+		return err
+	}
+
+	c.Start()
+	<-ctx.Done()
+	stopCtx := c.Stop()
+	<-stopCtx.Done()
+
+	releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.lease.Release(releaseCtx); err != nil {
+		logger.Warn("failed to release rollup leader lease", zap.Error(err))
+	}
+	return nil
+}
+
+func (s *Scheduler) runOnce(ctx context.Context) {
+	isLeader, err := s.lease.TryAcquireOrRenew(ctx)
+	if err != nil {
+		logger.Warn("rollup leader lease check failed", zap.Error(err))
+		return
+	}
+	if !isLeader {
+		return
+	}
+
+	now := s.now().UTC()
+	closedYesterday := dateOnly(now).AddDate(0, 0, -1)
+
+	slugs, err := s.slugs.RecentSlugs(ctx, now.Add(-s.window))
+	if err != nil {
+		logger.Error("failed to list slugs for rollup", zap.Error(err))
+		return
+	}
+
+	oldestWatermark := now
+	for _, slug := range slugs {
+		if err := s.rollupSlug(ctx, slug, closedYesterday, now); err != nil {
+			logger.Error("failed to roll up slug", zap.Error(err), zap.String("slug", slug))
+			continue
+		}
+
+		if wm, ok, err := s.sink.Watermark(ctx, slug); err == nil && ok && wm.Before(oldestWatermark) {
+			oldestWatermark = wm
+		}
+	}
+
+	s.mu.Lock()
+	s.lastRun = now
+	s.oldest = oldestWatermark
+	s.mu.Unlock()
+
+	rollupLastRunTimestamp.Set(float64(now.Unix()))
+	rollupWatermarkLagSeconds.Set(now.Sub(oldestWatermark).Seconds())
+}
+
+func (s *Scheduler) rollupSlug(ctx context.Context, slug string, closedYesterday, now time.Time) error {
+	from := now.Add(-s.window)
+	if wm, ok, err := s.sink.Watermark(ctx, slug); err == nil && ok && wm.After(from) {
+		from = wm
+	}
+	if from.After(closedYesterday) {
+		return nil
+	}
+
+	counts, err := s.source.GetDaily(ctx, slug, from, closedYesterday)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sink.UpsertDaily(ctx, slug, counts); err != nil {
+		return err
+	}
+	return s.sink.SetWatermark(ctx, slug, closedYesterday)
+}
+
+// SchedulerStatus is the snapshot Status reports for the /internal/rollup/status endpoint.
+type SchedulerStatus struct {
+	LastRun          time.Time `json:"lastRun"`
+	OldestWatermark  time.Time `json:"oldestWatermark"`
+	WatermarkLagSecs float64   `json:"watermarkLagSeconds"`
+}
+
+// Status returns the most recently observed run time and watermark lag.
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var lag float64
+	if !s.lastRun.IsZero() {
+		lag = s.lastRun.Sub(s.oldest).Seconds()
+	}
+	return SchedulerStatus{
+		LastRun:          s.lastRun,
+		OldestWatermark:  s.oldest,
+		WatermarkLagSecs: lag,
+	}
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/IgorGrieder/encurtador-url/internal/middleware"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type apiKeyDoc struct {
+	ID      string   `bson:"_id"`
+	KeyHash string   `bson:"keyHash"`
+	Scopes  []string `bson:"scopes,omitempty"`
+	Revoked bool     `bson:"revoked,omitempty"`
+}
+
+// APIKeyRepository resolves hashed API keys from the api_keys collection.
+type APIKeyRepository struct {
+	coll *mongo.Collection
+}
+
+func NewAPIKeyRepository(m *db.Mongo) (*APIKeyRepository, error) {
+	repo := &APIKeyRepository{coll: m.Collection("api_keys")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "keyHash", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_key_hash"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*middleware.APIKey, error) {
+	var doc apiKeyDoc
+	err := r.coll.FindOne(ctx, bson.M{"keyHash": hash}).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, middleware.ErrKeyNotFound
+		}
+		return nil, err
+	}
+	if doc.Revoked {
+		return nil, middleware.ErrKeyNotFound
+	}
+
+	return &middleware.APIKey{
+		ID:      doc.ID,
+		KeyHash: doc.KeyHash,
+		Scopes:  doc.Scopes,
+		Revoked: doc.Revoked,
+	}, nil
+}
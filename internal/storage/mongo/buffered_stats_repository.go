@@ -2,14 +2,38 @@ package mongo
 
 import (
 	"context"
+	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/telemetry"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/tracing"
 	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "mongo.buffered_click_stats"
+
+// OverflowPolicy decides what IncDaily does when the queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowDropNewest discards the incoming event (the original, and
+	// still default, behavior).
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowDropOldest evicts the queue's oldest pending event to make
+	// room for the incoming one, trading older counts for fresher ones.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowBlock waits up to BufferedClickStatsOptions.BlockTimeout for
+	// room to free up, honoring ctx and the repository's own shutdown.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowSample admits events probabilistically once occupancy rises
+	// above SampleLowWatermark, so the queue degrades gracefully under
+	// sustained pressure instead of either dropping everything past the
+	// threshold or blocking the caller.
+	OverflowSample OverflowPolicy = "sample"
 )
 
 type BufferedClickStatsOptions struct {
@@ -17,15 +41,31 @@ type BufferedClickStatsOptions struct {
 	FlushInterval  time.Duration
 	MaxBatchEvents int
 	FlushTimeout   time.Duration
+
+	// OverflowPolicy selects how IncDaily behaves when the queue is full.
+	// Defaults to OverflowDropNewest.
+	OverflowPolicy OverflowPolicy
+	// BlockTimeout bounds how long OverflowBlock waits for queue room.
+	// Defaults to 50ms.
+	BlockTimeout time.Duration
+	// SampleLowWatermark is the queue occupancy below which OverflowSample
+	// admits every event; admission probability scales down linearly from
+	// there to the queue's capacity. Defaults to half of QueueSize.
+	SampleLowWatermark int
 }
 
 type BufferedClickStatsRepository struct {
 	base         *ClickStatsRepository
+	sink         links.ClickSink
 	queue        chan clickEvent
 	flushEvery   time.Duration
 	maxBatch     int
 	flushTimeout time.Duration
 
+	overflowPolicy     OverflowPolicy
+	blockTimeout       time.Duration
+	sampleLowWatermark int
+
 	stopOnce sync.Once
 	stopCh   chan struct{}
 	doneCh   chan struct{}
@@ -33,6 +73,21 @@ type BufferedClickStatsRepository struct {
 	dropped atomic.Int64
 }
 
+// samplePool lends each caller of sampleAdmit its own *rand.Rand, so
+// OverflowSample's coin flip scales with concurrent IncDaily callers instead
+// of serializing on one shared, mutex-guarded source.
+var samplePool = sync.Pool{
+	New: func() any {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	},
+}
+
+func sampleAdmit(p float64) bool {
+	rng := samplePool.Get().(*rand.Rand)
+	defer samplePool.Put(rng)
+	return rng.Float64() < p
+}
+
 type clickEvent struct {
 	slug string
 	day  int32 // YYYYMMDD (UTC)
@@ -43,12 +98,22 @@ type daySlugKey struct {
 	day  int32
 }
 
-func NewBufferedClickStatsRepository(base *ClickStatsRepository, opts BufferedClickStatsOptions) *BufferedClickStatsRepository {
+// NewBufferedClickStatsRepository builds a repository that reads through
+// base and flushes each batch through sink. A nil sink defaults to
+// NewMongoSink(base), preserving this type's original straight-to-Mongo
+// behavior; pass a Kafka or NATS clicksink (see
+// internal/processing/links/clicksink) to hand aggregation off to a
+// separate consumer instead. GetDaily always reads through base regardless
+// of which sink is writing - a non-Mongo sink's consumer owns applying
+// counts to whatever store it reads from.
+func NewBufferedClickStatsRepository(base *ClickStatsRepository, sink links.ClickSink, opts BufferedClickStatsOptions) *BufferedClickStatsRepository {
 	const (
 		defaultQueueSize      = 100_000
 		defaultFlushInterval  = 250 * time.Millisecond
 		defaultMaxBatchEvents = 50_000
 		defaultFlushTimeout   = 2 * time.Second
+		defaultOverflowPolicy = OverflowDropNewest
+		defaultBlockTimeout   = 50 * time.Millisecond
 	)
 
 	if opts.QueueSize <= 0 {
@@ -63,15 +128,31 @@ func NewBufferedClickStatsRepository(base *ClickStatsRepository, opts BufferedCl
 	if opts.FlushTimeout <= 0 {
 		opts.FlushTimeout = defaultFlushTimeout
 	}
+	if opts.OverflowPolicy == "" {
+		opts.OverflowPolicy = defaultOverflowPolicy
+	}
+	if opts.BlockTimeout <= 0 {
+		opts.BlockTimeout = defaultBlockTimeout
+	}
+	if opts.SampleLowWatermark <= 0 {
+		opts.SampleLowWatermark = opts.QueueSize / 2
+	}
+	if sink == nil {
+		sink = NewMongoSink(base)
+	}
 
 	r := &BufferedClickStatsRepository{
-		base:         base,
-		queue:        make(chan clickEvent, opts.QueueSize),
-		flushEvery:   opts.FlushInterval,
-		maxBatch:     opts.MaxBatchEvents,
-		flushTimeout: opts.FlushTimeout,
-		stopCh:       make(chan struct{}),
-		doneCh:       make(chan struct{}),
+		base:               base,
+		sink:               sink,
+		queue:              make(chan clickEvent, opts.QueueSize),
+		flushEvery:         opts.FlushInterval,
+		maxBatch:           opts.MaxBatchEvents,
+		flushTimeout:       opts.FlushTimeout,
+		overflowPolicy:     opts.OverflowPolicy,
+		blockTimeout:       opts.BlockTimeout,
+		sampleLowWatermark: opts.SampleLowWatermark,
+		stopCh:             make(chan struct{}),
+		doneCh:             make(chan struct{}),
 	}
 
 	go r.loop()
@@ -83,20 +164,109 @@ func (r *BufferedClickStatsRepository) IncDaily(ctx context.Context, slug string
 		return nil
 	}
 
+	day := dayKey(at)
+	trace.SpanFromContext(ctx).SetAttributes(tracing.SlugAttr(slug), tracing.DayKeyAttr(day))
+
 	ev := clickEvent{
 		slug: slug,
-		day:  dayKey(at),
+		day:  day,
 	}
 
 	select {
 	case r.queue <- ev:
+		telemetry.ClicksEnqueuedTotal.Inc()
 		return nil
 	default:
+		return r.handleOverflow(ctx, ev)
+	}
+}
+
+// handleOverflow is reached once IncDaily's first, non-blocking send finds
+// the queue full; it applies r.overflowPolicy to decide the event's fate.
+func (r *BufferedClickStatsRepository) handleOverflow(ctx context.Context, ev clickEvent) error {
+	switch r.overflowPolicy {
+	case OverflowDropOldest:
+		select {
+		case <-r.queue:
+			r.dropped.Add(1)
+			telemetry.ClicksOverflowTotal.WithLabelValues("drop_oldest").Inc()
+		default:
+		}
+		select {
+		case r.queue <- ev:
+			telemetry.ClicksEnqueuedTotal.Inc()
+		default:
+			// Another goroutine refilled the slot we just freed; drop the
+			// newest rather than looping, to bound IncDaily's latency.
+			r.dropped.Add(1)
+			telemetry.ClicksOverflowTotal.WithLabelValues("drop_newest").Inc()
+		}
+		return nil
+
+	case OverflowBlock:
+		timer := time.NewTimer(r.blockTimeout)
+		defer timer.Stop()
+
+		select {
+		case r.queue <- ev:
+			telemetry.ClicksEnqueuedTotal.Inc()
+		case <-timer.C:
+			r.dropped.Add(1)
+			telemetry.ClicksOverflowTotal.WithLabelValues("blocked_timeout").Inc()
+		case <-ctx.Done():
+			r.dropped.Add(1)
+			telemetry.ClicksOverflowTotal.WithLabelValues("blocked_timeout").Inc()
+		case <-r.stopCh:
+			r.dropped.Add(1)
+			telemetry.ClicksOverflowTotal.WithLabelValues("blocked_timeout").Inc()
+		}
+		return nil
+
+	case OverflowSample:
+		if sampleAdmit(r.sampleAdmitProbability()) {
+			select {
+			case r.queue <- ev:
+				telemetry.ClicksEnqueuedTotal.Inc()
+				return nil
+			default:
+			}
+		}
 		r.dropped.Add(1)
+		telemetry.ClicksOverflowTotal.WithLabelValues("sampled_drop").Inc()
+		return nil
+
+	default: // OverflowDropNewest
+		r.dropped.Add(1)
+		telemetry.ClicksOverflowTotal.WithLabelValues("drop_newest").Inc()
 		return nil
 	}
 }
 
+// sampleAdmitProbability implements OverflowSample's admission curve: 1
+// while occupancy sits at or below sampleLowWatermark, scaling linearly down
+// to 0 as occupancy approaches the queue's capacity.
+func (r *BufferedClickStatsRepository) sampleAdmitProbability() float64 {
+	occupancy := len(r.queue)
+	low := r.sampleLowWatermark
+	capacity := cap(r.queue)
+
+	if occupancy <= low {
+		return 1
+	}
+	if capacity <= low {
+		return 0
+	}
+
+	p := 1 - float64(occupancy-low)/float64(capacity-low)
+	if p < 0 {
+		return 0
+	}
+	if p > 1 {
+		return 1
+	}
+	return p
+}
+
 func (r *BufferedClickStatsRepository) GetDaily(ctx context.Context, slug string, from, to time.Time) ([]links.DailyCount, error) {
 	return r.base.GetDaily(ctx, slug, from, to)
 }
@@ -105,15 +275,20 @@ func (r *BufferedClickStatsRepository) Dropped() int64 {
 	return r.dropped.Load()
 }
 
+// Shutdown stops the flush loop (which drains the queue and flushes
+// whatever's left, per loop's stopCh case), then closes the sink - both
+// steps bounded by ctx, so a hung flush or a slow sink Close can't block
+// shutdown forever.
 func (r *BufferedClickStatsRepository) Shutdown(ctx context.Context) error {
 	r.stopOnce.Do(func() { close(r.stopCh) })
 
 	select {
 	case <-r.doneCh:
-		return nil
 	case <-ctx.Done():
 		return ctx.Err()
 	}
+
+	return r.sink.Close(ctx)
 }
 
 func (r *BufferedClickStatsRepository) loop() {
@@ -173,31 +348,50 @@ func (r *BufferedClickStatsRepository) loop() {
 	}
 }
 
+// flush compacts pending into one links.ClickCount per (slug, day) and
+// hands the batch to r.sink, wrapped in a span (batch_size/dropped
+// attributes, matching the ones the outbox worker already tags its own
+// batches with) and the flush_duration_seconds/clicks_flushed_total
+// metrics, so a flush that's slow or silently failing shows up next to the
+// queue-pressure counters IncDaily feeds.
 func (r *BufferedClickStatsRepository) flush(ctx context.Context, pending map[daySlugKey]int64) error {
-	models := make([]mongo.WriteModel, 0, len(pending))
-
-	for key, inc := range pending {
-		date := dateStringFromDayKey(key.day)
-
-		models = append(models, mongo.NewUpdateOneModel().
-			SetFilter(bson.M{"slug": key.slug, "date": date}).
-			SetUpdate(bson.M{
-				"$inc": bson.M{"count": inc},
-				"$setOnInsert": bson.M{
-					"slug": key.slug,
-					"date": date,
-				},
-			}).
-			SetUpsert(true),
-		)
+	start := time.Now()
+	counts := toClickCounts(pending)
+	batchSize := len(counts)
+
+	err := tracing.StartSpan(ctx, tracerName, "Flush", []attribute.KeyValue{
+		tracing.BatchSizeAttr(batchSize),
+		tracing.DroppedAttr(r.dropped.Load()),
+	}, func(ctx context.Context) error {
+		return r.sink.Flush(ctx, counts)
+	})
+
+	telemetry.FlushDurationSeconds.Observe(time.Since(start).Seconds())
+	if err == nil {
+		telemetry.ClicksFlushedTotal.Add(float64(batchSize))
 	}
+	return err
+}
 
-	if len(models) == 0 {
+// toClickCounts compacts pending - keyed by (slug, day), one entry per
+// click seen since the last flush - into one links.ClickCount per key, all
+// stamped with the same flush time.
+func toClickCounts(pending map[daySlugKey]int64) []links.ClickCount {
+	if len(pending) == 0 {
 		return nil
 	}
 
-	_, err := r.base.coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
-	return err
+	flushedAt := time.Now().UTC()
+	counts := make([]links.ClickCount, 0, len(pending))
+	for key, count := range pending {
+		counts = append(counts, links.ClickCount{
+			Slug:      key.slug,
+			Date:      dateStringFromDayKey(key.day),
+			Count:     count,
+			FlushedAt: flushedAt,
+		})
+	}
+	return counts
 }
 
 func dayKey(t time.Time) int32 {
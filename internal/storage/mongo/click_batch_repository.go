@@ -0,0 +1,111 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ClickGroup is one (slug, date) bucket's worth of clicks, already summed by
+// the caller (see cmd/click_consumer's batcher), ready to apply as a single
+// $inc.
+type ClickGroup struct {
+	Slug  string
+	Date  string // YYYY-MM-DD (UTC), matches clickDailyDoc.Date
+	Count int64
+}
+
+// ClickBatchRepository applies a batch of ClickGroups to the links and
+// clicks_daily collections with one unordered BulkWrite per collection,
+// instead of the one-round-trip-per-click calls LinksRepository.
+// FindActiveBySlugAndIncClick and ClickStatsRepository.IncDaily make. Like
+// ClickDrainSink.ApplySlugBatch, it doesn't re-check link expiry before
+// incrementing clicks - an event for a slug that's since expired or been
+// deleted just matches zero documents instead of being filtered out, the
+// same tradeoff the outbox drain path already makes for batched writes.
+type ClickBatchRepository struct {
+	links *mongo.Collection
+	stats *mongo.Collection
+}
+
+func NewClickBatchRepository(m *db.Mongo) *ClickBatchRepository {
+	return &ClickBatchRepository{
+		links: m.Collection("links"),
+		stats: m.Collection("clicks_daily"),
+	}
+}
+
+// BulkIncrement applies groups to both collections and returns the subset
+// that failed to write to either one, so the caller can re-enqueue just
+// those groups instead of the whole batch. A nil/empty return with a nil
+// error means every group was applied.
+func (r *ClickBatchRepository) BulkIncrement(ctx context.Context, groups []ClickGroup) ([]ClickGroup, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	linkModels := make([]mongo.WriteModel, len(groups))
+	statsModels := make([]mongo.WriteModel, len(groups))
+	for i, g := range groups {
+		linkModels[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"slug": g.Slug}).
+			SetUpdate(bson.M{"$inc": bson.M{"clicks": g.Count}})
+
+		statsModels[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"slug": g.Slug, "date": g.Date}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{"count": g.Count},
+				"$setOnInsert": bson.M{
+					"slug": g.Slug,
+					"date": g.Date,
+				},
+			}).
+			SetUpsert(true)
+	}
+
+	opts := options.BulkWrite().SetOrdered(false)
+	failedIdx := map[int]bool{}
+
+	if _, err := r.links.BulkWrite(ctx, linkModels, opts); err != nil {
+		if !markBulkWriteFailures(err, failedIdx) {
+			return nil, err
+		}
+	}
+	if _, err := r.stats.BulkWrite(ctx, statsModels, opts); err != nil {
+		if !markBulkWriteFailures(err, failedIdx) {
+			return nil, err
+		}
+	}
+
+	if len(failedIdx) == 0 {
+		return nil, nil
+	}
+
+	failed := make([]ClickGroup, 0, len(failedIdx))
+	for i := range groups {
+		if failedIdx[i] {
+			failed = append(failed, groups[i])
+		}
+	}
+	return failed, nil
+}
+
+// markBulkWriteFailures records the indexes BulkWrite rejected into failedIdx
+// and reports whether err was a recognized partial-failure
+// (mongo.BulkWriteException); a false return means err was something else
+// entirely (e.g. a connection failure) and the caller should treat the whole
+// batch as failed rather than silently dropping it.
+func markBulkWriteFailures(err error, failedIdx map[int]bool) bool {
+	var bwErr mongo.BulkWriteException
+	if !errors.As(err, &bwErr) {
+		return false
+	}
+	for _, we := range bwErr.WriteErrors {
+		failedIdx[we.Index] = true
+	}
+	return true
+}
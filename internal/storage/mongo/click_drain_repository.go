@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ClickDrainRepository adapts ClickOutboxRepository's ObjectID-keyed claim
+// methods to links.DrainRepository's string IDs, the same translation
+// postgres.ClickDrainRepository does for its own native ID type.
+type ClickDrainRepository struct {
+	repo *ClickOutboxRepository
+}
+
+func NewClickDrainRepository(repo *ClickOutboxRepository) *ClickDrainRepository {
+	return &ClickDrainRepository{repo: repo}
+}
+
+func (a *ClickDrainRepository) ClaimPending(ctx context.Context, now time.Time, limit int, workerID string, lease time.Duration) ([]links.OutboxEvent, error) {
+	claimed, err := a.repo.ClaimPending(ctx, now, int64(limit), workerID, lease)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]links.OutboxEvent, len(claimed))
+	for i, ev := range claimed {
+		events[i] = links.OutboxEvent{
+			ID:         ev.ID.Hex(),
+			Slug:       ev.Slug,
+			OccurredAt: ev.OccurredAt,
+			Attempts:   ev.Attempts,
+		}
+	}
+	return events, nil
+}
+
+func (a *ClickDrainRepository) MarkDrained(ctx context.Context, id, workerID string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return a.repo.MarkSentOwned(ctx, oid, workerID)
+}
+
+func (a *ClickDrainRepository) MarkRetry(ctx context.Context, id, workerID, lastError string, nextAttemptAt time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return a.repo.MarkRetryOwned(ctx, oid, workerID, lastError, nextAttemptAt)
+}
+
+func (a *ClickDrainRepository) MoveToDLQ(ctx context.Context, id, workerID, lastError string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return a.repo.MoveToDLQOwned(ctx, oid, workerID, lastError)
+}
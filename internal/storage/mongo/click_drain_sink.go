@@ -0,0 +1,75 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ClickDrainSink implements links.DrainSink: it commits one slug-day
+// group's worth of drained outbox events as a single multi-document
+// transaction incrementing both the link's clicks counter and its
+// click_daily_rollup bucket, using client.StartSession/WithTransaction.
+//
+// This is the first place in this tree that uses a Mongo multi-document
+// transaction rather than a single-document update - everything else
+// (LinksRepository.FindActiveBySlugAndIncClick, RollupRepository.UpsertDaily)
+// gets away with one collection at a time. Transactions require a replica
+// set deployment (a standalone mongod rejects them); the docker-compose
+// profile this repo targets already runs Mongo as a single-node replica
+// set for change-stream support elsewhere, so this doesn't add a new
+// deployment requirement.
+type ClickDrainSink struct {
+	client *mongo.Client
+	links  *mongo.Collection
+	rollup *mongo.Collection
+}
+
+func NewClickDrainSink(m *db.Mongo) *ClickDrainSink {
+	return &ClickDrainSink{
+		client: m.Client,
+		links:  m.Collection("links"),
+		rollup: m.Collection("click_daily_rollup"),
+	}
+}
+
+func (s *ClickDrainSink) ApplySlugBatch(ctx context.Context, slug string, clicks int, day time.Time) error {
+	if clicks <= 0 {
+		return nil
+	}
+
+	session, err := s.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if _, err := s.links.UpdateOne(sessCtx,
+			bson.M{"slug": slug},
+			bson.M{"$inc": bson.M{"clicks": int64(clicks)}},
+		); err != nil {
+			return nil, err
+		}
+
+		now := time.Now().UTC()
+		dayKey := day.UTC().Format(time.DateOnly)
+		if _, err := s.rollup.UpdateOne(sessCtx,
+			bson.M{"slug": slug, "day": dayKey},
+			bson.M{
+				"$inc": bson.M{"count": int64(clicks)},
+				"$set": bson.M{"updatedAt": now},
+			},
+			options.Update().SetUpsert(true),
+		); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	})
+	return err
+}
@@ -0,0 +1,176 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrOutboxEventNotOwned mirrors postgres.ErrOutboxEventNotOwned: it means
+// the event's claim (owner + ownerExpiresAt) was already superseded by
+// another worker by the time this one tried to resolve it.
+var ErrOutboxEventNotOwned = errors.New("outbox event not owned by worker")
+
+// ClaimPending claims up to limit pending events for workerID, the Mongo
+// equivalent of Postgres's SELECT ... FOR UPDATE SKIP LOCKED LIMIT N: since
+// a single findAndModify can only claim one document at a time, it loops,
+// each iteration atomically claiming the next unclaimed-or-expired-claim
+// event by setting owner/ownerExpiresAt.
+func (r *ClickOutboxRepository) ClaimPending(ctx context.Context, now time.Time, limit int64, workerID string, lease time.Duration) ([]OutboxClickEvent, error) {
+	if limit <= 0 {
+		limit = 1
+	}
+	if lease <= 0 {
+		lease = 30 * time.Second
+	}
+	now = now.UTC()
+
+	events := make([]OutboxClickEvent, 0, limit)
+	for int64(len(events)) < limit {
+		filter := bson.M{
+			"status":        outboxStatusPending,
+			"nextAttemptAt": bson.M{"$lte": now},
+			"$or": bson.A{
+				bson.M{"owner": bson.M{"$exists": false}},
+				bson.M{"owner": ""},
+				bson.M{"ownerExpiresAt": bson.M{"$lte": now}},
+			},
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"owner":          workerID,
+				"ownerExpiresAt": now.Add(lease),
+				"updatedAt":      now,
+			},
+		}
+
+		var doc outboxDoc
+		err := r.coll.FindOneAndUpdate(
+			ctx, filter, update,
+			options.FindOneAndUpdate().
+				SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+				SetReturnDocument(options.After),
+		).Decode(&doc)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, OutboxClickEvent{
+			ID:          doc.ID,
+			Slug:        doc.Slug,
+			OccurredAt:  doc.OccurredAt,
+			TraceParent: doc.TraceParent,
+			TraceState:  doc.TraceState,
+			Baggage:     doc.Baggage,
+			Attempts:    doc.Attempts,
+		})
+	}
+
+	return events, nil
+}
+
+// ReleaseExpired resets the claim on any event whose lease has expired back
+// to unclaimed (owner cleared) without touching attempts, so a worker that
+// died mid-processing doesn't strand its claims until ClaimPending's own
+// $or happens to sweep past them. It's a belt-and-suspenders pass an
+// operator or scheduler can run on a timer; ClaimPending already reclaims
+// an expired lease opportunistically on its next poll, so nothing depends
+// on this being called for correctness, only for bounding how long a dead
+// worker's claim lingers in the collection.
+func (r *ClickOutboxRepository) ReleaseExpired(ctx context.Context, now time.Time) (int64, error) {
+	now = now.UTC()
+	res, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{
+			"status":         outboxStatusPending,
+			"owner":          bson.M{"$ne": ""},
+			"ownerExpiresAt": bson.M{"$lte": now},
+		},
+		bson.M{"$set": bson.M{
+			"owner":          "",
+			"ownerExpiresAt": time.Time{},
+			"updatedAt":      now,
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// MarkSentOwned is MarkSent's claim-aware counterpart: it only applies if
+// workerID still holds the claim, returning ErrOutboxEventNotOwned
+// otherwise so the caller knows another worker already resolved it.
+func (r *ClickOutboxRepository) MarkSentOwned(ctx context.Context, id primitive.ObjectID, workerID string) error {
+	now := time.Now().UTC()
+	res, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "owner": workerID},
+		bson.M{"$set": bson.M{
+			"status":      outboxStatusSent,
+			"updatedAt":   now,
+			"sentAt":      now,
+			"processedAt": nextProcessedAt(),
+			"lastError":   "",
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrOutboxEventNotOwned
+	}
+	return nil
+}
+
+// MarkRetryOwned is MarkRetry's claim-aware counterpart.
+func (r *ClickOutboxRepository) MarkRetryOwned(ctx context.Context, id primitive.ObjectID, workerID string, lastError string, nextAttemptAt time.Time) error {
+	res, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "owner": workerID},
+		bson.M{
+			"$set": bson.M{
+				"status":        outboxStatusPending,
+				"lastError":     lastError,
+				"nextAttemptAt": nextAttemptAt.UTC(),
+				"updatedAt":     time.Now().UTC(),
+			},
+			"$inc": bson.M{"attempts": 1},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrOutboxEventNotOwned
+	}
+	return nil
+}
+
+// MoveToDLQOwned is MoveToDLQ's claim-aware counterpart.
+func (r *ClickOutboxRepository) MoveToDLQOwned(ctx context.Context, id primitive.ObjectID, workerID string, lastError string) error {
+	var doc outboxDoc
+	err := r.coll.FindOneAndDelete(ctx, bson.M{"_id": id, "owner": workerID}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrOutboxEventNotOwned
+		}
+		return err
+	}
+
+	dlqDoc := outboxDLQDoc{
+		outboxDoc: doc,
+		DLQReason: lastError,
+		DLQAt:     time.Now().UTC(),
+	}
+	_, err = r.dlqColl.InsertOne(ctx, dlqDoc)
+	return err
+}
@@ -0,0 +1,52 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSink is the default links.ClickSink: each flushed count is applied
+// straight to the clicks_daily collection via the same bulk $inc/upsert
+// BufferedClickStatsRepository always used before ClickSink existed.
+type MongoSink struct {
+	base *ClickStatsRepository
+}
+
+func NewMongoSink(base *ClickStatsRepository) *MongoSink {
+	return &MongoSink{base: base}
+}
+
+func (s *MongoSink) Flush(ctx context.Context, counts []links.ClickCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(counts))
+	for _, c := range counts {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"slug": c.Slug, "date": c.Date}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{"count": c.Count},
+				"$setOnInsert": bson.M{
+					"slug": c.Slug,
+					"date": c.Date,
+				},
+			}).
+			SetUpsert(true),
+		)
+	}
+
+	_, err := s.base.coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Close is a no-op: MongoSink writes through the shared *ClickStatsRepository
+// connection, which outlives the sink and is closed by whoever owns the
+// underlying Mongo connection (see db.Mongo.Disconnect).
+func (s *MongoSink) Close(context.Context) error {
+	return nil
+}
@@ -0,0 +1,119 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type idempotencyDoc struct {
+	Key         string    `bson:"_id"`
+	APIKey      string    `bson:"apiKey"`
+	RequestHash string    `bson:"requestHash"`
+	Status      string    `bson:"status"`
+	Slug        string    `bson:"slug"`
+	CreatedAt   time.Time `bson:"createdAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
+
+// IdempotencyRepository backs links.IdempotencyRepository against the
+// idempotency_keys collection. A TTL index on expiresAt reclaims abandoned
+// keys without an explicit sweep, the same role Postgres's expires_at
+// column plays in the reclaim condition on Begin.
+type IdempotencyRepository struct {
+	coll *mongo.Collection
+	ttl  time.Duration
+}
+
+func NewIdempotencyRepository(m *db.Mongo, ttl time.Duration) (*IdempotencyRepository, error) {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	repo := &IdempotencyRepository{coll: m.Collection("idempotency_keys"), ttl: ttl}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (r *IdempotencyRepository) Begin(ctx context.Context, key, apiKey, requestHash string) (*links.IdempotencyRecord, bool, error) {
+	now := time.Now().UTC()
+
+	filter := bson.M{
+		"_id": key,
+		"$or": bson.A{
+			bson.M{"status": string(links.IdempotencyFailed)},
+			bson.M{"expiresAt": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"apiKey":      apiKey,
+			"requestHash": requestHash,
+			"status":      string(links.IdempotencyPending),
+			"slug":        "",
+			"createdAt":   now,
+			"expiresAt":   now.Add(r.ttl),
+		},
+	}
+
+	var doc idempotencyDoc
+	err := r.coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After),
+	).Decode(&doc)
+
+	if err == nil {
+		return mapIdempotencyDoc(doc), true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, err
+	}
+
+	// The key already exists and wasn't reclaimable: report it as-is.
+	if err := r.coll.FindOne(ctx, bson.M{"_id": key}).Decode(&doc); err != nil {
+		return nil, false, err
+	}
+	return mapIdempotencyDoc(doc), false, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key, slug string) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": key},
+		bson.M{"$set": bson.M{"status": string(links.IdempotencyCompleted), "slug": slug}},
+	)
+	return err
+}
+
+func (r *IdempotencyRepository) Fail(ctx context.Context, key string) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": key},
+		bson.M{"$set": bson.M{"status": string(links.IdempotencyFailed)}},
+	)
+	return err
+}
+
+func mapIdempotencyDoc(doc idempotencyDoc) *links.IdempotencyRecord {
+	return &links.IdempotencyRecord{
+		Key:         doc.Key,
+		APIKey:      doc.APIKey,
+		RequestHash: doc.RequestHash,
+		Status:      links.IdempotencyStatus(doc.Status),
+		Slug:        doc.Slug,
+		CreatedAt:   doc.CreatedAt,
+	}
+}
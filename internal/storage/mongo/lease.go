@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Lease is a Mongo-backed mutual-exclusion lease built on findAndModify: the
+// first replica to upsert an unexpired lease document with its own owner ID
+// becomes leader until it stops renewing. It's the Mongo-backend equivalent
+// of postgres.AdvisoryLease and redisStorage.LeaderLease, used by rollup
+// schedulers and dispatchers that need leader election without Redis.
+type Lease struct {
+	coll  *mongo.Collection
+	key   string
+	owner string
+	ttl   time.Duration
+
+	isLeader bool
+}
+
+func NewLease(m *db.Mongo, key string, ttl time.Duration) *Lease {
+	if ttl <= 0 {
+		ttl = 10 * time.Second
+	}
+	return &Lease{
+		coll:  m.Collection("leases"),
+		key:   key,
+		owner: randomOwnerID(),
+		ttl:   ttl,
+	}
+}
+
+// TryAcquireOrRenew attempts to become (or remain) leader. It matches on
+// documents that either have no current owner (expired or never created)
+// or are already owned by this instance, so a held lease renews and an
+// expired one is taken over, but a live lease held by another replica
+// isn't disturbed.
+func (l *Lease) TryAcquireOrRenew(ctx context.Context) (bool, error) {
+	now := time.Now().UTC()
+
+	filter := bson.M{
+		"_id": l.key,
+		"$or": bson.A{
+			bson.M{"owner": l.owner},
+			bson.M{"expiresAt": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"owner": l.owner, "expiresAt": now.Add(l.ttl)},
+	}
+
+	err := l.coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After),
+	).Err()
+
+	if err == mongo.ErrNoDocuments {
+		l.isLeader = false
+		return false, nil
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		l.isLeader = false
+		return false, nil
+	}
+	if err != nil {
+		l.isLeader = false
+		return false, err
+	}
+
+	l.isLeader = true
+	return true, nil
+}
+
+// Release gives up leadership immediately, if still held, so another
+// replica can take over without waiting out the full TTL.
+func (l *Lease) Release(ctx context.Context) error {
+	if !l.isLeader {
+		return nil
+	}
+	l.isLeader = false
+
+	_, err := l.coll.DeleteOne(ctx, bson.M{"_id": l.key, "owner": l.owner})
+	return err
+}
+
+func randomOwnerID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return hex.EncodeToString([]byte(time.Now().String()))
+	}
+	return hex.EncodeToString(buf)
+}
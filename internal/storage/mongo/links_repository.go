@@ -18,14 +18,17 @@ type LinksRepository struct {
 }
 
 type linkDoc struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	Slug      string             `bson:"slug"`
-	URL       string             `bson:"url"`
-	Notes     string             `bson:"notes,omitempty"`
-	APIKey    string             `bson:"apiKey,omitempty"`
-	CreatedAt time.Time          `bson:"createdAt"`
-	ExpiresAt *time.Time         `bson:"expiresAt,omitempty"`
-	Clicks    int64              `bson:"clicks,omitempty"`
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	Slug            string             `bson:"slug"`
+	URL             string             `bson:"url"`
+	Notes           string             `bson:"notes,omitempty"`
+	APIKey          string             `bson:"apiKey,omitempty"`
+	CreatedAt       time.Time          `bson:"createdAt"`
+	ExpiresAt       *time.Time         `bson:"expiresAt,omitempty"`
+	Clicks          int64              `bson:"clicks,omitempty"`
+	MaxClicks       *int64             `bson:"maxClicks,omitempty"`
+	SingleUse       bool               `bson:"singleUse,omitempty"`
+	ClicksRemaining *int64             `bson:"clicksRemaining,omitempty"`
 }
 
 func NewLinksRepository(m *db.Mongo) (*LinksRepository, error) {
@@ -53,12 +56,15 @@ func NewLinksRepository(m *db.Mongo) (*LinksRepository, error) {
 
 func (r *LinksRepository) Insert(ctx context.Context, link *links.Link) error {
 	doc := linkDoc{
-		Slug:      link.Slug,
-		URL:       link.URL,
-		Notes:     link.Notes,
-		APIKey:    link.APIKey,
-		CreatedAt: link.CreatedAt.UTC(),
-		ExpiresAt: link.ExpiresAt,
+		Slug:            link.Slug,
+		URL:             link.URL,
+		Notes:           link.Notes,
+		APIKey:          link.APIKey,
+		CreatedAt:       link.CreatedAt.UTC(),
+		ExpiresAt:       link.ExpiresAt,
+		MaxClicks:       link.MaxClicks,
+		SingleUse:       link.SingleUse,
+		ClicksRemaining: link.ClicksRemaining,
 	}
 
 	_, err := r.coll.InsertOne(ctx, doc)
@@ -128,14 +134,74 @@ func (r *LinksRepository) FindActiveBySlugAndIncClick(ctx context.Context, slug
 	return nil, err
 }
 
+// ConsumeClick atomically decrements slug's clicksRemaining via a single
+// findOneAndUpdate filtered on clicksRemaining > 0, mirroring
+// FindActiveBySlugAndIncClick's no-rows disambiguation: a miss means either
+// the link has no cap at all (ClicksRemaining is nil - remaining is -1, left
+// untouched) or its budget is already exhausted (ErrExpired).
+func (r *LinksRepository) ConsumeClick(ctx context.Context, slug string) (int64, error) {
+	filter := bson.M{
+		"slug":            slug,
+		"clicksRemaining": bson.M{"$gt": 0},
+	}
+	update := bson.M{
+		"$inc": bson.M{"clicksRemaining": -1},
+	}
+
+	var doc linkDoc
+	err := r.coll.FindOneAndUpdate(
+		ctx,
+		filter,
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err == nil {
+		return *doc.ClicksRemaining, nil
+	}
+
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, err
+	}
+
+	existing, findErr := r.FindBySlug(ctx, slug)
+	if findErr != nil {
+		return 0, findErr
+	}
+	if existing.ClicksRemaining == nil {
+		return -1, nil
+	}
+	return 0, links.ErrExpired
+}
+
+// RecentSlugs returns the distinct slugs of links created since the given
+// time, satisfying stats.SlugSource: the candidate set a rollup run
+// considers on each tick.
+func (r *LinksRepository) RecentSlugs(ctx context.Context, since time.Time) ([]string, error) {
+	raw, err := r.coll.Distinct(ctx, "slug", bson.M{"createdAt": bson.M{"$gte": since}})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
 func mapLinkDoc(doc linkDoc) *links.Link {
 	return &links.Link{
-		Slug:      doc.Slug,
-		URL:       doc.URL,
-		Notes:     doc.Notes,
-		APIKey:    doc.APIKey,
-		CreatedAt: doc.CreatedAt,
-		ExpiresAt: doc.ExpiresAt,
-		Clicks:    doc.Clicks,
+		Slug:            doc.Slug,
+		URL:             doc.URL,
+		Notes:           doc.Notes,
+		APIKey:          doc.APIKey,
+		CreatedAt:       doc.CreatedAt,
+		ExpiresAt:       doc.ExpiresAt,
+		Clicks:          doc.Clicks,
+		MaxClicks:       doc.MaxClicks,
+		SingleUse:       doc.SingleUse,
+		ClicksRemaining: doc.ClicksRemaining,
 	}
 }
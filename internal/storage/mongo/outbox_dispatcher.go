@@ -0,0 +1,161 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/outbox"
+	redisStorage "github.com/IgorGrieder/encurtador-url/internal/storage/redis"
+	"go.uber.org/zap"
+)
+
+// OutboxDispatcherOptions tunes the background dispatcher's polling and
+// retry behavior.
+type OutboxDispatcherOptions struct {
+	PollInterval  time.Duration
+	BatchSize     int64
+	MaxAttempts   int
+	RetryBase     time.Duration
+	RetryMax      time.Duration
+	LeaseDuration time.Duration
+}
+
+// OutboxDispatcher drains the click outbox on a timer, applying each event
+// to StatsRepository.IncDaily and marking it sent. A Redis-backed leader
+// lease ensures only one replica dispatches at a time; events that keep
+// failing past MaxAttempts are archived to the DLQ collection instead of
+// retried forever.
+type OutboxDispatcher struct {
+	outbox *ClickOutboxRepository
+	stats  *ClickStatsRepository
+	lease  *redisStorage.LeaderLease
+	opts   OutboxDispatcherOptions
+}
+
+func NewOutboxDispatcher(outbox *ClickOutboxRepository, stats *ClickStatsRepository, lease *redisStorage.LeaderLease, opts OutboxDispatcherOptions) *OutboxDispatcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 250 * time.Millisecond
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 200
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = time.Second
+	}
+	if opts.RetryMax <= 0 {
+		opts.RetryMax = 30 * time.Second
+	}
+
+	return &OutboxDispatcher{
+		outbox: outbox,
+		stats:  stats,
+		lease:  lease,
+		opts:   opts,
+	}
+}
+
+// Run blocks, polling the outbox until ctx is canceled. Launch it as a
+// goroutine from main.go.
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := d.lease.Release(releaseCtx); err != nil {
+			logger.Warn("failed to release outbox leader lease", zap.Error(err))
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.tick(ctx)
+		}
+	}
+}
+
+func (d *OutboxDispatcher) tick(ctx context.Context) {
+	isLeader, err := d.lease.TryAcquireOrRenew(ctx)
+	if err != nil {
+		logger.Warn("outbox leader lease check failed", zap.Error(err))
+		return
+	}
+	if !isLeader {
+		return
+	}
+
+	d.reportBacklog(ctx)
+
+	events, err := d.outbox.ListPending(ctx, time.Now().UTC(), d.opts.BatchSize)
+	if err != nil {
+		logger.Error("failed to list pending outbox events", zap.Error(err))
+		return
+	}
+
+	for _, ev := range events {
+		if err := d.stats.IncDaily(ctx, ev.Slug, ev.OccurredAt); err != nil {
+			d.handleFailure(ctx, ev, err)
+			continue
+		}
+		if err := d.outbox.MarkSent(ctx, ev.ID); err != nil {
+			logger.Error("failed to mark outbox event sent", zap.Error(err), zap.String("slug", ev.Slug))
+			continue
+		}
+		outboxDispatchedTotal.Inc()
+	}
+}
+
+func (d *OutboxDispatcher) handleFailure(ctx context.Context, ev OutboxClickEvent, cause error) {
+	attempt := ev.Attempts + 1
+	if attempt >= d.opts.MaxAttempts {
+		if err := d.outbox.MoveToDLQ(ctx, ev.ID, cause.Error()); err != nil {
+			logger.Error("failed to move poison outbox event to DLQ", zap.Error(err), zap.String("slug", ev.Slug))
+			return
+		}
+		outboxDLQTotal.Inc()
+		outbox.DeadEventsTotal.WithLabelValues("max_attempts").Inc()
+		logger.Warn("outbox event exhausted retries, moved to DLQ",
+			zap.String("slug", ev.Slug),
+			zap.Int("attempts", attempt),
+			zap.Error(cause),
+		)
+		return
+	}
+
+	delay := backoffDelay(d.opts.RetryBase, d.opts.RetryMax, attempt)
+	if err := d.outbox.MarkRetry(ctx, ev.ID, cause.Error(), time.Now().UTC().Add(delay)); err != nil {
+		logger.Error("failed to mark outbox event for retry", zap.Error(err), zap.String("slug", ev.Slug))
+	}
+}
+
+func (d *OutboxDispatcher) reportBacklog(ctx context.Context) {
+	if count, err := d.outbox.CountPending(ctx); err == nil {
+		outboxBacklogDepth.Set(float64(count))
+	}
+	if age, ok, err := d.outbox.OldestPendingAge(ctx, time.Now().UTC()); err == nil && ok {
+		outboxLagSeconds.Set(age.Seconds())
+	} else if err == nil {
+		outboxLagSeconds.Set(0)
+	}
+}
+
+func backoffDelay(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,36 @@
+package mongo
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	outboxBacklogDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "click_outbox_backlog_depth",
+			Help: "Number of pending events waiting in the click outbox.",
+		},
+	)
+
+	outboxLagSeconds = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "click_outbox_lag_seconds",
+			Help: "Age in seconds of the oldest pending event in the click outbox.",
+		},
+	)
+
+	outboxDispatchedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "click_outbox_dispatched_total",
+			Help: "Total number of click outbox events successfully dispatched.",
+		},
+	)
+
+	outboxDLQTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "click_outbox_dlq_total",
+			Help: "Total number of click outbox events moved to the dead-letter collection.",
+		},
+	)
+)
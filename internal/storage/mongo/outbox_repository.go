@@ -2,6 +2,8 @@ package mongo
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
@@ -14,13 +16,34 @@ import (
 )
 
 const (
-	outboxCollectionName = "click_outbox"
-	outboxStatusPending  = "pending"
-	outboxStatusSent     = "sent"
+	outboxCollectionName    = "click_outbox"
+	outboxDLQCollectionName = "click_outbox_dlq"
+	outboxStatusPending     = "pending"
+	outboxStatusSent        = "sent"
 )
 
+// processedAtSeq hands out strictly increasing processed_at timestamps
+// (as Unix nanos) within this process, so MarkSent never records a
+// processedAt that appears to go backwards even if the wall clock does.
+var processedAtSeq int64
+
+func nextProcessedAt() time.Time {
+	now := time.Now().UTC().UnixNano()
+	for {
+		last := atomic.LoadInt64(&processedAtSeq)
+		next := now
+		if next <= last {
+			next = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&processedAtSeq, last, next) {
+			return time.Unix(0, next).UTC()
+		}
+	}
+}
+
 type ClickOutboxRepository struct {
-	coll *mongo.Collection
+	coll    *mongo.Collection
+	dlqColl *mongo.Collection
 }
 
 type outboxDoc struct {
@@ -38,6 +61,18 @@ type outboxDoc struct {
 	CreatedAt     time.Time          `bson:"createdAt"`
 	UpdatedAt     time.Time          `bson:"updatedAt"`
 	SentAt        *time.Time         `bson:"sentAt,omitempty"`
+	ProcessedAt   *time.Time         `bson:"processedAt,omitempty"`
+	Owner         string             `bson:"owner,omitempty"`
+	OwnerExpires  time.Time          `bson:"ownerExpiresAt,omitempty"`
+}
+
+// outboxDLQDoc is an outboxDoc plus the bookkeeping of why it was given up
+// on, stored in a separate collection so operators can inspect and replay
+// poison messages without them clogging the pending-events scan.
+type outboxDLQDoc struct {
+	outboxDoc `bson:",inline"`
+	DLQReason string    `bson:"dlqReason"`
+	DLQAt     time.Time `bson:"dlqAt"`
 }
 
 type OutboxClickEvent struct {
@@ -51,7 +86,10 @@ type OutboxClickEvent struct {
 }
 
 func NewClickOutboxRepository(m *db.Mongo) (*ClickOutboxRepository, error) {
-	repo := &ClickOutboxRepository{coll: m.Collection(outboxCollectionName)}
+	repo := &ClickOutboxRepository{
+		coll:    m.Collection(outboxCollectionName),
+		dlqColl: m.Collection(outboxDLQCollectionName),
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -65,6 +103,21 @@ func NewClickOutboxRepository(m *db.Mongo) (*ClickOutboxRepository, error) {
 			Keys:    bson.D{{Key: "createdAt", Value: -1}},
 			Options: options.Index().SetName("createdAt_desc"),
 		},
+		// Backs ReleaseExpired's sweep for timed-out claims (see
+		// click_outbox_drain.go), same shape as ClaimPending's $or on owner/
+		// ownerExpiresAt but indexed for the "only expired claims" case.
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "ownerExpiresAt", Value: 1}},
+			Options: options.Index().SetName("status_ownerExpiresAt"),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = repo.dlqColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "dlqAt", Value: -1}},
+		Options: options.Index().SetName("dlqAt_desc"),
 	})
 	if err != nil {
 		return nil, err
@@ -145,15 +198,148 @@ func (r *ClickOutboxRepository) MarkSent(ctx context.Context, id primitive.Objec
 		ctx,
 		id,
 		bson.M{"$set": bson.M{
-			"status":    outboxStatusSent,
-			"updatedAt": now,
-			"sentAt":    now,
-			"lastError": "",
+			"status":      outboxStatusSent,
+			"updatedAt":   now,
+			"sentAt":      now,
+			"processedAt": nextProcessedAt(),
+			"lastError":   "",
 		}},
 	)
 	return err
 }
 
+// MoveToDLQ removes a poison event from the active outbox and archives it,
+// with its last error, in the dead-letter collection for operator review.
+func (r *ClickOutboxRepository) MoveToDLQ(ctx context.Context, id primitive.ObjectID, lastError string) error {
+	var doc outboxDoc
+	err := r.coll.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+
+	dlqDoc := outboxDLQDoc{
+		outboxDoc: doc,
+		DLQReason: lastError,
+		DLQAt:     time.Now().UTC(),
+	}
+	_, err = r.dlqColl.InsertOne(ctx, dlqDoc)
+	return err
+}
+
+// DeadEvent is an admin-facing view of an event archived to the
+// dead-letter collection, for operator inspection and replay.
+type DeadEvent struct {
+	ID         primitive.ObjectID
+	Slug       string
+	OccurredAt time.Time
+	Attempts   int
+	LastError  string
+	DLQReason  string
+	CreatedAt  time.Time
+	DeadAt     time.Time
+}
+
+// ErrDeadEventNotFound is returned by Requeue when id doesn't name a
+// document in the dead-letter collection.
+var ErrDeadEventNotFound = errors.New("dead outbox event not found")
+
+// ListDead returns the most recently dead-lettered events, newest first,
+// for an operator reviewing poison messages.
+func (r *ClickOutboxRepository) ListDead(ctx context.Context, limit int64) ([]DeadEvent, error) {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	cur, err := r.dlqColl.Find(
+		ctx,
+		bson.M{},
+		options.Find().
+			SetSort(bson.D{{Key: "dlqAt", Value: -1}}).
+			SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	events := make([]DeadEvent, 0)
+	for cur.Next(ctx) {
+		var doc outboxDLQDoc
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		events = append(events, DeadEvent{
+			ID:         doc.ID,
+			Slug:       doc.Slug,
+			OccurredAt: doc.OccurredAt,
+			Attempts:   doc.Attempts,
+			LastError:  doc.LastError,
+			DLQReason:  doc.DLQReason,
+			CreatedAt:  doc.CreatedAt,
+			DeadAt:     doc.DLQAt,
+		})
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Requeue moves a dead-lettered event back onto the active outbox with a
+// fresh retry budget (attempts reset to 0), for an operator who has fixed
+// whatever was causing it to fail.
+func (r *ClickOutboxRepository) Requeue(ctx context.Context, id primitive.ObjectID) error {
+	var dlqDoc outboxDLQDoc
+	if err := r.dlqColl.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&dlqDoc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrDeadEventNotFound
+		}
+		return err
+	}
+
+	now := time.Now().UTC()
+	doc := dlqDoc.outboxDoc
+	doc.Status = outboxStatusPending
+	doc.Attempts = 0
+	doc.NextAttemptAt = now
+	doc.UpdatedAt = now
+	doc.LastError = ""
+	doc.Owner = ""
+	doc.OwnerExpires = time.Time{}
+
+	_, err := r.coll.InsertOne(ctx, doc)
+	return err
+}
+
+// CountPending returns the current backlog depth, used for the
+// outbox-backlog Prometheus gauge.
+func (r *ClickOutboxRepository) CountPending(ctx context.Context) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.M{"status": outboxStatusPending})
+}
+
+// OldestPendingAge returns how long the oldest pending event has been
+// waiting, used for the outbox-lag Prometheus gauge. The bool is false when
+// the outbox is empty.
+func (r *ClickOutboxRepository) OldestPendingAge(ctx context.Context, now time.Time) (time.Duration, bool, error) {
+	var doc outboxDoc
+	err := r.coll.FindOne(
+		ctx,
+		bson.M{"status": outboxStatusPending},
+		options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return now.UTC().Sub(doc.CreatedAt.UTC()), true, nil
+}
+
 func (r *ClickOutboxRepository) MarkRetry(ctx context.Context, id primitive.ObjectID, lastError string, nextAttemptAt time.Time) error {
 	now := time.Now().UTC()
 	_, err := r.coll.UpdateByID(
@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type rollupDoc struct {
+	Slug      string    `bson:"slug"`
+	Day       string    `bson:"day"`
+	Count     int64     `bson:"count"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+type watermarkDoc struct {
+	Slug      string    `bson:"_id"`
+	Watermark time.Time `bson:"watermark"`
+}
+
+// RollupRepository persists pre-aggregated daily click counts in the
+// click_daily_rollup collection, and per-slug watermarks in
+// rollup_watermarks.
+type RollupRepository struct {
+	rollups    *mongo.Collection
+	watermarks *mongo.Collection
+}
+
+func NewRollupRepository(m *db.Mongo) (*RollupRepository, error) {
+	repo := &RollupRepository{
+		rollups:    m.Collection("click_daily_rollup"),
+		watermarks: m.Collection("rollup_watermarks"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := repo.rollups.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}, {Key: "day", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_slug_day"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (r *RollupRepository) UpsertDaily(ctx context.Context, slug string, counts []links.DailyCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(counts))
+	for _, c := range counts {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"slug": slug, "day": c.Date}).
+			SetUpdate(bson.M{"$set": bson.M{"slug": slug, "day": c.Date, "count": c.Count, "updatedAt": time.Now().UTC()}}).
+			SetUpsert(true),
+		)
+	}
+
+	_, err := r.rollups.BulkWrite(ctx, models)
+	return err
+}
+
+func (r *RollupRepository) GetDaily(ctx context.Context, slug string, from, to time.Time) ([]links.DailyCount, error) {
+	cur, err := r.rollups.Find(ctx, bson.M{
+		"slug": slug,
+		"day": bson.M{
+			"$gte": from.UTC().Format(time.DateOnly),
+			"$lte": to.UTC().Format(time.DateOnly),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []links.DailyCount
+	for cur.Next(ctx) {
+		var doc rollupDoc
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		out = append(out, links.DailyCount{Date: doc.Day, Count: doc.Count})
+	}
+	return out, cur.Err()
+}
+
+func (r *RollupRepository) Watermark(ctx context.Context, slug string) (time.Time, bool, error) {
+	var doc watermarkDoc
+	err := r.watermarks.FindOne(ctx, bson.M{"_id": slug}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return doc.Watermark.UTC(), true, nil
+}
+
+func (r *RollupRepository) SetWatermark(ctx context.Context, slug string, at time.Time) error {
+	_, err := r.watermarks.UpdateOne(ctx,
+		bson.M{"_id": slug},
+		bson.M{"$set": bson.M{"watermark": at.UTC()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
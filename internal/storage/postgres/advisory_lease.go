@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLease is a Postgres session-level advisory lock used as a leader
+// lease: it holds a single connection checked out of the pool for as long
+// as it's leader, releasing it (and the lock) when the connection is
+// returned. Unlike redisStorage.LeaderLease it needs no TTL or renewal -
+// the lock is automatically freed if the holding connection dies.
+type AdvisoryLease struct {
+	pool   *pgxpool.Pool
+	lockID int64
+
+	conn *pgxpool.Conn
+}
+
+// NewAdvisoryLease derives a stable lock ID from key via FNV-1a so callers
+// can name leases the same way redisStorage.NewLeaderLease does, instead of
+// picking an arbitrary int64.
+func NewAdvisoryLease(p *db.Postgres, key string) (*AdvisoryLease, error) {
+	if p == nil || p.Pool == nil {
+		return nil, errors.New("postgres pool is nil")
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	return &AdvisoryLease{
+		pool:   p.Pool,
+		lockID: int64(h.Sum64()),
+	}, nil
+}
+
+// TryAcquireOrRenew attempts to become (or confirms it remains) leader.
+// Renewal is a no-op: the held connection already keeps the advisory lock.
+func (l *AdvisoryLease) TryAcquireOrRenew(ctx context.Context) (bool, error) {
+	if l.conn != nil {
+		return true, nil
+	}
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, l.lockID).Scan(&acquired); err != nil {
+		conn.Release()
+		return false, err
+	}
+	if !acquired {
+		conn.Release()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Release gives up the advisory lock and returns the connection to the pool.
+func (l *AdvisoryLease) Release(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+
+	_, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, l.lockID)
+	l.conn.Release()
+	l.conn = nil
+	return err
+}
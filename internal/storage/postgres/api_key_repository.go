@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/IgorGrieder/encurtador-url/internal/middleware"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// APIKeyRepository resolves hashed API keys against the api_keys table.
+// Unlike the other Postgres repositories it queries the pool directly
+// rather than through sqlc.Queries: there is no generated package to add
+// this query to (no queries/*.sql or sqlc.yaml exist in this tree yet), so
+// hand-writing the query here is the honest option until that scaffolding
+// is added.
+type APIKeyRepository struct {
+	pool pgPool
+}
+
+// pgPool is the subset of pgxpool.Pool this repository needs, narrowed so
+// it's trivial to fake in tests without a real database.
+type pgPool interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgxRow
+}
+
+// pgxRow mirrors pgx.Row's single method, used only to keep pgPool's
+// signature dependency-free of the pgx import in this file's public surface.
+type pgxRow interface {
+	Scan(dest ...any) error
+}
+
+type poolAdapter struct {
+	pool *pgxpool.Pool
+}
+
+func (a poolAdapter) QueryRow(ctx context.Context, sql string, args ...any) pgxRow {
+	return a.pool.QueryRow(ctx, sql, args...)
+}
+
+func NewAPIKeyRepository(p *db.Postgres) (*APIKeyRepository, error) {
+	if p == nil || p.Pool == nil {
+		return nil, errors.New("postgres pool is nil")
+	}
+	return &APIKeyRepository{pool: poolAdapter{p.Pool}}, nil
+}
+
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*middleware.APIKey, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT id, key_hash, scopes, revoked FROM api_keys WHERE key_hash = $1`,
+		hash,
+	)
+
+	var key middleware.APIKey
+	if err := row.Scan(&key.ID, &key.KeyHash, &key.Scopes, &key.Revoked); err != nil {
+		return nil, middleware.ErrKeyNotFound
+	}
+	if key.Revoked {
+		return nil, middleware.ErrKeyNotFound
+	}
+	return &key, nil
+}
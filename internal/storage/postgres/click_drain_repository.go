@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+)
+
+// ClickDrainRepository adapts ClickOutboxRepository's existing claim
+// methods to links.DrainRepository. It translates rather than widening
+// ClaimPending/MarkSent/MarkRetry's own signatures, because cmd/outbox_worker
+// already depends on those as they are today.
+type ClickDrainRepository struct {
+	repo *ClickOutboxRepository
+}
+
+func NewClickDrainRepository(repo *ClickOutboxRepository) *ClickDrainRepository {
+	return &ClickDrainRepository{repo: repo}
+}
+
+func (a *ClickDrainRepository) ClaimPending(ctx context.Context, now time.Time, limit int, workerID string, lease time.Duration) ([]links.OutboxEvent, error) {
+	claimed, err := a.repo.ClaimPending(ctx, now, int64(limit), workerID, lease)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]links.OutboxEvent, len(claimed))
+	for i, ev := range claimed {
+		events[i] = links.OutboxEvent{
+			ID:         ev.ID,
+			Slug:       ev.Slug,
+			OccurredAt: ev.OccurredAt,
+			Attempts:   ev.Attempts,
+		}
+	}
+	return events, nil
+}
+
+func (a *ClickDrainRepository) MarkDrained(ctx context.Context, id, workerID string) error {
+	return a.repo.MarkSent(ctx, id, workerID)
+}
+
+func (a *ClickDrainRepository) MarkRetry(ctx context.Context, id, workerID, lastError string, nextAttemptAt time.Time) error {
+	return a.repo.MarkRetry(ctx, id, workerID, lastError, nextAttemptAt)
+}
+
+func (a *ClickDrainRepository) MoveToDLQ(ctx context.Context, id, workerID, lastError string) error {
+	return a.repo.MoveToDLQ(ctx, id, workerID, lastError)
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ClickDrainSink implements links.DrainSink: it commits one slug-day
+// group's worth of drained outbox events as a single transaction
+// incrementing both links.clicks and click_daily_rollup.count, so
+// ClickOutboxDrainer can never apply one without the other.
+type ClickDrainSink struct {
+	pool *pgxpool.Pool
+}
+
+func NewClickDrainSink(p *db.Postgres) (*ClickDrainSink, error) {
+	if p == nil || p.Pool == nil {
+		return nil, errors.New("postgres pool is nil")
+	}
+	return &ClickDrainSink{pool: p.Pool}, nil
+}
+
+func (s *ClickDrainSink) ApplySlugBatch(ctx context.Context, slug string, clicks int, day time.Time) error {
+	if clicks <= 0 {
+		return nil
+	}
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `UPDATE links SET clicks = clicks + $2 WHERE slug = $1`, slug, clicks); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO click_daily_rollup (slug, day, count, updated_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT (slug, day) DO UPDATE SET count = click_daily_rollup.count + $3, updated_at = now()`,
+		slug, day, clicks,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
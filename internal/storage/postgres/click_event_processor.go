@@ -7,12 +7,16 @@ import (
 	"time"
 
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/tracing"
 	"github.com/IgorGrieder/encurtador-url/internal/storage/postgres/sqlc"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const processorTracerComponent = "postgres.click_event_processor"
+
 type ClickEventProcessor struct {
 	pool *pgxpool.Pool
 }
@@ -50,9 +54,16 @@ func (p *ClickEventProcessor) Process(
 	}()
 
 	queries := sqlc.New(tx)
-	insertedRows, err := queries.InsertProcessedEventOnce(ctx, sqlc.InsertProcessedEventOnceParams{
-		EventID:     eventID,
-		ProcessedAt: processorToTimestamptz(time.Now().UTC()),
+
+	var insertedRows int64
+	err = tracing.StartSpan(ctx, processorTracerComponent, "insert_processed_event", []attribute.KeyValue{
+		tracing.DBSystemAttr("postgresql"),
+	}, func(ctx context.Context) error {
+		insertedRows, err = queries.InsertProcessedEventOnce(ctx, sqlc.InsertProcessedEventOnceParams{
+			EventID:     eventID,
+			ProcessedAt: processorToTimestamptz(time.Now().UTC()),
+		})
+		return err
 	})
 	if err != nil {
 		return false, false, err
@@ -66,9 +77,15 @@ func (p *ClickEventProcessor) Process(
 		return true, false, nil
 	}
 
-	_, err = queries.GetActiveLinkBySlugAndIncClick(ctx, sqlc.GetActiveLinkBySlugAndIncClickParams{
-		Slug:      slug,
-		ExpiresAt: processorToTimestamptz(occurredAt),
+	err = tracing.StartSpan(ctx, processorTracerComponent, "inc_click", []attribute.KeyValue{
+		tracing.DBSystemAttr("postgresql"),
+		tracing.SlugAttr(slug),
+	}, func(ctx context.Context) error {
+		_, err := queries.GetActiveLinkBySlugAndIncClick(ctx, sqlc.GetActiveLinkBySlugAndIncClickParams{
+			Slug:      slug,
+			ExpiresAt: processorToTimestamptz(occurredAt),
+		})
+		return err
 	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -81,10 +98,16 @@ func (p *ClickEventProcessor) Process(
 		return false, false, err
 	}
 
-	if err := queries.IncDailyClick(ctx, sqlc.IncDailyClickParams{
-		Slug: slug,
-		Day:  processorToDate(occurredAt),
-	}); err != nil {
+	err = tracing.StartSpan(ctx, processorTracerComponent, "inc_daily", []attribute.KeyValue{
+		tracing.DBSystemAttr("postgresql"),
+		tracing.SlugAttr(slug),
+	}, func(ctx context.Context) error {
+		return queries.IncDailyClick(ctx, sqlc.IncDailyClickParams{
+			Slug: slug,
+			Day:  processorToDate(occurredAt),
+		})
+	})
+	if err != nil {
 		return false, false, err
 	}
 
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"github.com/IgorGrieder/encurtador-url/internal/storage/postgres/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PostgresSink is the default Postgres links.ClickSink: a flush's whole
+// batch of ClickCounts is applied in one IncDailyClickBatch call - a single
+// `INSERT ... SELECT * FROM unnest($1::text[], $2::date[], $3::bigint[]) ON
+// CONFLICT (slug, day) DO UPDATE SET count = click_daily.count +
+// EXCLUDED.count` - rather than one upsert per (slug, day) pair.
+type PostgresSink struct {
+	base *ClickStatsRepository
+}
+
+func NewPostgresSink(base *ClickStatsRepository) *PostgresSink {
+	return &PostgresSink{base: base}
+}
+
+func (s *PostgresSink) Flush(ctx context.Context, counts []links.ClickCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	slugs := make([]string, len(counts))
+	days := make([]pgtype.Date, len(counts))
+	amounts := make([]int64, len(counts))
+	for i, c := range counts {
+		slugs[i] = c.Slug
+		day, err := time.Parse(time.DateOnly, c.Date)
+		if err != nil {
+			return err
+		}
+		days[i] = toDate(day)
+		amounts[i] = c.Count
+	}
+
+	return s.base.queries.IncDailyClickBatch(ctx, sqlc.IncDailyClickBatchParams{
+		Slugs:   slugs,
+		Days:    days,
+		Amounts: amounts,
+	})
+}
+
+// Close is a no-op: PostgresSink writes through the shared
+// *ClickStatsRepository connection, which outlives the sink and is closed
+// by whoever owns the underlying Postgres pool (see db.Postgres).
+func (s *PostgresSink) Close(context.Context) error {
+	return nil
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyRepository backs links.IdempotencyRepository against the
+// idempotency_keys table. Like APIKeyRepository and RollupRepository it
+// queries the pool directly rather than through sqlc.Queries, for the same
+// reason: there's no queries/*.sql or sqlc.yaml in this tree yet to add
+// these to.
+//
+// Begin and Complete/Fail are deliberately separate statements rather than
+// one transaction spanning LinksRepository.Insert: links.Service already
+// treats link storage and cross-cutting concerns (stats, outbox) as
+// independent repositories coordinated at the service layer, and
+// idempotency follows that same boundary. A crash between createLink
+// succeeding and Complete running leaves the key "pending" until its TTL
+// expires, at which point it's reclaimed like any other stale key -
+// the worst case is one extra retry, not a correctness issue.
+type IdempotencyRepository struct {
+	pool *pgxpool.Pool
+	ttl  time.Duration
+}
+
+func NewIdempotencyRepository(p *db.Postgres, ttl time.Duration) (*IdempotencyRepository, error) {
+	if p == nil || p.Pool == nil {
+		return nil, errors.New("postgres pool is nil")
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &IdempotencyRepository{pool: p.Pool, ttl: ttl}, nil
+}
+
+func (r *IdempotencyRepository) Begin(ctx context.Context, key, apiKey, requestHash string) (*links.IdempotencyRecord, bool, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(r.ttl)
+
+	var rec links.IdempotencyRecord
+	var status string
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (key, api_key, request_hash, status, slug, created_at, expires_at)
+		VALUES ($1, $2, $3, 'pending', '', $4, $5)
+		ON CONFLICT (key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			status = 'pending',
+			slug = '',
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.status = 'failed' OR idempotency_keys.expires_at <= $4
+		RETURNING api_key, request_hash, status, slug, created_at`,
+		key, apiKey, requestHash, now, expiresAt,
+	).Scan(&rec.APIKey, &rec.RequestHash, &status, &rec.Slug, &rec.CreatedAt)
+
+	if err == nil {
+		rec.Key = key
+		rec.Status = links.IdempotencyStatus(status)
+		return &rec, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
+	}
+
+	// The conflicting row wasn't reclaimable (still pending or completed):
+	// report it as-is instead of claiming it.
+	err = r.pool.QueryRow(ctx,
+		`SELECT api_key, request_hash, status, slug, created_at FROM idempotency_keys WHERE key = $1`,
+		key,
+	).Scan(&rec.APIKey, &rec.RequestHash, &status, &rec.Slug, &rec.CreatedAt)
+	if err != nil {
+		return nil, false, err
+	}
+	rec.Key = key
+	rec.Status = links.IdempotencyStatus(status)
+	return &rec, false, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key, slug string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE idempotency_keys SET status = 'completed', slug = $2 WHERE key = $1`,
+		key, slug,
+	)
+	return err
+}
+
+func (r *IdempotencyRepository) Fail(ctx context.Context, key string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE idempotency_keys SET status = 'failed' WHERE key = $1`, key)
+	return err
+}
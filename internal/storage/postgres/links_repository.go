@@ -7,13 +7,17 @@ import (
 	"time"
 
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/tracing"
 	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
 	"github.com/IgorGrieder/encurtador-url/internal/storage/postgres/sqlc"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const tracerComponent = "postgres.links"
+
 type LinksRepository struct {
 	queries *sqlc.Queries
 }
@@ -30,35 +34,51 @@ func (r *LinksRepository) Insert(ctx context.Context, link *links.Link) error {
 		return errors.New("link is nil")
 	}
 
-	_, err := r.queries.CreateLink(ctx, sqlc.CreateLinkParams{
-		Slug:      link.Slug,
-		Url:       link.URL,
-		Notes:     toNullableText(link.Notes),
-		ApiKey:    toNullableText(link.APIKey),
-		CreatedAt: toTimestamptz(link.CreatedAt),
-		ExpiresAt: toNullableTimestamptz(link.ExpiresAt),
-		Clicks:    link.Clicks,
+	return tracing.StartSpan(ctx, tracerComponent, "Insert", []attribute.KeyValue{
+		tracing.DBSystemAttr("postgresql"),
+		tracing.SlugAttr(link.Slug),
+	}, func(ctx context.Context) error {
+		_, err := r.queries.CreateLink(ctx, sqlc.CreateLinkParams{
+			Slug:            link.Slug,
+			Url:             link.URL,
+			Notes:           toNullableText(link.Notes),
+			ApiKey:          toNullableText(link.APIKey),
+			CreatedAt:       toTimestamptz(link.CreatedAt),
+			ExpiresAt:       toNullableTimestamptz(link.ExpiresAt),
+			Clicks:          link.Clicks,
+			MaxClicks:       toNullableInt8(link.MaxClicks),
+			SingleUse:       link.SingleUse,
+			ClicksRemaining: toNullableInt8(link.ClicksRemaining),
+		})
+		if err == nil {
+			return nil
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return links.ErrSlugTaken
+		}
+		return err
 	})
-	if err == nil {
-		return nil
-	}
-
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-		return links.ErrSlugTaken
-	}
-	return err
 }
 
 func (r *LinksRepository) FindBySlug(ctx context.Context, slug string) (*links.Link, error) {
-	row, err := r.queries.GetLinkBySlug(ctx, slug)
-	if err == nil {
-		return mapLinkRow(row), nil
-	}
-	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, links.ErrNotFound
-	}
-	return nil, err
+	var out *links.Link
+	err := tracing.StartSpan(ctx, tracerComponent, "FindBySlug", []attribute.KeyValue{
+		tracing.DBSystemAttr("postgresql"),
+		tracing.SlugAttr(slug),
+	}, func(ctx context.Context) error {
+		row, err := r.queries.GetLinkBySlug(ctx, slug)
+		if err == nil {
+			out = mapLinkRow(row)
+			return nil
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return links.ErrNotFound
+		}
+		return err
+	})
+	return out, err
 }
 
 func (r *LinksRepository) FindActiveBySlug(ctx context.Context, slug string, at time.Time) (*links.Link, error) {
@@ -84,33 +104,82 @@ func (r *LinksRepository) FindActiveBySlug(ctx context.Context, slug string, at
 }
 
 func (r *LinksRepository) FindActiveBySlugAndIncClick(ctx context.Context, slug string, at time.Time) (*links.Link, error) {
-	row, err := r.queries.GetActiveLinkBySlugAndIncClick(ctx, sqlc.GetActiveLinkBySlugAndIncClickParams{
-		Slug:      slug,
-		ExpiresAt: toTimestamptz(at),
+	var out *links.Link
+	err := tracing.StartSpan(ctx, tracerComponent, "FindActiveBySlugAndIncClick", []attribute.KeyValue{
+		tracing.DBSystemAttr("postgresql"),
+		tracing.SlugAttr(slug),
+	}, func(ctx context.Context) error {
+		row, err := r.queries.GetActiveLinkBySlugAndIncClick(ctx, sqlc.GetActiveLinkBySlugAndIncClickParams{
+			Slug:      slug,
+			ExpiresAt: toTimestamptz(at),
+		})
+		if err == nil {
+			out = mapLinkRow(row)
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		existing, findErr := r.FindBySlug(ctx, slug)
+		if findErr == nil && existing != nil {
+			return links.ErrExpired
+		}
+		if findErr != nil {
+			return findErr
+		}
+		return links.ErrNotFound
 	})
-	if err == nil {
-		return mapLinkRow(row), nil
-	}
-	if !errors.Is(err, pgx.ErrNoRows) {
-		return nil, err
-	}
+	return out, err
+}
 
-	existing, findErr := r.FindBySlug(ctx, slug)
-	if findErr == nil && existing != nil {
-		return nil, links.ErrExpired
-	}
-	if findErr != nil {
-		return nil, findErr
-	}
-	return nil, links.ErrNotFound
+// ConsumeClick atomically decrements slug's clicks_remaining via a single
+// `UPDATE ... WHERE clicks_remaining > 0 RETURNING clicks_remaining`, mirroring
+// FindActiveBySlugAndIncClick's no-rows disambiguation: a miss means either
+// the link has no cap at all (ClicksRemaining is nil - remaining is -1, left
+// untouched) or its budget is already exhausted (ErrExpired).
+func (r *LinksRepository) ConsumeClick(ctx context.Context, slug string) (int64, error) {
+	var remaining int64
+	err := tracing.StartSpan(ctx, tracerComponent, "ConsumeClick", []attribute.KeyValue{
+		tracing.DBSystemAttr("postgresql"),
+		tracing.SlugAttr(slug),
+	}, func(ctx context.Context) error {
+		left, err := r.queries.ConsumeLinkClick(ctx, slug)
+		if err == nil {
+			remaining = left
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		existing, findErr := r.FindBySlug(ctx, slug)
+		if findErr != nil {
+			return findErr
+		}
+		if existing.ClicksRemaining == nil {
+			remaining = -1
+			return nil
+		}
+		return links.ErrExpired
+	})
+	return remaining, err
 }
 
 func (r *LinksRepository) DeleteBySlug(ctx context.Context, slug string) (bool, error) {
-	rows, err := r.queries.DeleteLinkBySlug(ctx, slug)
-	if err != nil {
-		return false, err
-	}
-	return rows > 0, nil
+	var deleted bool
+	err := tracing.StartSpan(ctx, tracerComponent, "DeleteBySlug", []attribute.KeyValue{
+		tracing.DBSystemAttr("postgresql"),
+		tracing.SlugAttr(slug),
+	}, func(ctx context.Context) error {
+		rows, err := r.queries.DeleteLinkBySlug(ctx, slug)
+		if err != nil {
+			return err
+		}
+		deleted = rows > 0
+		return nil
+	})
+	return deleted, err
 }
 
 func mapLinkRow(row sqlc.Link) *links.Link {
@@ -121,12 +190,21 @@ func mapLinkRow(row sqlc.Link) *links.Link {
 		APIKey:    nullableTextValue(row.ApiKey),
 		CreatedAt: row.CreatedAt.Time.UTC(),
 		Clicks:    row.Clicks,
+		SingleUse: row.SingleUse,
 	}
 
 	if row.ExpiresAt.Valid {
 		t := row.ExpiresAt.Time.UTC()
 		out.ExpiresAt = &t
 	}
+	if row.MaxClicks.Valid {
+		v := row.MaxClicks.Int64
+		out.MaxClicks = &v
+	}
+	if row.ClicksRemaining.Valid {
+		v := row.ClicksRemaining.Int64
+		out.ClicksRemaining = &v
+	}
 
 	return out
 }
@@ -162,3 +240,10 @@ func toNullableTimestamptz(v *time.Time) pgtype.Timestamptz {
 	}
 	return toTimestamptz(*v)
 }
+
+func toNullableInt8(v *int64) pgtype.Int8 {
+	if v == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *v, Valid: true}
+}
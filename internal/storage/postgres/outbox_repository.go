@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 )
@@ -22,6 +23,7 @@ const (
 var ErrOutboxEventNotOwned = errors.New("outbox event not owned by worker")
 
 type ClickOutboxRepository struct {
+	pool    *pgxpool.Pool
 	queries *sqlc.Queries
 }
 
@@ -39,15 +41,26 @@ func NewClickOutboxRepository(p *db.Postgres) (*ClickOutboxRepository, error) {
 	if p == nil || p.Pool == nil {
 		return nil, errors.New("postgres pool is nil")
 	}
-	return &ClickOutboxRepository{queries: sqlc.New(p.Pool)}, nil
+	return &ClickOutboxRepository{pool: p.Pool, queries: sqlc.New(p.Pool)}, nil
 }
 
+// outboxNotifyChannel is the Postgres NOTIFY channel EnqueueClick signals on
+// and Subscribe listens to, letting a dispatcher wake immediately on a new
+// event instead of waiting out its poll interval.
+const outboxNotifyChannel = "click_outbox"
+
 func (r *ClickOutboxRepository) EnqueueClick(ctx context.Context, slug string, occurredAt time.Time) error {
 	now := time.Now().UTC()
 	carrier := propagation.MapCarrier{}
 	otel.GetTextMapPropagator().Inject(ctx, carrier)
 
-	_, err := r.queries.EnqueueClickOutbox(ctx, sqlc.EnqueueClickOutboxParams{
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := sqlc.New(tx).EnqueueClickOutbox(ctx, sqlc.EnqueueClickOutboxParams{
 		EventType:     "click.recorded",
 		Slug:          slug,
 		OccurredAt:    toOutboxTimestamptz(occurredAt),
@@ -57,16 +70,131 @@ func (r *ClickOutboxRepository) EnqueueClick(ctx context.Context, slug string, o
 		Status:        outboxStatusPending,
 		NextAttemptAt: toOutboxTimestamptz(now),
 		CreatedAt:     toOutboxTimestamptz(now),
-	})
-	return err
+	}); err != nil {
+		return err
+	}
+
+	// pg_notify keeps the payload parameterized - NOTIFY's own syntax only
+	// accepts a string literal, which would mean building the statement by
+	// hand for a value (the slug) that isn't ours to trust unescaped.
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, outboxNotifyChannel, slug); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Subscribe opens a dedicated connection that LISTENs on outboxNotifyChannel
+// and reports one signal per notification on the returned channel, so a
+// dispatcher can wake immediately instead of waiting for its next poll.
+// Sends are non-blocking and coalesce: if a wakeup is already buffered, a
+// second one before it's consumed is simply dropped, which is fine since a
+// wakeup only ever means "go claim pending work again", not "N events are
+// now available". On a dropped connection it reacquires and re-issues
+// LISTEN with a short backoff rather than giving up, since losing this
+// channel only degrades a dispatcher back to poll-interval latency, it
+// doesn't lose events (ClaimPending is still the source of truth).
+func (r *ClickOutboxRepository) Subscribe(ctx context.Context) (<-chan struct{}, error) {
+	ch := make(chan struct{}, 1)
+
+	conn, err := r.listen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer close(ch)
+		for {
+			if ctx.Err() != nil {
+				conn.Release()
+				return
+			}
+
+			notifyCtx, cancel := context.WithTimeout(ctx, 35*time.Second)
+			_, err := conn.Conn().WaitForNotification(notifyCtx)
+			cancel()
+			if err == nil {
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+				continue
+			}
+
+			if ctx.Err() != nil {
+				conn.Release()
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				// Idle timeout, not a dropped connection - keep waiting.
+				continue
+			}
+
+			// The connection dropped: release it and reconnect, re-issuing
+			// LISTEN (a fresh connection never inherits it), with backoff.
+			conn.Release()
+			backoff := time.Second
+			for {
+				conn, err = r.listen(ctx)
+				if err == nil {
+					break
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				if backoff < 15*time.Second {
+					backoff *= 2
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// listen acquires a pool connection and issues LISTEN on it. The connection
+// is held (not returned to the pool) for as long as the caller keeps it, per
+// pgxpool's documented pattern for session-scoped state like LISTEN/NOTIFY.
+func (r *ClickOutboxRepository) listen(ctx context.Context) (*pgxpool.Conn, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+outboxNotifyChannel); err != nil {
+		conn.Release()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// OutboxShard assigns a worker a disjoint slice of slugs to claim, the same
+// way a Kafka consumer group assigns partitions: hashtext(slug) % Count =
+// Index. A slug always hashes to the same shard, so every click for a given
+// link is claimed by the same worker and lands on the sink in order.
+// Count <= 1 means unsharded - every worker claims from the whole table,
+// the original single-worker behavior.
+type OutboxShard struct {
+	Count int
+	Index int
 }
 
+// ClaimPending claims up to limit pending events for workerID, restricted
+// to shard's slice of slugs. There's no queries/*.sql or sqlc.yaml in this
+// tree to add a sharded variant of ClaimNextOutboxEvent to (see MoveToDLQ's
+// doc comment for the same gap), so unlike this file's other sqlc-backed
+// methods it queries r.pool directly.
 func (r *ClickOutboxRepository) ClaimPending(
 	ctx context.Context,
 	now time.Time,
 	limit int64,
 	workerID string,
 	lease time.Duration,
+	shard OutboxShard,
 ) ([]OutboxClickEvent, error) {
 	if limit <= 0 {
 		limit = 1
@@ -74,19 +202,46 @@ func (r *ClickOutboxRepository) ClaimPending(
 	if lease <= 0 {
 		lease = 30 * time.Second
 	}
+	if shard.Count <= 0 {
+		shard.Count = 1
+	}
 	workerID = strings.TrimSpace(workerID)
 	if workerID == "" {
 		return nil, errors.New("workerID must not be empty")
 	}
 
 	now = now.UTC()
+	nowTs := toOutboxTimestamptz(now)
+	leaseTs := toOutboxTimestamptz(now.Add(lease))
+
 	events := make([]OutboxClickEvent, 0, limit)
 	for int64(len(events)) < limit {
-		row, err := r.queries.ClaimNextOutboxEvent(ctx, sqlc.ClaimNextOutboxEventParams{
-			UpdatedAt:           toOutboxTimestamptz(now),
-			ProcessingOwner:     toOutboxNullableText(workerID),
-			ProcessingExpiresAt: toOutboxTimestamptz(now.Add(lease)),
-		})
+		var (
+			pgID        pgtype.UUID
+			slug        string
+			occurredAt  pgtype.Timestamptz
+			traceparent pgtype.Text
+			tracestate  pgtype.Text
+			baggage     pgtype.Text
+			attempts    int32
+		)
+		err := r.pool.QueryRow(ctx, `
+			UPDATE click_outbox
+			SET processing_owner = $1, processing_expires_at = $2, updated_at = $3
+			WHERE id = (
+				SELECT id
+				FROM click_outbox
+				WHERE status = $4
+				  AND next_attempt_at <= $3
+				  AND (processing_owner IS NULL OR processing_expires_at <= $3)
+				  AND ($5::int <= 1 OR abs(hashtext(slug)) % $5::int = $6::int)
+				ORDER BY created_at ASC
+				FOR UPDATE SKIP LOCKED
+				LIMIT 1
+			)
+			RETURNING id, slug, occurred_at, traceparent, tracestate, baggage, attempts`,
+			toOutboxNullableText(workerID), leaseTs, nowTs, outboxStatusPending, shard.Count, shard.Index,
+		).Scan(&pgID, &slug, &occurredAt, &traceparent, &tracestate, &baggage, &attempts)
 		if errors.Is(err, pgx.ErrNoRows) {
 			break
 		}
@@ -94,18 +249,18 @@ func (r *ClickOutboxRepository) ClaimPending(
 			return nil, err
 		}
 
-		id, err := uuidStringFromPg(row.ID)
+		id, err := uuidStringFromPg(pgID)
 		if err != nil {
 			return nil, err
 		}
 		events = append(events, OutboxClickEvent{
 			ID:          id,
-			Slug:        row.Slug,
-			OccurredAt:  row.OccurredAt.Time.UTC(),
-			TraceParent: outboxNullableTextValue(row.Traceparent),
-			TraceState:  outboxNullableTextValue(row.Tracestate),
-			Baggage:     outboxNullableTextValue(row.Baggage),
-			Attempts:    int(row.Attempts),
+			Slug:        slug,
+			OccurredAt:  occurredAt.Time.UTC(),
+			TraceParent: outboxNullableTextValue(traceparent),
+			TraceState:  outboxNullableTextValue(tracestate),
+			Baggage:     outboxNullableTextValue(baggage),
+			Attempts:    int(attempts),
 		})
 	}
 
@@ -158,6 +313,157 @@ func (r *ClickOutboxRepository) MarkRetry(
 	return nil
 }
 
+// MoveToDLQ archives a poison outbox event to click_outbox_dlq and removes
+// it from the active table, mirroring Mongo's click_outbox_dlq collection.
+// There's no queries/*.sql or sqlc.yaml in this tree to add this query to
+// (see IdempotencyRepository's doc comment for the same gap), so unlike
+// the rest of this file it queries r.pool directly instead of through
+// sqlc.Queries.
+func (r *ClickOutboxRepository) MoveToDLQ(ctx context.Context, id string, workerID string, lastError string) error {
+	pgID, err := parsePgUUID(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO click_outbox_dlq (id, event_type, slug, occurred_at, traceparent, tracestate, baggage, attempts, last_error, dlq_reason, created_at, dlq_at)
+		SELECT id, event_type, slug, occurred_at, traceparent, tracestate, baggage, attempts, last_error, $2, created_at, $3
+		FROM click_outbox
+		WHERE id = $1 AND processing_owner = $4`,
+		pgID, lastError, time.Now().UTC(), workerID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOutboxEventNotOwned
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM click_outbox WHERE id = $1`, pgID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeadEvent is an admin-facing view of an event archived to
+// click_outbox_dlq, for operator inspection and replay.
+type DeadEvent struct {
+	ID         string
+	Slug       string
+	OccurredAt time.Time
+	Attempts   int
+	LastError  string
+	DLQReason  string
+	CreatedAt  time.Time
+	DeadAt     time.Time
+}
+
+// ErrDeadEventNotFound is returned by Requeue when id doesn't name a row in
+// click_outbox_dlq.
+var ErrDeadEventNotFound = errors.New("dead outbox event not found")
+
+// ListDead returns the most recently dead-lettered events, newest first,
+// for an operator reviewing poison messages. There's no queries/*.sql or
+// sqlc.yaml in this tree (see MoveToDLQ's doc comment), so like MoveToDLQ
+// this queries r.pool directly.
+func (r *ClickOutboxRepository) ListDead(ctx context.Context, limit int) ([]DeadEvent, error) {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, slug, occurred_at, attempts, last_error, dlq_reason, created_at, dlq_at
+		FROM click_outbox_dlq
+		ORDER BY dlq_at DESC
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]DeadEvent, 0)
+	for rows.Next() {
+		var (
+			pgID       pgtype.UUID
+			slug       string
+			occurredAt pgtype.Timestamptz
+			attempts   int32
+			lastError  pgtype.Text
+			dlqReason  pgtype.Text
+			createdAt  pgtype.Timestamptz
+			deadAt     pgtype.Timestamptz
+		)
+		if err := rows.Scan(&pgID, &slug, &occurredAt, &attempts, &lastError, &dlqReason, &createdAt, &deadAt); err != nil {
+			return nil, err
+		}
+		id, err := uuidStringFromPg(pgID)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, DeadEvent{
+			ID:         id,
+			Slug:       slug,
+			OccurredAt: occurredAt.Time.UTC(),
+			Attempts:   int(attempts),
+			LastError:  outboxNullableTextValue(lastError),
+			DLQReason:  outboxNullableTextValue(dlqReason),
+			CreatedAt:  createdAt.Time.UTC(),
+			DeadAt:     deadAt.Time.UTC(),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Requeue moves a dead-lettered event back onto the active outbox with a
+// fresh retry budget (attempts reset to 0), for an operator who has fixed
+// whatever was causing it to fail.
+func (r *ClickOutboxRepository) Requeue(ctx context.Context, id string) error {
+	pgID, err := parsePgUUID(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now().UTC()
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO click_outbox (id, event_type, slug, occurred_at, traceparent, tracestate, baggage, status, attempts, next_attempt_at, created_at, updated_at)
+		SELECT id, event_type, slug, occurred_at, traceparent, tracestate, baggage, $2, 0, $3, created_at, $3
+		FROM click_outbox_dlq
+		WHERE id = $1`,
+		pgID, outboxStatusPending, now,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDeadEventNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM click_outbox_dlq WHERE id = $1`, pgID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 func toOutboxNullableText(v string) pgtype.Text {
 	v = strings.TrimSpace(v)
 	if v == "" {
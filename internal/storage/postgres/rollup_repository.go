@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/db"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RollupRepository persists pre-aggregated daily click counts against
+// click_daily_rollup and per-slug watermarks against rollup_watermarks.
+// Like APIKeyRepository it queries the pool directly rather than through
+// sqlc.Queries, for the same reason: there's no queries/*.sql or sqlc.yaml
+// in this tree yet to add these to.
+type RollupRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRollupRepository(p *db.Postgres) (*RollupRepository, error) {
+	if p == nil || p.Pool == nil {
+		return nil, errors.New("postgres pool is nil")
+	}
+	return &RollupRepository{pool: p.Pool}, nil
+}
+
+func (r *RollupRepository) UpsertDaily(ctx context.Context, slug string, counts []links.DailyCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, c := range counts {
+		batch.Queue(
+			`INSERT INTO click_daily_rollup (slug, day, count, updated_at)
+			 VALUES ($1, $2, $3, now())
+			 ON CONFLICT (slug, day) DO UPDATE SET count = $3, updated_at = now()`,
+			slug, c.Date, c.Count,
+		)
+	}
+
+	br := r.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for range counts {
+		if _, err := br.Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RollupRepository) GetDaily(ctx context.Context, slug string, from, to time.Time) ([]links.DailyCount, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT day, count FROM click_daily_rollup WHERE slug = $1 AND day BETWEEN $2 AND $3 ORDER BY day`,
+		slug, from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []links.DailyCount
+	for rows.Next() {
+		var day time.Time
+		var count int64
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, err
+		}
+		out = append(out, links.DailyCount{Date: day.Format(time.DateOnly), Count: count})
+	}
+	return out, rows.Err()
+}
+
+func (r *RollupRepository) Watermark(ctx context.Context, slug string) (time.Time, bool, error) {
+	var wm time.Time
+	err := r.pool.QueryRow(ctx, `SELECT watermark FROM rollup_watermarks WHERE slug = $1`, slug).Scan(&wm)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return wm.UTC(), true, nil
+}
+
+func (r *RollupRepository) SetWatermark(ctx context.Context, slug string, at time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO rollup_watermarks (slug, watermark) VALUES ($1, $2)
+		 ON CONFLICT (slug) DO UPDATE SET watermark = $2`,
+		slug, at.UTC(),
+	)
+	return err
+}
+
+// RecentSlugs returns the distinct slugs of links created since the given
+// time, the candidate set a rollup run considers each tick.
+func (r *RollupRepository) RecentSlugs(ctx context.Context, since time.Time) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT DISTINCT slug FROM links WHERE created_at >= $1`, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		out = append(out, slug)
+	}
+	return out, rows.Err()
+}
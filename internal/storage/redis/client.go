@@ -8,44 +8,142 @@ import (
 	"io"
 	"net"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+// topologyMode selects how a Client discovers which address to send a
+// command to. Single mode sends everything to one fixed address; sentinel
+// mode re-resolves that address through Redis Sentinel whenever the master
+// fails over; cluster mode routes each command by key hash slot across
+// many addresses.
+type topologyMode int
+
+const (
+	modeSingle topologyMode = iota
+	modeSentinel
+	modeCluster
+)
+
+// pooledConn tracks how long a connection has sat idle in the pool, so
+// getConn can cheaply revalidate it (see healthCheck) instead of handing a
+// possibly-dead connection to a caller - the common way a Redis failover or
+// restart shows up is a hard failure on the first command after it.
+type pooledConn struct {
+	conn     net.Conn
+	lastUsed time.Time
+}
+
 type Client struct {
-	addr     string
 	password string
 	db       int
-
-	pool chan net.Conn
-	mu   sync.Mutex
+	poolSize int
+
+	// idleTimeout is how long a pooled connection may sit unused before
+	// getConn health-checks it with a PING instead of handing it straight
+	// back to a caller.
+	idleTimeout time.Duration
+
+	mode topologyMode
+
+	sentinelAddrs []string
+	masterName    string
+	clusterAddrs  []string
+
+	// topoMu guards addr (single/sentinel mode's current backend) and slots
+	// (cluster mode's slot map), both of which can change after New: addr
+	// on sentinel failover, slots on a cluster resharding (MOVED/ASK).
+	topoMu sync.RWMutex
+	addr   string
+	slots  []slotRange
+
+	// poolsMu guards pools, keyed by backend address - cluster mode talks
+	// to many addresses, so a single pool/addr pair (the old single-address
+	// design) isn't enough.
+	poolsMu sync.Mutex
+	pools   map[string]chan *pooledConn
+
+	scriptMu sync.Mutex
+	scripts  map[string]string // lua script source -> cached sha1 hex, see evalSha
 }
 
+// Config selects a Client's topology. Exactly one of ClusterAddrs or
+// SentinelAddrs+MasterName should be set for cluster/sentinel mode; leaving
+// both empty falls back to single mode against Addr. ClusterAddrs takes
+// precedence if both are set.
 type Config struct {
 	Addr     string
 	Password string
 	DB       int
 	PoolSize int
+
+	// IdleTimeout is how long a pooled connection may go unused before
+	// being health-checked (PING) before reuse. Defaults to 30s.
+	IdleTimeout time.Duration
+
+	// SentinelAddrs, with MasterName, puts the Client in sentinel mode: the
+	// current master is discovered via SENTINEL get-master-addr-by-name
+	// against these addresses, and re-resolved whenever a command fails
+	// with READONLY or MASTERDOWN (the errors Redis returns when talking to
+	// a demoted or not-yet-promoted node during a failover).
+	SentinelAddrs []string
+	MasterName    string
+
+	// ClusterAddrs puts the Client in cluster mode: the slot map is fetched
+	// via CLUSTER SLOTS against these addresses at startup, and every
+	// command is routed to the address owning CRC16(key) % 16384, honoring
+	// MOVED/ASK redirections by updating the slot map and retrying once.
+	ClusterAddrs []string
 }
 
 func New(cfg Config) (*Client, error) {
-	if cfg.Addr == "" {
-		cfg.Addr = "localhost:6379"
-	}
 	if cfg.PoolSize <= 0 {
 		cfg.PoolSize = 10
 	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 30 * time.Second
+	}
 
 	c := &Client{
-		addr:     cfg.Addr,
-		password: cfg.Password,
-		db:       cfg.DB,
-		pool:     make(chan net.Conn, cfg.PoolSize),
+		password:    cfg.Password,
+		db:          cfg.DB,
+		poolSize:    cfg.PoolSize,
+		idleTimeout: cfg.IdleTimeout,
+		pools:       make(map[string]chan *pooledConn),
 	}
 
-	// Validate connectivity.
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+
+	switch {
+	case len(cfg.ClusterAddrs) > 0:
+		c.mode = modeCluster
+		c.clusterAddrs = cfg.ClusterAddrs
+		if err := c.refreshClusterSlots(ctx); err != nil {
+			return nil, err
+		}
+	case len(cfg.SentinelAddrs) > 0:
+		if cfg.MasterName == "" {
+			return nil, errors.New("redis: MasterName is required when SentinelAddrs is set")
+		}
+		c.mode = modeSentinel
+		c.sentinelAddrs = cfg.SentinelAddrs
+		c.masterName = cfg.MasterName
+		addr, err := c.resolveMasterViaSentinel(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.addr = addr
+	default:
+		c.mode = modeSingle
+		c.addr = cfg.Addr
+		if c.addr == "" {
+			c.addr = "localhost:6379"
+		}
+	}
+
+	// Validate connectivity.
 	if err := c.Ping(ctx); err != nil {
 		return nil, err
 	}
@@ -54,15 +152,22 @@ func New(cfg Config) (*Client, error) {
 }
 
 func (c *Client) Close() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.poolsMu.Lock()
+	defer c.poolsMu.Unlock()
 
+	for _, pool := range c.pools {
+		drainPool(pool)
+	}
+	return nil
+}
+
+func drainPool(pool chan *pooledConn) {
 	for {
 		select {
-		case conn := <-c.pool:
-			_ = conn.Close()
+		case pc := <-pool:
+			_ = pc.conn.Close()
 		default:
-			return nil
+			return
 		}
 	}
 }
@@ -89,6 +194,52 @@ func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
 	return resp.num, nil
 }
 
+// EvalInt runs a Lua script via EVAL and returns its reply as an integer.
+// It is intentionally narrow: every limiter script in this package is
+// written to return a single integer (a count, a remaining value, or -1),
+// which keeps the hand-rolled RESP client above from needing array support.
+func (c *Client) EvalInt(ctx context.Context, script string, keys []string, args []string) (int64, error) {
+	cmd := make([]string, 0, 3+len(keys)+len(args))
+	cmd = append(cmd, "EVAL", script, strconv.Itoa(len(keys)))
+	cmd = append(cmd, keys...)
+	cmd = append(cmd, args...)
+
+	resp, err := c.do(ctx, cmd...)
+	if err != nil {
+		return 0, err
+	}
+	if resp.typ != respInteger {
+		return 0, fmt.Errorf("unexpected EVAL response: %s", resp.String())
+	}
+	return resp.num, nil
+}
+
+// SetNX sets key to value with a millisecond TTL only if key does not
+// already exist, returning whether the set happened. It is the primitive
+// behind Redis-based leader election leases.
+func (c *Client) SetNX(ctx context.Context, key, value string, ttlMillis int64) (bool, error) {
+	if ttlMillis <= 0 {
+		ttlMillis = 1000
+	}
+	resp, err := c.do(ctx, "SET", key, value, "NX", "PX", strconv.FormatInt(ttlMillis, 10))
+	if err != nil {
+		return false, err
+	}
+	return !resp.null, nil
+}
+
+// Del deletes key and reports whether it existed.
+func (c *Client) Del(ctx context.Context, key string) (bool, error) {
+	resp, err := c.do(ctx, "DEL", key)
+	if err != nil {
+		return false, err
+	}
+	if resp.typ != respInteger {
+		return false, fmt.Errorf("unexpected DEL response: %s", resp.String())
+	}
+	return resp.num > 0, nil
+}
+
 func (c *Client) ExpireSeconds(ctx context.Context, key string, ttlSeconds int64) error {
 	if ttlSeconds <= 0 {
 		ttlSeconds = 60
@@ -104,50 +255,91 @@ func (c *Client) ExpireSeconds(ctx context.Context, key string, ttlSeconds int64
 	return nil
 }
 
-func (c *Client) getConn(ctx context.Context) (net.Conn, *bufio.ReadWriter, func(error), error) {
+// getConn returns a connection to addr, either a pooled one (health-checked
+// with a PING first if it's been idle longer than idleTimeout) or a freshly
+// dialed one.
+func (c *Client) getConn(ctx context.Context, addr string) (net.Conn, *bufio.ReadWriter, func(error), error) {
+	pool := c.poolFor(addr)
+
 	select {
-	case conn := <-c.pool:
-		rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
-		putBack := func(err error) {
-			if err != nil {
-				_ = conn.Close()
-				return
-			}
-			select {
-			case c.pool <- conn:
-			default:
-				_ = conn.Close()
+	case pc := <-pool:
+		if c.idleTimeout > 0 && time.Since(pc.lastUsed) > c.idleTimeout {
+			if err := c.healthCheck(pc.conn); err != nil {
+				_ = pc.conn.Close()
+				return c.dialAndInit(ctx, addr, pool)
 			}
 		}
-		return conn, rw, putBack, nil
+		rw := bufio.NewReadWriter(bufio.NewReader(pc.conn), bufio.NewWriter(pc.conn))
+		return pc.conn, rw, c.putBackFunc(pool, pc), nil
 	default:
-		// Create a new connection.
-		d := net.Dialer{Timeout: 1 * time.Second}
-		conn, err := d.DialContext(ctx, "tcp", c.addr)
-		if err != nil {
-			return nil, nil, nil, err
-		}
+		return c.dialAndInit(ctx, addr, pool)
+	}
+}
 
-		rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
-		if err := c.initConn(ctx, conn, rw); err != nil {
-			_ = conn.Close()
-			return nil, nil, nil, err
-		}
+func (c *Client) poolFor(addr string) chan *pooledConn {
+	c.poolsMu.Lock()
+	defer c.poolsMu.Unlock()
 
-		putBack := func(err error) {
-			if err != nil {
-				_ = conn.Close()
-				return
-			}
-			select {
-			case c.pool <- conn:
-			default:
-				_ = conn.Close()
-			}
+	pool, ok := c.pools[addr]
+	if !ok {
+		pool = make(chan *pooledConn, c.poolSize)
+		c.pools[addr] = pool
+	}
+	return pool
+}
+
+func (c *Client) dialAndInit(ctx context.Context, addr string, pool chan *pooledConn) (net.Conn, *bufio.ReadWriter, func(error), error) {
+	d := net.Dialer{Timeout: 1 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	if err := c.initConn(ctx, conn, rw); err != nil {
+		_ = conn.Close()
+		return nil, nil, nil, err
+	}
+
+	pc := &pooledConn{conn: conn, lastUsed: time.Now()}
+	return conn, rw, c.putBackFunc(pool, pc), nil
+}
+
+func (c *Client) putBackFunc(pool chan *pooledConn, pc *pooledConn) func(error) {
+	return func(err error) {
+		if err != nil {
+			_ = pc.conn.Close()
+			return
+		}
+		pc.lastUsed = time.Now()
+		select {
+		case pool <- pc:
+		default:
+			_ = pc.conn.Close()
 		}
+	}
+}
 
-		return conn, rw, putBack, nil
+// healthCheck sends a PING on conn and expects a PONG back, used to
+// cheaply revalidate a connection that has been idle in the pool longer
+// than idleTimeout before it's handed to a caller.
+func (c *Client) healthCheck(conn net.Conn) error {
+	_ = conn.SetDeadline(time.Now().Add(500 * time.Millisecond))
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	if err := writeArray(rw.Writer, "PING"); err != nil {
+		return err
+	}
+	if err := rw.Flush(); err != nil {
+		return err
+	}
+	r, err := readResp(rw.Reader)
+	if err != nil {
+		return err
+	}
+	if r.typ != respSimpleString || r.str != "PONG" {
+		return fmt.Errorf("redis: unexpected health check response: %s", r.String())
 	}
+	return nil
 }
 
 func (c *Client) initConn(ctx context.Context, conn net.Conn, rw *bufio.ReadWriter) error {
@@ -192,12 +384,48 @@ func (c *Client) initConn(ctx context.Context, conn net.Conn, rw *bufio.ReadWrit
 	return nil
 }
 
+// do resolves the backend address for args (by topology mode and, in
+// cluster mode, command key) and runs it, retrying once if the response
+// tells it to go somewhere else: a MOVED/ASK redirect updates the slot map
+// and retries against the new address, and a READONLY/MASTERDOWN error in
+// sentinel mode (the server talking to a demoted or not-yet-promoted node
+// mid-failover) re-resolves the current master and retries against it.
 func (c *Client) do(ctx context.Context, args ...string) (resp, error) {
 	if len(args) == 0 {
 		return resp{}, errors.New("redis: empty command")
 	}
 
-	conn, rw, putBack, err := c.getConn(ctx)
+	addr, err := c.targetAddr(ctx, commandKey(args))
+	if err != nil {
+		return resp{}, err
+	}
+
+	r, err := c.doAt(ctx, addr, args)
+	if err == nil {
+		return r, nil
+	}
+
+	if rd, ok := parseRedirect(err); ok {
+		c.updateSlot(rd.slot, rd.addr)
+		return c.doAt(ctx, rd.addr, args)
+	}
+
+	if c.mode == modeSentinel && isFailoverErr(err) {
+		if newAddr, resolveErr := c.resolveMasterViaSentinel(ctx); resolveErr == nil {
+			c.topoMu.Lock()
+			c.addr = newAddr
+			c.topoMu.Unlock()
+			return c.doAt(ctx, newAddr, args)
+		}
+	}
+
+	return resp{}, err
+}
+
+// doAt runs one command against addr's pool, with no redirect/failover
+// handling - do is responsible for that.
+func (c *Client) doAt(ctx context.Context, addr string, args []string) (resp, error) {
+	conn, rw, putBack, err := c.getConn(ctx, addr)
 	if err != nil {
 		return resp{}, err
 	}
@@ -233,6 +461,117 @@ func (c *Client) do(ctx context.Context, args ...string) (resp, error) {
 	return r, nil
 }
 
+// targetAddr resolves which backend address args should be sent to: the
+// single fixed/sentinel-resolved address in single/sentinel mode, or the
+// owner of key's hash slot in cluster mode (fetching the slot map on first
+// use if it isn't loaded yet).
+func (c *Client) targetAddr(ctx context.Context, key string) (string, error) {
+	if c.mode != modeCluster {
+		c.topoMu.RLock()
+		addr := c.addr
+		c.topoMu.RUnlock()
+		return addr, nil
+	}
+
+	c.topoMu.RLock()
+	slots := c.slots
+	c.topoMu.RUnlock()
+
+	if len(slots) == 0 {
+		if err := c.refreshClusterSlots(ctx); err != nil {
+			return "", err
+		}
+		c.topoMu.RLock()
+		slots = c.slots
+		c.topoMu.RUnlock()
+	}
+
+	slot := hashSlot(key)
+	addr := addrForSlot(slots, slot)
+	if addr == "" {
+		return "", fmt.Errorf("redis: no cluster node owns slot %d", slot)
+	}
+	return addr, nil
+}
+
+// updateSlot records that slot is now (or, for ASK, temporarily) served by
+// addr, so the next command for the same slot goes straight there instead
+// of hitting the stale owner again.
+func (c *Client) updateSlot(slot int, addr string) {
+	c.topoMu.Lock()
+	defer c.topoMu.Unlock()
+
+	for i := range c.slots {
+		if slot >= c.slots[i].start && slot <= c.slots[i].end {
+			c.slots[i].addr = addr
+			return
+		}
+	}
+	c.slots = append(c.slots, slotRange{start: slot, end: slot, addr: addr})
+}
+
+// commandKey returns the key args routes by, for cluster slot hashing.
+// EVAL/EVALSHA carry their key count before their keys (EVAL script numkeys
+// key...), so the key is at a different position than every other command
+// this client sends, which is always COMMAND key ...
+func commandKey(args []string) string {
+	if len(args) < 2 {
+		return ""
+	}
+	switch strings.ToUpper(args[0]) {
+	case "EVAL", "EVALSHA":
+		if len(args) < 4 {
+			return ""
+		}
+		numKeys, err := strconv.Atoi(args[2])
+		if err != nil || numKeys < 1 {
+			return ""
+		}
+		return args[3]
+	default:
+		return args[1]
+	}
+}
+
+// redirect is a parsed MOVED/ASK error.
+type redirect struct {
+	slot int
+	addr string
+}
+
+// parseRedirect parses a MOVED or ASK error reply, e.g. "MOVED 3999
+// 127.0.0.1:6381". Redis Cluster returns MOVED for a slot permanently
+// reassigned (resharding finished) and ASK for one mid-migration; this
+// client treats both the same way - update the slot map and retry once -
+// since it never needs to send the ASKING preamble ASK strictly calls for
+// (it has no multi-command transactions that would need it to stick).
+func parseRedirect(err error) (redirect, bool) {
+	if err == nil {
+		return redirect{}, false
+	}
+	fields := strings.Fields(err.Error())
+	if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+		return redirect{}, false
+	}
+	slot, parseErr := strconv.Atoi(fields[1])
+	if parseErr != nil {
+		return redirect{}, false
+	}
+	return redirect{slot: slot, addr: fields[2]}, true
+}
+
+// isFailoverErr reports whether err is a READONLY or MASTERDOWN reply -
+// what a sentinel-managed node returns while a failover is in progress
+// (talking to the old master after it's been demoted, or to a replica not
+// yet promoted).
+func isFailoverErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.HasPrefix(msg, "READONLY") || strings.HasPrefix(msg, "MASTERDOWN")
+}
+
 func writeArray(w *bufio.Writer, args ...string) error {
 	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
 		return err
@@ -252,13 +591,16 @@ const (
 	respError        respType = '-'
 	respInteger      respType = ':'
 	respBulkString   respType = '$'
+	respArray        respType = '*'
 )
 
 type resp struct {
-	typ respType
-	str string
-	num int64
-	err error
+	typ  respType
+	str  string
+	num  int64
+	err  error
+	arr  []resp // populated for respArray, e.g. a Lua script returning a table
+	null bool   // true for a nil bulk string ($-1) or array ($-1), e.g. SET ... NX that did not set
 }
 
 func (r resp) String() string {
@@ -269,6 +611,8 @@ func (r resp) String() string {
 		return ":" + strconv.FormatInt(r.num, 10)
 	case respBulkString:
 		return "$" + r.str
+	case respArray:
+		return fmt.Sprintf("*%d", len(r.arr))
 	case respError:
 		if r.err != nil {
 			return "-" + r.err.Error()
@@ -330,7 +674,7 @@ func readResp(rd *bufio.Reader) (resp, error) {
 			return resp{}, err
 		}
 		if n == -1 {
-			return resp{typ: respBulkString, str: ""}, nil
+			return resp{typ: respBulkString, null: true}, nil
 		}
 		buf := make([]byte, n+2) // includes \r\n
 		if _, err := io.ReadFull(rd, buf); err != nil {
@@ -340,8 +684,28 @@ func readResp(rd *bufio.Reader) (resp, error) {
 			return resp{}, errors.New("redis: invalid bulk string ending")
 		}
 		return resp{typ: respBulkString, str: string(buf[:len(buf)-2])}, nil
+	case respArray:
+		s, err := readLine(rd)
+		if err != nil {
+			return resp{}, err
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return resp{}, err
+		}
+		if n == -1 {
+			return resp{typ: respArray, null: true}, nil
+		}
+		items := make([]resp, n)
+		for i := range items {
+			item, err := readResp(rd)
+			if err != nil {
+				return resp{}, err
+			}
+			items[i] = item
+		}
+		return resp{typ: respArray, arr: items}, nil
 	default:
 		return resp{}, fmt.Errorf("redis: unsupported response type %q", b)
 	}
 }
-
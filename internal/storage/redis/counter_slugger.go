@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+)
+
+// SluggerStrategyConfig selects and parameterizes which links.Slugger
+// NewSlugger builds.
+type SluggerStrategyConfig struct {
+	Strategy   string // "crypto", "counter", or "sqids"
+	CounterKey string
+	SqidsSalt  string
+}
+
+// NewSlugger builds the links.Slugger selected by cfg.Strategy. "crypto"
+// needs no Redis client; "counter" and "sqids" use client to atomically
+// increment a shared counter.
+func NewSlugger(client *Client, cfg SluggerStrategyConfig) (links.Slugger, error) {
+	switch cfg.Strategy {
+	case "", "crypto":
+		return links.NewCryptoSlugger(), nil
+	case "counter":
+		return NewCounterSlugger(client, cfg.CounterKey), nil
+	case "sqids":
+		return NewSqidsSlugger(client, cfg.CounterKey, cfg.SqidsSalt), nil
+	default:
+		return nil, fmt.Errorf("redis: unknown slugger strategy %q", cfg.Strategy)
+	}
+}
+
+const counterSlugAlphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// CounterSlugger generates short, gap-free slugs by atomically incrementing
+// a single Redis counter and encoding the result in base62. Unlike
+// CryptoSlugger it can never collide, so Service's retry loop is a no-op
+// for this slugger, at the cost of slugs being sequentially guessable.
+type CounterSlugger struct {
+	client *Client
+	key    string
+}
+
+func NewCounterSlugger(client *Client, key string) *CounterSlugger {
+	if key == "" {
+		key = "slug:counter"
+	}
+	return &CounterSlugger{client: client, key: key}
+}
+
+func (s *CounterSlugger) Generate(length int) (string, error) {
+	return s.GenerateWithContext(context.Background(), links.SlugHint{Length: length})
+}
+
+func (s *CounterSlugger) GenerateWithContext(ctx context.Context, hint links.SlugHint) (string, error) {
+	n, err := s.client.Incr(ctx, s.key)
+	if err != nil {
+		return "", err
+	}
+	return encodeBase62(uint64(n), counterSlugAlphabet, hint.Length), nil
+}
+
+func encodeBase62(n uint64, alphabet string, minLength int) string {
+	base := uint64(len(alphabet))
+
+	digits := make([]byte, 0, 11)
+	if n == 0 {
+		digits = append(digits, alphabet[0])
+	}
+	for n > 0 {
+		digits = append(digits, alphabet[n%base])
+		n /= base
+	}
+
+	out := make([]byte, len(digits))
+	for i, d := range digits {
+		out[len(digits)-1-i] = d
+	}
+
+	if minLength > len(out) {
+		return strings.Repeat(string(alphabet[0]), minLength-len(out)) + string(out)
+	}
+	return string(out)
+}
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// scriptSha returns script's SHA1 hex digest, computing and caching it on
+// first use per Client. The digest is exactly what Redis computes itself
+// for SCRIPT LOAD/EVALSHA, so it can be derived client-side without a round
+// trip - caching it just avoids re-hashing the same script text on every
+// call.
+func (c *Client) scriptSha(script string) string {
+	c.scriptMu.Lock()
+	defer c.scriptMu.Unlock()
+
+	if c.scripts == nil {
+		c.scripts = make(map[string]string)
+	}
+	if sha, ok := c.scripts[script]; ok {
+		return sha
+	}
+
+	sum := sha1.Sum([]byte(script))
+	sha := hex.EncodeToString(sum[:])
+	c.scripts[script] = sha
+	return sha
+}
+
+// evalSha runs script via EVALSHA, falling back to a full EVAL (which
+// primes the server's script cache) on a NOSCRIPT miss - the first call for
+// a given script on this connection's server, or any time the server's
+// script cache was dropped (restart, SCRIPT FLUSH). Callers don't need to
+// SCRIPT LOAD anything up front.
+func (c *Client) evalSha(ctx context.Context, script string, keys []string, args []string) (resp, error) {
+	sha := c.scriptSha(script)
+
+	r, err := c.do(ctx, evalShaCommand(sha, keys, args)...)
+	if err == nil {
+		return r, nil
+	}
+	if !isNoScriptErr(err) {
+		return resp{}, err
+	}
+
+	return c.do(ctx, evalCommand(script, keys, args)...)
+}
+
+func evalCommand(script string, keys []string, args []string) []string {
+	cmd := make([]string, 0, 3+len(keys)+len(args))
+	cmd = append(cmd, "EVAL", script, strconv.Itoa(len(keys)))
+	cmd = append(cmd, keys...)
+	cmd = append(cmd, args...)
+	return cmd
+}
+
+func evalShaCommand(sha string, keys []string, args []string) []string {
+	cmd := make([]string, 0, 3+len(keys)+len(args))
+	cmd = append(cmd, "EVALSHA", sha, strconv.Itoa(len(keys)))
+	cmd = append(cmd, keys...)
+	cmd = append(cmd, args...)
+	return cmd
+}
+
+func isNoScriptErr(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT")
+}
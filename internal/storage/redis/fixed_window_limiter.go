@@ -28,6 +28,13 @@ func NewFixedWindowLimiter(client *Client, prefix string, window time.Duration)
 	}
 }
 
+// Window returns the limiter's fixed window length, so a caller building a
+// LimitDecision from Incr's raw count (see middleware.RedisFixedWindowLimiter)
+// can report a RetryAfter without duplicating the window value.
+func (l *FixedWindowLimiter) Window() time.Duration {
+	return l.window
+}
+
 // Incr increments the counter for (key, current window) and returns the current count.
 func (l *FixedWindowLimiter) Incr(ctx context.Context, key string) (int64, error) {
 	if key == "" {
@@ -54,3 +61,39 @@ func (l *FixedWindowLimiter) Incr(ctx context.Context, key string) (int64, error
 	return count, nil
 }
 
+// FixedWindowAdapter pairs a FixedWindowLimiter with a request limit so it
+// can be used anywhere the Limiter interface is expected, alongside the
+// sliding-window and token-bucket strategies.
+type FixedWindowAdapter struct {
+	store *FixedWindowLimiter
+	limit int64
+}
+
+func NewFixedWindowAdapter(store *FixedWindowLimiter, limit int64) *FixedWindowAdapter {
+	if limit <= 0 {
+		limit = 60
+	}
+	return &FixedWindowAdapter{store: store, limit: limit}
+}
+
+func (a *FixedWindowAdapter) Allow(ctx context.Context, key string) (LimitDecision, error) {
+	count, err := a.store.Incr(ctx, key)
+	if err != nil {
+		return LimitDecision{}, err
+	}
+
+	if count > a.limit {
+		return LimitDecision{
+			Allowed:    false,
+			Limit:      a.limit,
+			Remaining:  0,
+			RetryAfter: int64(a.store.window.Seconds()),
+		}, nil
+	}
+
+	return LimitDecision{
+		Allowed:   true,
+		Limit:     a.limit,
+		Remaining: a.limit - count,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// renewLeaseScript extends the TTL only if this owner still holds the lease,
+// preventing a slow owner from renewing a lease another replica has since
+// acquired after the original one expired.
+const renewLeaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`
+
+// releaseLeaseScript deletes the key only if this owner still holds it.
+const releaseLeaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+return 0
+`
+
+// LeaderLease is a simple Redis-backed mutual-exclusion lease: the first
+// replica to SET NX the key becomes leader until it stops renewing, letting
+// background dispatchers run on exactly one replica at a time without a
+// dedicated coordination service.
+type LeaderLease struct {
+	client *Client
+	key    string
+	owner  string
+	ttl    time.Duration
+
+	isLeader bool
+}
+
+func NewLeaderLease(client *Client, key string, ttl time.Duration) *LeaderLease {
+	if ttl <= 0 {
+		ttl = 10 * time.Second
+	}
+	return &LeaderLease{
+		client: client,
+		key:    key,
+		owner:  randomOwnerID(),
+		ttl:    ttl,
+	}
+}
+
+// TryAcquireOrRenew attempts to become (or remain) leader and reports the
+// current leadership state. Callers should invoke it on every dispatcher
+// tick: it both acquires an unheld lease and renews one already held.
+func (l *LeaderLease) TryAcquireOrRenew(ctx context.Context) (bool, error) {
+	if l.isLeader {
+		renewed, err := l.client.EvalInt(ctx, renewLeaseScript, []string{l.key}, []string{l.owner, ttlMillisString(l.ttl)})
+		if err != nil {
+			l.isLeader = false
+			return false, err
+		}
+		l.isLeader = renewed == 1
+		return l.isLeader, nil
+	}
+
+	acquired, err := l.client.SetNX(ctx, l.key, l.owner, l.ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	l.isLeader = acquired
+	return acquired, nil
+}
+
+// Release gives up leadership immediately, if still held, so another
+// replica can take over without waiting out the full TTL.
+func (l *LeaderLease) Release(ctx context.Context) error {
+	if !l.isLeader {
+		return nil
+	}
+	l.isLeader = false
+	_, err := l.client.EvalInt(ctx, releaseLeaseScript, []string{l.key}, []string{l.owner})
+	return err
+}
+
+func ttlMillisString(d time.Duration) string {
+	millis := d.Milliseconds()
+	if millis <= 0 {
+		millis = 1000
+	}
+	return strconv.FormatInt(millis, 10)
+}
+
+func randomOwnerID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return hex.EncodeToString([]byte(time.Now().String()))
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LimitDecision describes the outcome of a single rate-limit check, enough
+// for callers to compute the standard X-RateLimit-* response headers.
+type LimitDecision struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	RetryAfter int64 // seconds, only meaningful when Allowed is false
+}
+
+// Limiter is implemented by every rate-limiting strategy (fixed window,
+// sliding window, token bucket, ...) so the HTTP middleware can be built
+// against a single abstraction regardless of which one is configured.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (LimitDecision, error)
+}
+
+// LimiterStrategyConfig carries the strategy-agnostic parameters needed to
+// build any of the Limiter implementations in this package. It mirrors
+// config.RateLimitConfig without importing the config package, keeping the
+// storage layer free of higher-level dependencies (see db.ConnectMongo).
+type LimiterStrategyConfig struct {
+	Strategy          string // "fixed", "sliding", or "token_bucket"
+	RequestsPerWindow int
+	Window            time.Duration
+	BucketCapacity    int64
+	RefillPerSecond   float64
+}
+
+// NewLimiter builds the Limiter selected by cfg.Strategy, keyed under prefix.
+func NewLimiter(client *Client, prefix string, cfg LimiterStrategyConfig) (Limiter, error) {
+	switch cfg.Strategy {
+	case "", "fixed":
+		store := NewFixedWindowLimiter(client, prefix, cfg.Window)
+		return NewFixedWindowAdapter(store, int64(cfg.RequestsPerWindow)), nil
+	case "sliding":
+		return NewSlidingWindowLimiter(client, prefix, cfg.Window, int64(cfg.RequestsPerWindow)), nil
+	case "token_bucket":
+		return NewTokenBucketLimiter(client, prefix, cfg.BucketCapacity, cfg.RefillPerSecond), nil
+	default:
+		return nil, fmt.Errorf("redis: unknown rate limit strategy %q", cfg.Strategy)
+	}
+}
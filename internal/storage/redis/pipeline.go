@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+// Pipeline batches several commands onto one connection and flushes them in
+// a single write, then reads back one response per queued command in
+// order - do's one-command-per-round-trip costs an RTT for every call, which
+// adds up for call sites like FixedWindowLimiter's separate INCR and EXPIRE.
+// Pipelining doesn't make the batch atomic against other clients (that needs
+// a Lua script, see evalSha) - it only removes the extra round trips.
+type Pipeline struct {
+	client *Client
+	cmds   [][]string
+}
+
+// Pipeline starts a new batch of commands against c.
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{client: c}
+}
+
+// Queue appends a command to the batch. It isn't sent until Exec.
+func (p *Pipeline) Queue(args ...string) {
+	p.cmds = append(p.cmds, args)
+}
+
+// Exec sends every queued command in one write/flush and returns one
+// response (and one error) per command, in queue order. A RESP-level error
+// reply (e.g. a WRONGTYPE on one command) is reported only in that
+// command's slot - it doesn't stop the remaining responses from being
+// read, since Redis already executed every queued command by the time any
+// of their replies come back. A connection-level failure (a read/write
+// error, not a RESP error reply) aborts the read loop and fills every
+// remaining slot with that error, since the connection can no longer be
+// trusted to be in sync with the commands it did or didn't process.
+func (p *Pipeline) Exec(ctx context.Context) ([]resp, []error) {
+	n := len(p.cmds)
+	resps := make([]resp, n)
+	errs := make([]error, n)
+	if n == 0 {
+		return resps, errs
+	}
+
+	// A pipeline is one connection, so in cluster mode every queued command
+	// must hash to the same slot - routing is resolved from the first
+	// command's key and reused for the whole batch, same as real Redis
+	// Cluster clients require for MULTI/EXEC and pipelines.
+	addr, err := p.client.targetAddr(ctx, commandKey(p.cmds[0]))
+	if err != nil {
+		fillErr(errs, err)
+		return resps, errs
+	}
+
+	conn, rw, putBack, err := p.client.getConn(ctx, addr)
+	if err != nil {
+		fillErr(errs, err)
+		return resps, errs
+	}
+
+	var opErr error
+	defer func() { putBack(opErr) }()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	}
+
+	for _, args := range p.cmds {
+		if err := writeArray(rw.Writer, args...); err != nil {
+			opErr = err
+			fillErr(errs, err)
+			return resps, errs
+		}
+	}
+	if err := rw.Flush(); err != nil {
+		opErr = err
+		fillErr(errs, err)
+		return resps, errs
+	}
+
+	for i := 0; i < n; i++ {
+		r, err := readResp(rw.Reader)
+		if err != nil {
+			opErr = err
+			for j := i; j < n; j++ {
+				errs[j] = err
+			}
+			return resps, errs
+		}
+		if r.typ == respError {
+			errs[i] = r.err
+			continue
+		}
+		resps[i] = r
+	}
+
+	return resps, errs
+}
+
+func fillErr(errs []error, err error) {
+	for i := range errs {
+		errs[i] = err
+	}
+}
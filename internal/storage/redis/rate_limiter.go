@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// rateLimiterScript is a sliding-window limiter like slidingWindowScript,
+// but trades the trim+count+record-in-EVAL pattern for EVALSHA with a
+// NOSCRIPT fallback (see evalSha) and returns a 3-element table instead of
+// a single integer, so one round trip carries whether the request was
+// allowed, how many requests remain, and when the window resets - enough
+// for a caller to fill in X-RateLimit-* headers without a second call.
+const rateLimiterScript = `
+local bucket = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', bucket, '-inf', now - window)
+local count = redis.call('ZCARD', bucket)
+local resetAt = now + window
+
+if count < limit then
+	redis.call('ZADD', bucket, now, now)
+	redis.call('PEXPIRE', bucket, window)
+	return {1, limit - count - 1, resetAt}
+end
+return {0, 0, resetAt}
+`
+
+// RateLimiter is SlidingWindowLimiter's EVALSHA-backed counterpart: same
+// sorted-set sliding window, but the script is cached and replayed via
+// evalSha rather than sent in full on every call, and its reply is decoded
+// from a RESP array instead of a single integer.
+type RateLimiter struct {
+	client *Client
+	prefix string
+	window time.Duration
+	limit  int64
+	now    func() time.Time
+}
+
+func NewRateLimiter(client *Client, prefix string, window time.Duration, limit int64) *RateLimiter {
+	if prefix == "" {
+		prefix = "rate:atomic"
+	}
+	if window <= 0 {
+		window = time.Minute
+	}
+	if limit <= 0 {
+		limit = 60
+	}
+	return &RateLimiter{
+		client: client,
+		prefix: prefix,
+		window: window,
+		limit:  limit,
+		now:    time.Now,
+	}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string) (LimitDecision, error) {
+	if key == "" {
+		key = "unknown"
+	}
+
+	now := l.now().UTC()
+	nowMillis := now.UnixMilli()
+	windowMillis := l.window.Milliseconds()
+	redisKey := fmt.Sprintf("%s:%s", l.prefix, key)
+
+	r, err := l.client.evalSha(ctx, rateLimiterScript,
+		[]string{redisKey},
+		[]string{strconv.FormatInt(nowMillis, 10), strconv.FormatInt(windowMillis, 10), strconv.FormatInt(l.limit, 10)},
+	)
+	if err != nil {
+		return LimitDecision{}, err
+	}
+	if r.typ != respArray || len(r.arr) != 3 {
+		return LimitDecision{}, fmt.Errorf("redis: unexpected rate limiter script response: %s", r.String())
+	}
+
+	allowed := r.arr[0].num == 1
+	remaining := r.arr[1].num
+	resetMillis := r.arr[2].num
+
+	if !allowed {
+		retryAfter := (resetMillis - nowMillis) / 1000
+		if retryAfter < 0 {
+			retryAfter = 0
+		}
+		return LimitDecision{
+			Allowed:    false,
+			Limit:      l.limit,
+			Remaining:  0,
+			RetryAfter: retryAfter,
+		}, nil
+	}
+
+	return LimitDecision{
+		Allowed:   true,
+		Limit:     l.limit,
+		Remaining: remaining,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+)
+
+// SqidsSlugger encodes the same monotonic Redis counter as CounterSlugger,
+// but through an alphabet permuted from a per-deployment salt, so slugs
+// don't look sequential to an outside observer while still decoding back
+// to the counter in O(1) for lookups keyed by it.
+type SqidsSlugger struct {
+	client   *Client
+	key      string
+	alphabet string
+	indexOf  map[byte]int
+}
+
+func NewSqidsSlugger(client *Client, key, salt string) *SqidsSlugger {
+	if key == "" {
+		key = "slug:counter"
+	}
+
+	alphabet := shuffleAlphabet(counterSlugAlphabet, salt)
+	indexOf := make(map[byte]int, len(alphabet))
+	for i := 0; i < len(alphabet); i++ {
+		indexOf[alphabet[i]] = i
+	}
+
+	return &SqidsSlugger{client: client, key: key, alphabet: alphabet, indexOf: indexOf}
+}
+
+func (s *SqidsSlugger) Generate(length int) (string, error) {
+	return s.GenerateWithContext(context.Background(), links.SlugHint{Length: length})
+}
+
+func (s *SqidsSlugger) GenerateWithContext(ctx context.Context, hint links.SlugHint) (string, error) {
+	n, err := s.client.Incr(ctx, s.key)
+	if err != nil {
+		return "", err
+	}
+	return encodeBase62(uint64(n), s.alphabet, hint.Length), nil
+}
+
+// Decode reverses Generate/GenerateWithContext, returning the counter value
+// a slug this encoder produced was built from.
+func (s *SqidsSlugger) Decode(slug string) (uint64, error) {
+	base := uint64(len(s.alphabet))
+	var n uint64
+	for i := 0; i < len(slug); i++ {
+		idx, ok := s.indexOf[slug[i]]
+		if !ok {
+			return 0, errors.New("redis: slug contains a character outside this deployment's alphabet")
+		}
+		n = n*base + uint64(idx)
+	}
+	return n, nil
+}
+
+// shuffleAlphabet deterministically permutes alphabet, seeded from salt, so
+// two deployments with different salts encode the same counter value as
+// different-looking slugs without needing any shared external state.
+func shuffleAlphabet(alphabet, salt string) string {
+	chars := []byte(alphabet)
+	seed := fnv1aSeed(salt)
+	for i := len(chars) - 1; i > 0; i-- {
+		seed = seed*6364136223846793005 + 1442695040888963407 // LCG step
+		j := int(seed % uint64(i+1))
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars)
+}
+
+func fnv1aSeed(s string) uint64 {
+	var h uint64 = 14695981039346656037
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	if h == 0 {
+		h = 1 // never seed the LCG with zero, which would be a fixed point
+	}
+	return h
+}
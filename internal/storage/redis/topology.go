@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// clusterSlotCount is the fixed number of hash slots a Redis Cluster
+// partitions its keyspace into (CLUSTER_SLOTS in redis.conf terms - this is
+// a protocol constant, not configurable).
+const clusterSlotCount = 16384
+
+// slotRange is one contiguous range of hash slots and the address of the
+// node currently serving it, as reported by CLUSTER SLOTS (or narrowed to a
+// single slot by a MOVED/ASK redirect - see Client.updateSlot).
+type slotRange struct {
+	start int
+	end   int
+	addr  string
+}
+
+// hashSlot returns the cluster hash slot for key: CRC16(key) mod 16384,
+// honoring a {hashtag} substring if key has one (so multi-key operations on
+// keys sharing a tag land in the same slot, same as real Redis Cluster
+// clients).
+func hashSlot(key string) int {
+	hashKey := key
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			hashKey = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16([]byte(hashKey)) % clusterSlotCount)
+}
+
+// crc16 is CRC-16/XMODEM (poly 0x1021, init 0x0000, no input/output
+// reflection), the variant Redis Cluster uses for slot hashing.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = (crc << 1) ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func addrForSlot(slots []slotRange, slot int) string {
+	for _, r := range slots {
+		if slot >= r.start && slot <= r.end {
+			return r.addr
+		}
+	}
+	return ""
+}
+
+// refreshClusterSlots fetches the current slot map via CLUSTER SLOTS,
+// trying each configured cluster address in turn until one answers.
+func (c *Client) refreshClusterSlots(ctx context.Context) error {
+	var lastErr error
+	for _, addr := range c.clusterAddrs {
+		slots, err := c.fetchClusterSlots(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		c.topoMu.Lock()
+		c.slots = slots
+		c.topoMu.Unlock()
+		return nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no cluster addresses configured")
+	}
+	return fmt.Errorf("redis: failed to fetch cluster slots: %w", lastErr)
+}
+
+func (c *Client) fetchClusterSlots(ctx context.Context, addr string) ([]slotRange, error) {
+	d := net.Dialer{Timeout: 2 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	if err := c.initConn(ctx, conn, rw); err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if err := writeArray(rw.Writer, "CLUSTER", "SLOTS"); err != nil {
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		return nil, err
+	}
+
+	r, err := readResp(rw.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if r.typ == respError {
+		return nil, r.err
+	}
+	if r.typ != respArray {
+		return nil, fmt.Errorf("redis: unexpected CLUSTER SLOTS response: %s", r.String())
+	}
+
+	slots := make([]slotRange, 0, len(r.arr))
+	for _, entry := range r.arr {
+		if entry.typ != respArray || len(entry.arr) < 3 {
+			continue
+		}
+		master := entry.arr[2]
+		if master.typ != respArray || len(master.arr) < 2 {
+			continue
+		}
+		slots = append(slots, slotRange{
+			start: int(entry.arr[0].num),
+			end:   int(entry.arr[1].num),
+			addr:  fmt.Sprintf("%s:%d", master.arr[0].str, master.arr[1].num),
+		})
+	}
+	return slots, nil
+}
+
+// resolveMasterViaSentinel asks each configured sentinel in turn for the
+// current address of masterName, returning the first answer. Sentinels are
+// a separate control plane from the data nodes they monitor, so this talks
+// to them directly rather than through initConn/getConn's pooled data-node
+// connections.
+func (c *Client) resolveMasterViaSentinel(ctx context.Context) (string, error) {
+	var lastErr error
+	for _, addr := range c.sentinelAddrs {
+		resolved, err := c.queryMasterAddr(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return resolved, nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no sentinel addresses configured")
+	}
+	return "", fmt.Errorf("redis: failed to resolve master via sentinel: %w", lastErr)
+}
+
+func (c *Client) queryMasterAddr(ctx context.Context, sentinelAddr string) (string, error) {
+	d := net.Dialer{Timeout: 2 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", sentinelAddr)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = conn.Close() }()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if err := writeArray(rw.Writer, "SENTINEL", "get-master-addr-by-name", c.masterName); err != nil {
+		return "", err
+	}
+	if err := rw.Flush(); err != nil {
+		return "", err
+	}
+
+	r, err := readResp(rw.Reader)
+	if err != nil {
+		return "", err
+	}
+	if r.typ == respError {
+		return "", r.err
+	}
+	if r.typ != respArray || r.null || len(r.arr) != 2 {
+		return "", fmt.Errorf("redis: unexpected SENTINEL get-master-addr-by-name response: %s", r.String())
+	}
+
+	return fmt.Sprintf("%s:%s", r.arr[0].str, r.arr[1].str), nil
+}
@@ -0,0 +1,34 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const cursorPrefix = "dailycount:"
+
+// encodeCursor builds an opaque Relay cursor for the daily count at index i
+// within the requested date range, so clients never need to understand the
+// server's pagination bookkeeping.
+func encodeCursor(i int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(i)))
+}
+
+// decodeCursor reverses encodeCursor, returning the index it carried.
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %w", err)
+	}
+	s := string(raw)
+	if !strings.HasPrefix(s, cursorPrefix) {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	i, err := strconv.Atoi(strings.TrimPrefix(s, cursorPrefix))
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return i, nil
+}
@@ -0,0 +1,17 @@
+package graphql
+
+import (
+	"net/http"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// NewHandler parses schemaString against svc's Resolver and returns it as a
+// plain http.Handler, ready to mount behind the REST API's auth middleware
+// stack the same way linksHandler is.
+func NewHandler(svc *links.Service) http.Handler {
+	schema := graphqlgo.MustParseSchema(schemaString, NewResolver(svc))
+	return &relay.Handler{Schema: schema}
+}
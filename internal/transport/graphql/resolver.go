@@ -0,0 +1,252 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/constants"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links/auth"
+	"github.com/IgorGrieder/encurtador-url/internal/transport/http/middleware"
+)
+
+// Resolver backs the root Query/Mutation types directly with links.Service,
+// so GraphQL, REST, and gRPC all run the exact same business logic.
+type Resolver struct {
+	svc *links.Service
+}
+
+func NewResolver(svc *links.Service) *Resolver {
+	return &Resolver{svc: svc}
+}
+
+// apiError wraps a constants.APIError so graphql-go surfaces its code in
+// the response's "extensions" field, the same taxonomy REST uses in its
+// error body and gRPC maps to status codes.
+type apiError struct {
+	err constants.APIError
+}
+
+func (e apiError) Error() string { return e.err.Message }
+
+func (e apiError) Extensions() map[string]any {
+	return map[string]any{"code": e.err.Code}
+}
+
+func errorFor(err error) error {
+	switch {
+	case errors.Is(err, links.ErrNotFound):
+		return apiError{constants.ErrLinkNotFound}
+	case errors.Is(err, links.ErrExpired):
+		return apiError{constants.ErrLinkNotFound.WithMessage("link expired")}
+	case errors.Is(err, links.ErrInvalidURL):
+		return apiError{constants.ErrInvalidURL}
+	case errors.Is(err, links.ErrInvalidRange):
+		return apiError{constants.ErrInvalidRequestBody.WithMessage("from must be <= to")}
+	case errors.Is(err, links.ErrSlugTaken):
+		return apiError{constants.ErrInvalidRequestBody.WithMessage("could not allocate a slug, try again")}
+	case errors.Is(err, links.ErrForbidden):
+		return apiError{constants.ErrForbidden}
+	default:
+		return apiError{constants.ErrInternalError}
+	}
+}
+
+// claimsFromResolver adapts the AuthClaims requireAuth resolved (see
+// router.go's graphqlMiddlewares) to auth.Claims, the type GetStatsForClaims
+// enforces OwnerSubject against - so any authenticated caller can't read an
+// arbitrary slug's stats just by knowing it. A request with no resolved
+// claims yields the zero value, which authorizeOwner treats as nothing to
+// enforce.
+func claimsFromResolver(ctx context.Context) auth.Claims {
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		return auth.Claims{}
+	}
+	return auth.Claims{Subject: claims.Subject, Scopes: claims.Scopes}
+}
+
+type linkResolver struct {
+	link *links.Link
+}
+
+func (r *linkResolver) Slug() string { return r.link.Slug }
+func (r *linkResolver) URL() string  { return r.link.URL }
+func (r *linkResolver) Notes() string {
+	return r.link.Notes
+}
+func (r *linkResolver) CreatedAt() string { return r.link.CreatedAt.Format(time.RFC3339) }
+func (r *linkResolver) ExpiresAt() *string {
+	if r.link.ExpiresAt == nil {
+		return nil
+	}
+	s := r.link.ExpiresAt.Format(time.RFC3339)
+	return &s
+}
+func (r *linkResolver) MaxClicks() *int32 {
+	if r.link.MaxClicks == nil {
+		return nil
+	}
+	v := int32(*r.link.MaxClicks)
+	return &v
+}
+func (r *linkResolver) SingleUse() bool { return r.link.SingleUse }
+func (r *linkResolver) ClicksRemaining() *int32 {
+	if r.link.ClicksRemaining == nil {
+		return nil
+	}
+	v := int32(*r.link.ClicksRemaining)
+	return &v
+}
+
+type createLinkInput struct {
+	URL       string
+	Notes     *string
+	ExpiresAt *string
+	MaxClicks *int32
+	SingleUse *bool
+}
+
+type createLinkArgs struct {
+	Input createLinkInput
+}
+
+func (r *Resolver) CreateLink(ctx context.Context, args createLinkArgs) (*linkResolver, error) {
+	in := links.CreateLinkInput{URL: args.Input.URL}
+	if args.Input.Notes != nil {
+		in.Notes = *args.Input.Notes
+	}
+	if args.Input.ExpiresAt != nil {
+		t, err := time.Parse(time.RFC3339, *args.Input.ExpiresAt)
+		if err != nil {
+			return nil, apiError{constants.ErrInvalidRequestBody.WithMessage("expiresAt must be RFC3339")}
+		}
+		in.ExpiresAt = &t
+	}
+	if args.Input.MaxClicks != nil {
+		v := int64(*args.Input.MaxClicks)
+		in.MaxClicks = &v
+	}
+	if args.Input.SingleUse != nil {
+		in.SingleUse = *args.Input.SingleUse
+	}
+
+	link, err := r.svc.CreateLink(ctx, in)
+	if err != nil {
+		return nil, errorFor(err)
+	}
+	return &linkResolver{link: link}, nil
+}
+
+type linkArgs struct {
+	Slug string
+}
+
+func (r *Resolver) Link(ctx context.Context, args linkArgs) (*linkResolver, error) {
+	link, err := r.svc.GetLink(ctx, args.Slug)
+	if err != nil {
+		return nil, errorFor(err)
+	}
+	return &linkResolver{link: link}, nil
+}
+
+type linkStatsArgs struct {
+	Slug  string
+	From  string
+	To    string
+	First *int32
+	After *string
+}
+
+func (r *Resolver) LinkStats(ctx context.Context, args linkStatsArgs) (*dailyCountConnectionResolver, error) {
+	from, err := time.Parse(time.DateOnly, args.From)
+	if err != nil {
+		return nil, apiError{constants.ErrInvalidRequestBody.WithMessage("invalid from (YYYY-MM-DD)")}
+	}
+	to, err := time.Parse(time.DateOnly, args.To)
+	if err != nil {
+		return nil, apiError{constants.ErrInvalidRequestBody.WithMessage("invalid to (YYYY-MM-DD)")}
+	}
+
+	stats, err := r.svc.GetStatsForClaims(ctx, args.Slug, from, to, claimsFromResolver(ctx))
+	if err != nil {
+		return nil, errorFor(err)
+	}
+
+	start := 0
+	if args.After != nil {
+		idx, err := decodeCursor(*args.After)
+		if err != nil {
+			return nil, apiError{constants.ErrInvalidRequestBody.WithMessage("invalid after cursor")}
+		}
+		start = idx + 1
+	}
+
+	end := len(stats.Daily)
+	if args.First != nil && start+int(*args.First) < end {
+		end = start + int(*args.First)
+	}
+	if start > end {
+		start = end
+	}
+
+	return &dailyCountConnectionResolver{daily: stats.Daily, clicksRemaining: stats.ClicksRemaining, start: start, end: end}, nil
+}
+
+type dailyCountConnectionResolver struct {
+	daily           []links.DailyCount
+	clicksRemaining *int64
+	start, end      int
+}
+
+func (c *dailyCountConnectionResolver) ClicksRemaining() *int32 {
+	if c.clicksRemaining == nil {
+		return nil
+	}
+	v := int32(*c.clicksRemaining)
+	return &v
+}
+
+func (c *dailyCountConnectionResolver) Edges() []*dailyCountEdgeResolver {
+	edges := make([]*dailyCountEdgeResolver, 0, c.end-c.start)
+	for i := c.start; i < c.end; i++ {
+		edges = append(edges, &dailyCountEdgeResolver{count: c.daily[i], index: i})
+	}
+	return edges
+}
+
+func (c *dailyCountConnectionResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{hasNext: c.end < len(c.daily), endIndex: c.end - 1}
+}
+
+type dailyCountEdgeResolver struct {
+	count links.DailyCount
+	index int
+}
+
+func (e *dailyCountEdgeResolver) Cursor() string { return encodeCursor(e.index) }
+func (e *dailyCountEdgeResolver) Node() *dailyCountResolver {
+	return &dailyCountResolver{count: e.count}
+}
+
+type dailyCountResolver struct {
+	count links.DailyCount
+}
+
+func (d *dailyCountResolver) Date() string { return d.count.Date }
+func (d *dailyCountResolver) Count() int32 { return int32(d.count.Count) }
+
+type pageInfoResolver struct {
+	hasNext  bool
+	endIndex int
+}
+
+func (p *pageInfoResolver) HasNextPage() bool { return p.hasNext }
+func (p *pageInfoResolver) EndCursor() *string {
+	if p.endIndex < 0 {
+		return nil
+	}
+	c := encodeCursor(p.endIndex)
+	return &c
+}
@@ -0,0 +1,61 @@
+package graphql
+
+// schemaString is the GraphQL SDL for the links API: mutations/queries
+// mirror links.Service 1:1 with the REST handlers, and linkStats exposes
+// its daily counts as a Relay-style connection so clients can page through
+// a wide date range instead of receiving it all at once.
+const schemaString = `
+	schema {
+		query: Query
+		mutation: Mutation
+	}
+
+	type Query {
+		link(slug: String!): Link
+		linkStats(slug: String!, from: String!, to: String!, first: Int, after: String): DailyCountConnection!
+	}
+
+	type Mutation {
+		createLink(input: CreateLinkInput!): Link!
+	}
+
+	input CreateLinkInput {
+		url: String!
+		notes: String
+		expiresAt: String
+		maxClicks: Int
+		singleUse: Boolean
+	}
+
+	type Link {
+		slug: String!
+		url: String!
+		notes: String
+		createdAt: String!
+		expiresAt: String
+		maxClicks: Int
+		singleUse: Boolean
+		clicksRemaining: Int
+	}
+
+	type DailyCount {
+		date: String!
+		count: Int!
+	}
+
+	type DailyCountEdge {
+		cursor: String!
+		node: DailyCount!
+	}
+
+	type PageInfo {
+		hasNextPage: Boolean!
+		endCursor: String
+	}
+
+	type DailyCountConnection {
+		edges: [DailyCountEdge!]!
+		pageInfo: PageInfo!
+		clicksRemaining: Int
+	}
+`
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/IgorGrieder/encurtador-url/internal/transport/http/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodScopes maps each unary RPC to the scope RequireScope would check on
+// the equivalent REST route, so both transports enforce the same
+// authorization model.
+var methodScopes = map[string]string{
+	"/linksv1.LinkService/CreateLink": "links:create",
+	"/linksv1.LinkService/GetStats":   "links:stats",
+	"/linksv1.LinkService/DeleteLink": "links:delete",
+}
+
+// AuthInterceptor authenticates every unary call the same way RequireAnyAuth
+// does over HTTP (a bearer token in the "authorization" metadata key, or an
+// API key in "x-api-key"), then enforces methodScopes against the resulting
+// AuthClaims. Like the HTTP middleware it mirrors, it fails open when
+// neither mechanism is configured, since there's nothing to check against.
+func AuthInterceptor(jwtOpts middleware.JWTMiddlewareOptions, apiKeys []middleware.APIKeyConfig) grpc.UnaryServerInterceptor {
+	verifyJWT := middleware.NewJWTVerifier(jwtOpts)
+	verifyAPIKey := middleware.NewAPIKeyVerifier(apiKeys)
+	jwtConfigured := strings.TrimSpace(jwtOpts.JWKSURL) != ""
+	apiKeysConfigured := len(apiKeys) > 0
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !jwtConfigured && !apiKeysConfigured {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		var claims middleware.AuthClaims
+		var authenticated bool
+
+		if jwtConfigured {
+			if tokens := md.Get("authorization"); len(tokens) > 0 {
+				token := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tokens[0]), "Bearer "))
+				c, err := verifyJWT(token)
+				if err != nil {
+					return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+				}
+				claims, authenticated = c, true
+			}
+		}
+
+		if !authenticated && apiKeysConfigured {
+			keys := md.Get("x-api-key")
+			if len(keys) == 0 {
+				return nil, status.Error(codes.Unauthenticated, "missing credentials")
+			}
+			c, ok := verifyAPIKey(strings.TrimSpace(keys[0]))
+			if !ok {
+				return nil, status.Error(codes.Unauthenticated, "invalid api key")
+			}
+			claims, authenticated = c, true
+		}
+
+		if !authenticated {
+			return nil, status.Error(codes.Unauthenticated, "missing credentials")
+		}
+
+		if scope, ok := methodScopes[info.FullMethod]; ok && !claims.HasScope(scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "missing required scope %q", scope)
+		}
+
+		return handler(withClaims(ctx, claims), req)
+	}
+}
+
+type contextKey int
+
+const claimsContextKey contextKey = iota
+
+// withClaims stashes the AuthClaims AuthInterceptor resolved onto ctx, so a
+// handler like LinkServer.GetStats/DeleteLink can build an ownership check
+// out of claims.Subject without re-authenticating the call itself.
+func withClaims(ctx context.Context, claims middleware.AuthClaims) context.Context {
+	return context.WithValue(ctx, claimsContextKey, claims)
+}
+
+// claimsFromContext returns the AuthClaims AuthInterceptor stored, if any -
+// absent when AuthInterceptor ran open (no JWT/API-key configured at all).
+func claimsFromContext(ctx context.Context) (middleware.AuthClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey).(middleware.AuthClaims)
+	return claims, ok
+}
@@ -0,0 +1,143 @@
+// Package grpc exposes links.Service over gRPC, implementing the
+// LinkService defined in api/proto/linksv1/links.proto. The stubs this
+// file depends on (linksv1.LinkServiceServer, linksv1.Link, ...) are
+// generated by `make proto`; see the Makefile at the repo root.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	linksv1 "github.com/IgorGrieder/encurtador-url/internal/transport/grpc/gen/linksv1"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// LinkServer implements linksv1.LinkServiceServer directly on top of
+// links.Service, so gRPC clients share the exact same business logic as
+// the REST and GraphQL transports.
+type LinkServer struct {
+	linksv1.UnimplementedLinkServiceServer
+
+	svc     *links.Service
+	baseURL string
+}
+
+func NewLinkServer(svc *links.Service, baseURL string) *LinkServer {
+	return &LinkServer{svc: svc, baseURL: baseURL}
+}
+
+func (s *LinkServer) CreateLink(ctx context.Context, req *linksv1.CreateLinkRequest) (*linksv1.Link, error) {
+	var expiresAt *time.Time
+	if req.GetExpiresAt() != nil {
+		t := req.GetExpiresAt().AsTime()
+		expiresAt = &t
+	}
+
+	link, err := s.svc.CreateLink(ctx, links.CreateLinkInput{
+		URL:       req.GetUrl(),
+		Notes:     req.GetNotes(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, statusFor(err)
+	}
+
+	return toProtoLink(link), nil
+}
+
+func (s *LinkServer) Redirect(ctx context.Context, req *linksv1.RedirectRequest) (*linksv1.RedirectResponse, error) {
+	link, err := s.svc.Resolve(ctx, req.GetSlug())
+	if err != nil {
+		return nil, statusFor(err)
+	}
+
+	if err := s.svc.RecordClick(ctx, req.GetSlug()); err != nil {
+		return nil, status.Errorf(codes.Internal, "record click: %v", err)
+	}
+
+	return &linksv1.RedirectResponse{Url: link.URL}, nil
+}
+
+func (s *LinkServer) GetStats(ctx context.Context, req *linksv1.GetStatsRequest) (*linksv1.GetStatsResponse, error) {
+	from, err := time.Parse(time.DateOnly, req.GetFrom())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid from (YYYY-MM-DD)")
+	}
+	to, err := time.Parse(time.DateOnly, req.GetTo())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid to (YYYY-MM-DD)")
+	}
+
+	stats, err := s.svc.GetStatsForClaims(ctx, req.GetSlug(), from, to, claimsFromGRPC(ctx))
+	if err != nil {
+		return nil, statusFor(err)
+	}
+
+	out := make([]*linksv1.DailyCount, len(stats.Daily))
+	for i, d := range stats.Daily {
+		out[i] = &linksv1.DailyCount{Date: d.Date, Count: d.Count}
+	}
+
+	return &linksv1.GetStatsResponse{Daily: out}, nil
+}
+
+func (s *LinkServer) DeleteLink(ctx context.Context, req *linksv1.DeleteLinkRequest) (*linksv1.DeleteLinkResponse, error) {
+	if err := s.svc.DeleteLinkForClaims(ctx, req.GetSlug(), claimsFromGRPC(ctx)); err != nil {
+		return nil, statusFor(err)
+	}
+	return &linksv1.DeleteLinkResponse{Deleted: true}, nil
+}
+
+// claimsFromGRPC adapts the AuthClaims AuthInterceptor resolved (JWT or
+// API-key) to auth.Claims, the type GetStatsForClaims/DeleteLinkForClaims
+// enforce OwnerSubject against - so a links:stats/links:delete scope alone
+// isn't enough to read or remove a link the caller didn't create. A call
+// with no resolved claims (AuthInterceptor ran open) yields the zero value,
+// which authorizeOwner treats as nothing to enforce.
+func claimsFromGRPC(ctx context.Context) auth.Claims {
+	claims, ok := claimsFromContext(ctx)
+	if !ok {
+		return auth.Claims{}
+	}
+	return auth.Claims{Subject: claims.Subject, Scopes: claims.Scopes}
+}
+
+func toProtoLink(link *links.Link) *linksv1.Link {
+	out := &linksv1.Link{
+		Slug:      link.Slug,
+		Url:       link.URL,
+		Notes:     link.Notes,
+		CreatedAt: timestamppb.New(link.CreatedAt),
+	}
+	if link.ExpiresAt != nil {
+		out.ExpiresAt = timestamppb.New(*link.ExpiresAt)
+	}
+	return out
+}
+
+// statusFor maps links.Service's sentinel errors to the gRPC status codes
+// REST maps them to as constants.APIError HTTP statuses (NotFound->404,
+// InvalidURL/InvalidRange->400), so behavior stays consistent across
+// transports.
+func statusFor(err error) error {
+	switch {
+	case errors.Is(err, links.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, links.ErrExpired):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, links.ErrInvalidURL), errors.Is(err, links.ErrInvalidRange):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, links.ErrSlugTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, links.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
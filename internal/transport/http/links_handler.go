@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -11,16 +12,21 @@ import (
 	"github.com/IgorGrieder/encurtador-url/internal/config"
 	"github.com/IgorGrieder/encurtador-url/internal/constants"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/telemetry"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/tracing"
 	appvalidation "github.com/IgorGrieder/encurtador-url/internal/infrastructure/validation"
+	callerMiddleware "github.com/IgorGrieder/encurtador-url/internal/middleware"
 	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/links/auth"
 	"github.com/IgorGrieder/encurtador-url/internal/transport/http/middleware"
 	"github.com/IgorGrieder/encurtador-url/pkg/httputils"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type LinksHandler struct {
-	cfg *config.Config
+	cfg *config.Live
 	svc *links.Service
 
 	asyncClick   bool
@@ -28,7 +34,7 @@ type LinksHandler struct {
 	fastRedirect bool
 }
 
-func NewLinksHandler(cfg *config.Config, svc *links.Service) *LinksHandler {
+func NewLinksHandler(cfg *config.Live, svc *links.Service) *LinksHandler {
 	return NewLinksHandlerWithOptions(cfg, svc, LinksHandlerOptions{
 		AsyncClick:   true,
 		ClickTimeout: 2 * time.Second,
@@ -42,7 +48,11 @@ type LinksHandlerOptions struct {
 	FastRedirect bool
 }
 
-func NewLinksHandlerWithOptions(cfg *config.Config, svc *links.Service, opts LinksHandlerOptions) *LinksHandler {
+// NewLinksHandlerWithOptions takes cfg as a *config.Live rather than a
+// plain *config.Config so ShortURL's base URL and Redirect's status code
+// pick up a Config.Watch reload without this handler needing to be
+// rebuilt - see the read sites below, each calling cfg.Current().
+func NewLinksHandlerWithOptions(cfg *config.Live, svc *links.Service, opts LinksHandlerOptions) *LinksHandler {
 	if opts.ClickTimeout <= 0 {
 		opts.ClickTimeout = 2 * time.Second
 	}
@@ -57,9 +67,12 @@ func NewLinksHandlerWithOptions(cfg *config.Config, svc *links.Service, opts Lin
 }
 
 type createLinkRequest struct {
-	URL       string     `json:"url" validate:"required,notblank,http_url"`
-	Notes     string     `json:"notes,omitempty"`
-	ExpiresAt *time.Time `json:"expiresAt,omitempty" validate:"omitempty,future"`
+	URL        string     `json:"url" validate:"required,notblank,http_url"`
+	Notes      string     `json:"notes,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" validate:"omitempty,future"`
+	CustomSlug string     `json:"customSlug,omitempty"`
+	MaxClicks  *int64     `json:"maxClicks,omitempty" validate:"omitempty,gt=0"`
+	SingleUse  bool       `json:"singleUse,omitempty"`
 }
 
 type createLinkResponse struct {
@@ -98,27 +111,52 @@ func (h *LinksHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	apiKey := r.Header.Get(middleware.APIKeyHeader)
 
+	var ownerSubject string
+	if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+		ownerSubject = claims.Subject
+	}
+
 	link, err := h.svc.CreateLink(r.Context(), links.CreateLinkInput{
-		URL:       req.URL,
-		Notes:     req.Notes,
-		ExpiresAt: req.ExpiresAt,
-		APIKey:    apiKey,
+		URL:            req.URL,
+		Notes:          req.Notes,
+		ExpiresAt:      req.ExpiresAt,
+		APIKey:         apiKey,
+		OwnerSubject:   ownerSubject,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+		CustomSlug:     req.CustomSlug,
+		MaxClicks:      req.MaxClicks,
+		SingleUse:      req.SingleUse,
 	})
 	if err != nil {
-		switch err {
-		case links.ErrInvalidURL:
-			httputils.WriteAPIError(w, r, constants.ErrInvalidURL)
+		switch {
+		case errors.Is(err, links.ErrInvalidURL):
+			var verr *links.URLValidationError
+			apiErr := constants.ErrInvalidURL
+			if errors.As(err, &verr) {
+				apiErr = apiErr.WithMessage(verr.Message)
+			}
+			httputils.WriteAPIError(w, r, apiErr)
+		case errors.Is(err, links.ErrInvalidCustomSlug):
+			httputils.WriteAPIError(w, r, constants.ErrInvalidSlug)
+		case errors.Is(err, links.ErrSlugTaken):
+			httputils.WriteAPIError(w, r, constants.ErrSlugTaken)
+		case errors.Is(err, links.ErrIdempotencyConflict):
+			httputils.WriteAPIError(w, r, constants.ErrIdempotencyConflict)
+		case errors.Is(err, links.ErrIdempotencyInFlight):
+			httputils.WriteAPIError(w, r, constants.ErrIdempotencyInFlight)
 		default:
-			logger.Error("failed to create link", zap.Error(err))
+			logger.FromContext(r.Context()).Error("failed to create link", zap.Error(err))
 			httputils.WriteAPIError(w, r, constants.ErrInternalError)
 		}
 		return
 	}
 
+	trace.SpanFromContext(r.Context()).SetAttributes(tracing.SlugAttr(link.Slug))
+
 	httputils.WriteAPISuccess(w, r, constants.SuccessLinkCreated, createLinkResponse{
 		Slug:      link.Slug,
 		URL:       link.URL,
-		ShortURL:  strings.TrimRight(h.cfg.Shortener.BaseURL, "/") + "/" + link.Slug,
+		ShortURL:  strings.TrimRight(h.cfg.Current().Shortener.BaseURL, "/") + "/" + link.Slug,
 		Notes:     link.Notes,
 		CreatedAt: link.CreatedAt,
 		ExpiresAt: link.ExpiresAt,
@@ -126,9 +164,17 @@ func (h *LinksHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *LinksHandler) Redirect(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
 	slug := r.PathValue("slug")
+	trace.SpanFromContext(r.Context()).SetAttributes(tracing.SlugAttr(slug))
+	defer func() {
+		telemetry.RedirectLatencySeconds.Observe(time.Since(start).Seconds())
+	}()
 
-	link, err := h.svc.Resolve(r.Context(), slug)
+	ctx := logger.WithFields(r.Context(), zap.String("slug", slug))
+	reqLogger := logger.FromContext(ctx)
+
+	link, err := h.svc.Resolve(ctx, slug)
 	if err != nil {
 		switch err {
 		case links.ErrNotFound:
@@ -136,37 +182,50 @@ func (h *LinksHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 		case links.ErrExpired:
 			w.WriteHeader(http.StatusGone)
 		default:
-			logger.Error("failed to resolve slug", zap.Error(err), zap.String("slug", slug))
+			reqLogger.Error("failed to resolve slug", zap.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 		return
 	}
 
+	clickOpts := links.RecordClickOptions{
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+		Referer:   r.Referer(),
+	}
+
 	if h.asyncClick {
 		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), h.clickTimeout)
+			// logger.NewContext carries reqLogger - and with it
+			// request_id/api_key_hash/client_ip/slug - into a fresh,
+			// uncancelable context, so a "failed to record click" line
+			// here still correlates back to the redirect that triggered
+			// it instead of logging as a bare, unattributed warning.
+			clickCtx, cancel := context.WithTimeout(logger.NewContext(context.Background(), reqLogger), h.clickTimeout)
 			defer cancel()
-			if err := h.svc.RecordClick(ctx, slug); err != nil {
-				logger.Warn("failed to record click", zap.Error(err), zap.String("slug", slug))
+			if err := h.svc.RecordClickWithOptions(clickCtx, slug, clickOpts); err != nil {
+				reqLogger.Warn("failed to record click", zap.Error(err))
 			}
 		}()
 	} else {
-		_ = h.svc.RecordClick(r.Context(), slug)
+		_ = h.svc.RecordClickWithOptions(ctx, slug, clickOpts)
 	}
 
+	redirectStatus := h.cfg.Current().Shortener.RedirectStatus
 	if h.fastRedirect {
 		w.Header().Set("Location", link.URL)
-		w.WriteHeader(h.cfg.Shortener.RedirectStatus)
+		w.WriteHeader(redirectStatus)
 		return
 	}
-	http.Redirect(w, r, link.URL, h.cfg.Shortener.RedirectStatus)
+	http.Redirect(w, r, link.URL, redirectStatus)
 }
 
 type statsResponse struct {
-	Slug  string             `json:"slug"`
-	From  string             `json:"from"`
-	To    string             `json:"to"`
-	Daily []links.DailyCount `json:"daily"`
+	Slug            string             `json:"slug"`
+	From            string             `json:"from"`
+	To              string             `json:"to"`
+	Daily           []links.DailyCount `json:"daily"`
+	ClicksRemaining *int64             `json:"clicksRemaining,omitempty"`
 }
 
 type statsQueryParams struct {
@@ -176,6 +235,8 @@ type statsQueryParams struct {
 
 func (h *LinksHandler) Stats(w http.ResponseWriter, r *http.Request) {
 	slug := r.PathValue("slug")
+	trace.SpanFromContext(r.Context()).SetAttributes(tracing.SlugAttr(slug))
+	r = r.WithContext(logger.WithFields(r.Context(), zap.String("slug", slug)))
 
 	fromRaw := r.URL.Query().Get("from")
 	toRaw := r.URL.Query().Get("to")
@@ -213,24 +274,109 @@ func (h *LinksHandler) Stats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	daily, err := h.svc.GetStats(r.Context(), slug, from, to)
+	if !h.callerOwnsLink(w, r, slug) {
+		return
+	}
+
+	stats, err := h.svc.GetStatsForClaims(r.Context(), slug, from, to, claimsFromRequest(r))
 	if err != nil {
 		switch err {
 		case links.ErrNotFound:
 			httputils.WriteAPIError(w, r, constants.ErrLinkNotFound)
 		case links.ErrInvalidRange:
 			httputils.WriteAPIError(w, r, constants.ErrInvalidRequestBody.WithMessage("from must be <= to"))
+		case links.ErrForbidden:
+			httputils.WriteAPIError(w, r, constants.ErrForbidden)
 		default:
-			logger.Error("failed to fetch stats", zap.Error(err), zap.String("slug", slug))
+			logger.FromContext(r.Context()).Error("failed to fetch stats", zap.Error(err))
 			httputils.WriteAPIError(w, r, constants.ErrInternalError)
 		}
 		return
 	}
 
 	httputils.WriteAPISuccess(w, r, constants.SuccessStatsFound, statsResponse{
-		Slug:  slug,
-		From:  from.Format(time.DateOnly),
-		To:    to.Format(time.DateOnly),
-		Daily: daily,
+		Slug:            slug,
+		From:            from.Format(time.DateOnly),
+		To:              to.Format(time.DateOnly),
+		Daily:           stats.Daily,
+		ClicksRemaining: stats.ClicksRemaining,
 	})
 }
+
+func (h *LinksHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	if !h.callerOwnsLink(w, r, slug) {
+		return
+	}
+
+	if err := h.svc.DeleteLinkForClaims(r.Context(), slug, claimsFromRequest(r)); err != nil {
+		switch err {
+		case links.ErrNotFound:
+			httputils.WriteAPIError(w, r, constants.ErrLinkNotFound)
+		case links.ErrForbidden:
+			httputils.WriteAPIError(w, r, constants.ErrForbidden)
+		default:
+			logger.Error("failed to delete link", zap.Error(err), zap.String("slug", slug))
+			httputils.WriteAPIError(w, r, constants.ErrInternalError)
+		}
+		return
+	}
+
+	httputils.WriteAPISuccess(w, r, constants.SuccessLinkDeleted, nil)
+}
+
+// callerOwnsLink enforces that the caller resolved by callerMiddleware.AuthMiddleware
+// (if any) created slug's link, writing the appropriate API error and
+// returning false if not. A request with no resolved caller, or a link
+// created without an API key, is allowed through: this mirrors
+// CreateLink accepting requests with no X-API-Key header at all.
+func (h *LinksHandler) callerOwnsLink(w http.ResponseWriter, r *http.Request, slug string) bool {
+	caller, ok := callerMiddleware.CallerFromContext(r.Context())
+	if !ok {
+		return true
+	}
+
+	link, err := h.svc.GetLink(r.Context(), slug)
+	if err != nil {
+		if err == links.ErrNotFound {
+			httputils.WriteAPIError(w, r, constants.ErrLinkNotFound)
+		} else {
+			logger.Error("failed to look up link for ownership check", zap.Error(err), zap.String("slug", slug))
+			httputils.WriteAPIError(w, r, constants.ErrInternalError)
+		}
+		return false
+	}
+
+	if !callerMiddleware.OwnsLink(caller, link.APIKey) {
+		httputils.WriteAPIError(w, r, constants.ErrForbidden)
+		return false
+	}
+	return true
+}
+
+// claimsFromRequest adapts the AuthClaims requireAuth resolved (JWT or
+// API-key, see RequireAnyAuth) to auth.Claims, the type GetStatsForClaims
+// and DeleteLinkForClaims enforce OwnerSubject against. A request with no
+// resolved claims yields the zero value, which authorizeOwner treats as
+// nothing to enforce - the same fail-open posture callerOwnsLink applies
+// to the legacy API-key ownership path.
+func claimsFromRequest(r *http.Request) auth.Claims {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		return auth.Claims{}
+	}
+	return auth.Claims{Subject: claims.Subject, Scopes: claims.Scopes}
+}
+
+// clientIP strips the port off r.RemoteAddr the same way
+// transport/http/middleware's own clientIP does, so the IP
+// RecordClickWithOptions hashes into a ClickEvent.VisitorHash never
+// includes it.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err == nil && host != "" {
+		return host
+	}
+	return strings.TrimSpace(r.RemoteAddr)
+}
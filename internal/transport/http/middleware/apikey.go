@@ -10,17 +10,50 @@ import (
 
 const APIKeyHeader = "X-API-Key"
 
-func APIKeyMiddleware(allowedKeys []string) func(http.Handler) http.Handler {
-	allowed := make(map[string]struct{}, len(allowedKeys))
-	for _, k := range allowedKeys {
-		k = strings.TrimSpace(k)
-		if k == "" {
+// APIKeyConfig pairs a configured API key with the scopes it authorizes, so
+// API-key and JWT auth share the same AuthClaims/RequireScope model.
+type APIKeyConfig struct {
+	Key    string
+	Scopes []string
+}
+
+func normalizeAPIKeys(keys []APIKeyConfig) map[string]APIKeyConfig {
+	allowed := make(map[string]APIKeyConfig, len(keys))
+	for _, k := range keys {
+		key := strings.TrimSpace(k.Key)
+		if key == "" {
 			continue
 		}
-		allowed[k] = struct{}{}
+		allowed[key] = k
+	}
+	return allowed
+}
+
+func verifyAPIKey(allowed map[string]APIKeyConfig, key string) (AuthClaims, bool) {
+	cfg, ok := allowed[key]
+	if !ok {
+		return AuthClaims{}, false
 	}
+	return AuthClaims{Subject: "apikey:" + key, Scopes: cfg.Scopes}, true
+}
+
+// NewAPIKeyVerifier returns a function that checks a raw API key against
+// keys, for transports (like the gRPC AuthInterceptor) that read
+// credentials out of something other than an http.Request header.
+func NewAPIKeyVerifier(keys []APIKeyConfig) func(key string) (AuthClaims, bool) {
+	allowed := normalizeAPIKeys(keys)
+	return func(key string) (AuthClaims, bool) {
+		return verifyAPIKey(allowed, key)
+	}
+}
+
+// APIKeyMiddleware authenticates requests carrying a configured X-API-Key
+// header and stores the key's associated scopes in the request context as
+// AuthClaims, for RequireScope to enforce. If no keys are configured, it
+// runs open (MVP convenience).
+func APIKeyMiddleware(keys []APIKeyConfig) func(http.Handler) http.Handler {
+	allowed := normalizeAPIKeys(keys)
 
-	// If no keys are configured, run open (MVP convenience).
 	if len(allowed) == 0 {
 		return func(next http.Handler) http.Handler { return next }
 	}
@@ -32,12 +65,12 @@ func APIKeyMiddleware(allowedKeys []string) func(http.Handler) http.Handler {
 				httputils.WriteAPIError(w, r, constants.ErrUnauthorized)
 				return
 			}
-			if _, ok := allowed[apiKey]; !ok {
+			claims, ok := verifyAPIKey(allowed, apiKey)
+			if !ok {
 				httputils.WriteAPIError(w, r, constants.ErrUnauthorized)
 				return
 			}
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
 		})
 	}
 }
-
@@ -26,7 +26,7 @@ func TestAPIKeyMiddleware_OpenMode(t *testing.T) {
 }
 
 func TestAPIKeyMiddleware_ValidKey(t *testing.T) {
-	mw := APIKeyMiddleware([]string{"secret-key-1"})(okHandler())
+	mw := APIKeyMiddleware([]APIKeyConfig{{Key: "secret-key-1"}})(okHandler())
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	req.Header.Set(APIKeyHeader, "secret-key-1")
@@ -39,7 +39,7 @@ func TestAPIKeyMiddleware_ValidKey(t *testing.T) {
 }
 
 func TestAPIKeyMiddleware_MissingHeader(t *testing.T) {
-	mw := APIKeyMiddleware([]string{"secret-key-1"})(okHandler())
+	mw := APIKeyMiddleware([]APIKeyConfig{{Key: "secret-key-1"}})(okHandler())
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	rec := httptest.NewRecorder()
@@ -51,7 +51,7 @@ func TestAPIKeyMiddleware_MissingHeader(t *testing.T) {
 }
 
 func TestAPIKeyMiddleware_WrongKey(t *testing.T) {
-	mw := APIKeyMiddleware([]string{"secret-key-1"})(okHandler())
+	mw := APIKeyMiddleware([]APIKeyConfig{{Key: "secret-key-1"}})(okHandler())
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	req.Header.Set(APIKeyHeader, "wrong-key")
@@ -65,7 +65,7 @@ func TestAPIKeyMiddleware_WrongKey(t *testing.T) {
 
 func TestAPIKeyMiddleware_EmptyKeysSlice(t *testing.T) {
 	// Empty slice of keys → open mode
-	mw := APIKeyMiddleware([]string{})(okHandler())
+	mw := APIKeyMiddleware([]APIKeyConfig{})(okHandler())
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	rec := httptest.NewRecorder()
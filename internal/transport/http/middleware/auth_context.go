@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/IgorGrieder/encurtador-url/internal/constants"
+	"github.com/IgorGrieder/encurtador-url/pkg/httputils"
+)
+
+// AuthClaims is the authorization model shared by every auth mechanism
+// (API keys, JWT bearer tokens, ...): a caller identity plus the scopes it
+// was granted. RequireScope reads this from the request context regardless
+// of which middleware populated it.
+type AuthClaims struct {
+	Subject string
+	Scopes  []string
+}
+
+// HasScope reports whether scope is among the claims' granted scopes.
+func (c AuthClaims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey int
+
+const claimsContextKey contextKey = iota
+
+func withClaims(ctx context.Context, claims AuthClaims) context.Context {
+	return context.WithValue(ctx, claimsContextKey, claims)
+}
+
+// ClaimsFromContext returns the AuthClaims stored by APIKeyMiddleware,
+// JWTMiddleware, or RequireAnyAuth, if any ran ahead of the caller.
+func ClaimsFromContext(ctx context.Context) (AuthClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey).(AuthClaims)
+	return claims, ok
+}
+
+// RequireScope enforces that the caller's AuthClaims (set by an auth
+// middleware earlier in the chain) include scope. If no claims are present
+// at all, it runs open (MVP convenience, matching the "fail open when
+// unconfigured" posture of APIKeyMiddleware/JWTMiddleware) since there is
+// nothing to check against; if claims are present but lack the scope, it
+// rejects with ErrForbidden.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !claims.HasScope(scope) {
+				httputils.WriteAPIError(w, r, constants.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
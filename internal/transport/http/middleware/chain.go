@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/IgorGrieder/encurtador-url/internal/constants"
+	"github.com/IgorGrieder/encurtador-url/pkg/httputils"
+)
+
+// Chain wraps h with middlewares in order, so the first entry is the
+// outermost (runs first, sees the request before anything else does).
+func Chain(h http.Handler, middlewares ...func(http.Handler) http.Handler) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// RequireAnyAuth accepts a request authenticated by either a valid JWT
+// bearer token or a valid API key, so a protected route isn't locked into
+// one credential type: a bearer token is checked first (it needs no
+// shared-secret lookup), falling back to the API key header only when no
+// bearer token was presented at all. If neither mechanism is configured, it
+// runs open (MVP convenience), matching JWTMiddleware/APIKeyMiddleware used
+// individually.
+func RequireAnyAuth(jwtOpts JWTMiddlewareOptions, apiKeys []APIKeyConfig) func(http.Handler) http.Handler {
+	cache := newJWKSCache(jwtOpts.JWKSURL)
+	allowed := normalizeAPIKeys(apiKeys)
+	jwtConfigured := strings.TrimSpace(jwtOpts.JWKSURL) != ""
+
+	if !jwtConfigured && len(allowed) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if jwtConfigured {
+				if token := bearerToken(r); token != "" {
+					claims, err := verifyJWTToken(cache, jwtOpts, token)
+					if err != nil {
+						httputils.WriteAPIError(w, r, constants.ErrUnauthorized)
+						return
+					}
+					next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
+					return
+				}
+			}
+
+			if len(allowed) == 0 {
+				httputils.WriteAPIError(w, r, constants.ErrUnauthorized)
+				return
+			}
+
+			apiKey := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+			if apiKey == "" {
+				httputils.WriteAPIError(w, r, constants.ErrUnauthorized)
+				return
+			}
+			claims, ok := verifyAPIKey(allowed, apiKey)
+			if !ok {
+				httputils.WriteAPIError(w, r, constants.ErrUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
+		})
+	}
+}
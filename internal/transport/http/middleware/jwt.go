@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IgorGrieder/encurtador-url/internal/constants"
+	"github.com/IgorGrieder/encurtador-url/pkg/httputils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTMiddlewareOptions parameterizes JWTMiddleware's bearer-token
+// verification.
+type JWTMiddlewareOptions struct {
+	// JWKSURL is the JWKS endpoint bearer tokens are verified against.
+	JWKSURL string
+	// Algorithms restricts which signing algorithms are accepted (e.g.
+	// "RS256", "ES256"). A token signed with anything else is rejected.
+	Algorithms []string
+}
+
+// jwtClaims is the subset of standard plus custom claims JWTMiddleware
+// reads out of a verified bearer token.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope"`
+}
+
+// JWTMiddleware validates RS256/ES256 bearer tokens against a JWKS endpoint
+// and stores the resulting AuthClaims in the request context for
+// RequireScope and downstream handlers to read. Requests without a bearer
+// token are passed through unauthenticated (fail open, matching
+// APIKeyMiddleware's MVP posture) rather than rejected, so it can be
+// composed with other auth mechanisms via RequireAnyAuth.
+func JWTMiddleware(opts JWTMiddlewareOptions) func(http.Handler) http.Handler {
+	cache := newJWKSCache(opts.JWKSURL)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			claims, err := verifyJWTToken(cache, opts, token)
+			if err != nil {
+				httputils.WriteAPIError(w, r, constants.ErrUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// verifyJWTToken parses and verifies token against cache's JWKS keys,
+// translating the verified claims into the shared AuthClaims model.
+func verifyJWTToken(cache *jwksCache, opts JWTMiddlewareOptions, token string) (AuthClaims, error) {
+	claims := &jwtClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("jwt: token missing kid header")
+		}
+		return cache.keyFor(kid)
+	}, jwt.WithValidMethods(opts.Algorithms))
+	if err != nil {
+		return AuthClaims{}, fmt.Errorf("jwt: verify token: %w", err)
+	}
+
+	authClaims := AuthClaims{Subject: claims.Subject}
+	if claims.Scope != "" {
+		authClaims.Scopes = strings.Fields(claims.Scope)
+	}
+	return authClaims, nil
+}
+
+// NewJWTVerifier returns a function that verifies a raw bearer token
+// against opts' JWKS endpoint, independent of any particular transport:
+// JWTMiddleware uses it for HTTP requests, and the gRPC AuthInterceptor
+// uses it for tokens read out of call metadata.
+func NewJWTVerifier(opts JWTMiddlewareOptions) func(token string) (AuthClaims, error) {
+	cache := newJWKSCache(opts.JWKSURL)
+	return func(token string) (AuthClaims, error) {
+		return verifyJWTToken(cache, opts, token)
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	h := strings.TrimSpace(r.Header.Get("Authorization"))
+	if h == "" {
+		return ""
+	}
+	const prefix = "Bearer "
+	if !strings.HasPrefix(h, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(h[len(prefix):])
+}
+
+// jwksDoc is a JSON Web Key Set document as served by a JWKS endpoint.
+type jwksDoc struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwk is a single JSON Web Key, covering the RSA and EC fields we support.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: decode RSA modulus: %w", err)
+		}
+		eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: decode RSA exponent: %w", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(nBytes),
+			E: int(new(big.Int).SetBytes(eBytes).Int64()),
+		}, nil
+
+	case "EC":
+		curve, err := curveFor(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		xBytes, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: decode EC x: %w", err)
+		}
+		yBytes, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: decode EC y: %w", err)
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(xBytes),
+			Y:     new(big.Int).SetBytes(yBytes),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("jwks: unsupported key type %q", k.Kty)
+	}
+}
+
+func curveFor(name string) (elliptic.Curve, error) {
+	switch name {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("jwks: unsupported curve %q", name)
+	}
+}
+
+// jwksCache fetches and caches a JWKS document by kid, refreshing on a
+// cache miss so key rotation on the issuer side is picked up without a
+// restart.
+type jwksCache struct {
+	url    string
+	client *http.Client
+
+	mu   sync.RWMutex
+	keys map[string]any
+}
+
+func newJWKSCache(url string) *jwksCache {
+	return &jwksCache{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		keys:   make(map[string]any),
+	}
+}
+
+func (c *jwksCache) keyFor(kid string) (any, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	c.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok = c.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("jwks: no key found for kid %q", kid)
+	}
+	return key, nil
+}
+
+func (c *jwksCache) refresh() error {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return fmt.Errorf("jwks: fetch %s: %w", c.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks: fetch %s: unexpected status %d", c.url, resp.StatusCode)
+	}
+
+	var doc jwksDoc
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("jwks: decode response from %s: %w", c.url, err)
+	}
+
+	keys := make(map[string]any, len(doc.Keys))
+	for _, k := range doc.Keys {
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+	return nil
+}
@@ -39,6 +39,10 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			)
 		}
 
+		if requestID, ok := RequestIDFromContext(r.Context()); ok {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
+
 		logger.Info("request completed", fields...)
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,39 +16,76 @@ import (
 // RedisFixedWindowLimiter enforces a simple counter per user per fixed time window.
 // It also serves as a record of "how many requests this user made".
 type RedisFixedWindowLimiter struct {
-	store *redisStorage.FixedWindowLimiter
-	limit int64
-	now   func() time.Time
+	store   *redisStorage.FixedWindowLimiter
+	limitFn func() int
 }
 
-func NewRedisFixedWindowLimiter(store *redisStorage.FixedWindowLimiter, limitPerMinute int) *RedisFixedWindowLimiter {
-	if limitPerMinute <= 0 {
-		limitPerMinute = 60
+// NewRedisFixedWindowLimiter builds a limiter that calls limitFn on every
+// request rather than capturing a fixed RPM at construction time, so a
+// caller backed by config.Live's Current() picks up a Config.Watch reload
+// without the limiter being rebuilt.
+func NewRedisFixedWindowLimiter(store *redisStorage.FixedWindowLimiter, limitFn func() int) *RedisFixedWindowLimiter {
+	if limitFn == nil {
+		limitFn = func() int { return 60 }
 	}
 	return &RedisFixedWindowLimiter{
-		store: store,
-		limit: int64(limitPerMinute),
-		now:   time.Now,
+		store:   store,
+		limitFn: limitFn,
 	}
 }
 
-func RateLimitMiddleware(limiter *RedisFixedWindowLimiter) func(http.Handler) http.Handler {
+// Allow satisfies redisStorage.Limiter so RedisFixedWindowLimiter can run
+// behind StrategyRateLimiter alongside the sliding-window and token-bucket
+// strategies, rather than needing its own bespoke middleware.
+func (l *RedisFixedWindowLimiter) Allow(ctx context.Context, key string) (redisStorage.LimitDecision, error) {
+	count, err := l.store.Incr(ctx, key)
+	if err != nil {
+		return redisStorage.LimitDecision{}, err
+	}
+
+	limit := int64(l.limitFn())
+	if count > limit {
+		return redisStorage.LimitDecision{
+			Allowed:    false,
+			Limit:      limit,
+			Remaining:  0,
+			RetryAfter: int64(l.store.Window().Seconds()),
+		}, nil
+	}
+
+	return redisStorage.LimitDecision{
+		Allowed:   true,
+		Limit:     limit,
+		Remaining: limit - count,
+	}, nil
+}
+
+// StrategyRateLimiter wraps any redis.Limiter (fixed window, sliding window,
+// token bucket, ...) and is selected at startup via SECURITY_RATE_STRATEGY or,
+// for the link-creation endpoint specifically, RATE_LIMITER_ALGORITHM.
+func StrategyRateLimiter(limiter redisStorage.Limiter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			key := rateLimitKey(r)
 			ctx, cancel := context.WithTimeout(r.Context(), 200*time.Millisecond)
 			defer cancel()
 
-			count, err := limiter.store.Incr(ctx, key)
+			decision, err := limiter.Allow(ctx, key)
 			if err != nil {
-				// Fail open (MVP): do not block writes if Redis is temporarily unavailable.
+				// Fail open (MVP): do not block requests if Redis is temporarily unavailable.
 				next.ServeHTTP(w, r)
 				return
 			}
-			if count > limiter.limit {
+
+			w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(decision.Limit, 10))
+			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(decision.Remaining, 10))
+
+			if !decision.Allowed {
+				w.Header().Set("Retry-After", strconv.FormatInt(decision.RetryAfter, 10))
 				httputils.WriteAPIError(w, r, constants.ErrRateLimited)
 				return
 			}
+
 			next.ServeHTTP(w, r)
 		})
 	}
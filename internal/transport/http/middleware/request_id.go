@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/IgorGrieder/encurtador-url/pkg/httpclient"
+	"github.com/IgorGrieder/encurtador-url/pkg/httputils"
+	"github.com/oklog/ulid/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the header carrying a client-supplied request id, if
+// any. httputils.CorrelationIDHeader is treated as an equivalent,
+// lower-priority source.
+const RequestIDHeader = "X-Request-Id"
+
+type requestIDContextKey struct{}
+
+// RequestIDMiddleware assigns every request a single correlation id shared
+// across logs, traces, and any outbound calls it triggers: it reads
+// X-Request-Id or X-Correlation-Id (in that order) when the caller already
+// supplied one, generates a ULID otherwise, stores it in the request
+// context (and on the inbound headers, so httputils.GetCorrelationID sees
+// the same value), echoes it back on the response, and tags the current
+// OTel span with it.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get(RequestIDHeader))
+		if id == "" {
+			id = strings.TrimSpace(r.Header.Get(httputils.CorrelationIDHeader))
+		}
+		if id == "" {
+			id = ulid.Make().String()
+		}
+
+		r.Header.Set(RequestIDHeader, id)
+		r.Header.Set(httputils.CorrelationIDHeader, id)
+		w.Header().Set(RequestIDHeader, id)
+		w.Header().Set(httputils.CorrelationIDHeader, id)
+
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("request.id", id))
+
+		ctx := context.WithValue(r.Context(), requestIDContextKey{}, id)
+		ctx = httpclient.ContextWithRequestID(ctx, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the id RequestIDMiddleware stored in ctx, if
+// any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok
+}
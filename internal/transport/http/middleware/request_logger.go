@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// RequestLoggerMiddleware seeds the request context with a logger (via
+// logger.WithFields) carrying request_id, api_key_hash, and client_ip, so
+// every logger.FromContext(ctx) call downstream - including from a
+// goroutine that outlives the request, as long as it's handed this ctx (or
+// a context.Context built from its logger via logger.NewContext) before it
+// returns - emits lines already correlated to the request. Register it
+// after RequestIDMiddleware so request_id is already in context.
+func RequestLoggerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields := []zap.Field{zap.String("client_ip", clientIP(r))}
+
+		if requestID, ok := RequestIDFromContext(r.Context()); ok {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
+		if apiKey := strings.TrimSpace(r.Header.Get(APIKeyHeader)); apiKey != "" {
+			fields = append(fields, zap.String("api_key_hash", hashAPIKey(apiKey)))
+		}
+
+		ctx := logger.WithFields(r.Context(), fields...)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err == nil && host != "" {
+		return host
+	}
+	return strings.TrimSpace(r.RemoteAddr)
+}
+
+// hashAPIKey never logs the raw key: a truncated sha256 hex digest is
+// enough to correlate requests from the same caller across log lines
+// without putting a live credential in the logs.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:16]
+}
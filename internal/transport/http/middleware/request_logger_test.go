@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{"host and port", "203.0.113.5:54321", "203.0.113.5"},
+		{"ipv6 with port", "[2001:db8::1]:443", "2001:db8::1"},
+		{"no port", "203.0.113.5", "203.0.113.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if got := clientIP(r); got != tt.want {
+				t.Errorf("clientIP(%q) = %q, want %q", tt.remoteAddr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashAPIKeyIsStableAndDistinct(t *testing.T) {
+	a := hashAPIKey("key-one")
+	b := hashAPIKey("key-one")
+	c := hashAPIKey("key-two")
+
+	if a != b {
+		t.Errorf("hashAPIKey should be deterministic, got %q and %q", a, b)
+	}
+	if a == c {
+		t.Errorf("hashAPIKey should differ for different keys, both got %q", a)
+	}
+	if a == "key-one" {
+		t.Errorf("hashAPIKey must not return the raw key")
+	}
+}
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/IgorGrieder/encurtador-url/internal/processing/stats"
+)
+
+// RollupStatusHandler exposes the click rollup scheduler's last-run time
+// and watermark lag, for operators checking whether stats are up to date.
+type RollupStatusHandler struct {
+	scheduler *stats.Scheduler
+}
+
+func NewRollupStatusHandler(scheduler *stats.Scheduler) *RollupStatusHandler {
+	return &RollupStatusHandler{scheduler: scheduler}
+}
+
+func (h *RollupStatusHandler) Status(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.scheduler.Status())
+}
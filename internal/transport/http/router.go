@@ -7,7 +7,11 @@ import (
 
 	"github.com/IgorGrieder/encurtador-url/internal/config"
 	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/telemetry"
+	callerMiddleware "github.com/IgorGrieder/encurtador-url/internal/middleware"
 	"github.com/IgorGrieder/encurtador-url/internal/processing/links"
+	"github.com/IgorGrieder/encurtador-url/internal/processing/stats"
+	redisStorage "github.com/IgorGrieder/encurtador-url/internal/storage/redis"
+	graphqltransport "github.com/IgorGrieder/encurtador-url/internal/transport/graphql"
 	"github.com/IgorGrieder/encurtador-url/internal/transport/http/middleware"
 	"github.com/IgorGrieder/encurtador-url/pkg/httputils"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -18,7 +22,10 @@ var spanNames = map[string]string{
 	"GET /metrics":                "metrics",
 	"POST /api/links":             "links.create",
 	"GET /api/links/{slug}/stats": "links.stats",
+	"DELETE /api/links/{slug}":    "links.delete",
 	"GET /{slug}":                 "links.redirect",
+	"POST /graphql":               "graphql",
+	"GET /internal/rollup/status": "rollup.status",
 }
 
 type RouterOptions struct {
@@ -42,15 +49,36 @@ func DefaultRouterOptions() RouterOptions {
 	}
 }
 
-func NewRouter(cfg *config.Config, linkService *links.Service) http.Handler {
-	return NewRouterWithOptions(cfg, linkService, DefaultRouterOptions())
+func NewRouter(live *config.Live, linkService *links.Service, createLimiter redisStorage.Limiter) http.Handler {
+	return NewRouterWithOptions(live, linkService, createLimiter, nil, nil, nil, DefaultRouterOptions())
 }
 
-func NewRouterWithOptions(cfg *config.Config, linkService *links.Service, opts RouterOptions) http.Handler {
+// NewRouterWithOptions wires the full route table. apiKeyRepo and keyLimiter
+// back the caller-ownership/per-key-rate-limit checks on DELETE and stats
+// routes (see internal/middleware); either may be nil to leave those checks
+// disabled, e.g. when no API-key backend is configured. rollupScheduler, if
+// non-nil, exposes its status at GET /internal/rollup/status (see
+// internal/processing/stats).
+//
+// live is read once here for the auth/middleware setup below - JWT/API-key
+// config isn't hot-reloadable yet, since RequireAnyAuth bakes its JWKS URL
+// and key set into closures at router-build time. It's passed straight
+// through to the links handler, though, so ShortURL's base and the
+// redirect status code do pick up a later Config.Watch snapshot.
+func NewRouterWithOptions(
+	live *config.Live,
+	linkService *links.Service,
+	createLimiter redisStorage.Limiter,
+	apiKeyRepo callerMiddleware.APIKeyRepository,
+	keyLimiter callerMiddleware.Limiter,
+	rollupScheduler *stats.Scheduler,
+	opts RouterOptions,
+) http.Handler {
+	cfg := live.Current()
 	mux := http.NewServeMux()
 
 	healthHandler := NewHealthHandler()
-	linksHandler := NewLinksHandlerWithOptions(cfg, linkService, opts.LinksHandlerOptions)
+	linksHandler := NewLinksHandlerWithOptions(live, linkService, opts.LinksHandlerOptions)
 
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
 		httputils.RespondJSON(w, http.StatusOK, map[string]string{
@@ -60,16 +88,61 @@ func NewRouterWithOptions(cfg *config.Config, linkService *links.Service, opts R
 	})
 	mux.Handle("GET /metrics", healthHandler.Metrics())
 
+	requireAuth := middleware.RequireAnyAuth(
+		middleware.JWTMiddlewareOptions{
+			JWKSURL:    cfg.Security.JWT.JWKSURL,
+			Algorithms: []string{"RS256", "ES256"},
+		},
+		toMiddlewareAPIKeys(cfg.Security.APIKeys),
+	)
+
 	createMiddlewares := []func(http.Handler) http.Handler{
-		middleware.APIKeyMiddleware(cfg.Security.APIKeys),
+		requireAuth,
+		middleware.RequireScope("links:create"),
+		middleware.StrategyRateLimiter(createLimiter),
 	}
-
 	mux.Handle("POST /api/links", middleware.Chain(
 		http.HandlerFunc(linksHandler.Create),
 		createMiddlewares...,
 	))
 
-	mux.HandleFunc("GET /api/links/{slug}/stats", linksHandler.Stats)
+	var ownershipMiddlewares []func(http.Handler) http.Handler
+	if apiKeyRepo != nil {
+		ownershipMiddlewares = append(ownershipMiddlewares, callerMiddleware.AuthMiddleware(apiKeyRepo))
+	}
+	if keyLimiter != nil {
+		ownershipMiddlewares = append(ownershipMiddlewares, callerMiddleware.KeyRateLimitMiddleware(keyLimiter))
+	}
+
+	statsMiddlewares := append([]func(http.Handler) http.Handler{
+		requireAuth,
+		middleware.RequireScope("links:stats"),
+	}, ownershipMiddlewares...)
+	mux.Handle("GET /api/links/{slug}/stats", middleware.Chain(
+		http.HandlerFunc(linksHandler.Stats),
+		statsMiddlewares...,
+	))
+
+	deleteMiddlewares := append([]func(http.Handler) http.Handler{
+		requireAuth,
+		middleware.RequireScope("links:delete"),
+	}, ownershipMiddlewares...)
+	mux.Handle("DELETE /api/links/{slug}", middleware.Chain(
+		http.HandlerFunc(linksHandler.Delete),
+		deleteMiddlewares...,
+	))
+
+	graphqlMiddlewares := []func(http.Handler) http.Handler{requireAuth}
+	mux.Handle("POST /graphql", middleware.Chain(
+		graphqltransport.NewHandler(linkService),
+		graphqlMiddlewares...,
+	))
+
+	if rollupScheduler != nil {
+		rollupHandler := NewRollupStatusHandler(rollupScheduler)
+		mux.HandleFunc("GET /internal/rollup/status", rollupHandler.Status)
+	}
+
 	mux.HandleFunc("GET /{slug}", linksHandler.Redirect)
 
 	var innerHandler http.Handler = mux
@@ -82,6 +155,8 @@ func NewRouterWithOptions(cfg *config.Config, linkService *links.Service, opts R
 	if opts.EnableMetrics {
 		innerHandler = middleware.MetricsMiddleware(innerHandler)
 	}
+	innerHandler = middleware.RequestLoggerMiddleware(innerHandler)
+	innerHandler = middleware.RequestIDMiddleware(innerHandler)
 
 	otelOptions := []otelhttp.Option{
 		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
@@ -106,3 +181,15 @@ func NewRouterWithOptions(cfg *config.Config, linkService *links.Service, opts R
 
 	return otelhttp.NewHandler(innerHandler, cfg.App.Name, otelOptions...)
 }
+
+// toMiddlewareAPIKeys adapts config.APIKeyConfig (plain config data) to
+// middleware.APIKeyConfig, keeping the middleware package independent of
+// internal/config the same way internal/storage/redis takes its own
+// SluggerStrategyConfig instead of importing config directly.
+func toMiddlewareAPIKeys(keys []config.APIKeyConfig) []middleware.APIKeyConfig {
+	out := make([]middleware.APIKeyConfig, len(keys))
+	for i, k := range keys {
+		out[i] = middleware.APIKeyConfig{Key: k.Key, Scopes: k.Scopes}
+	}
+	return out
+}
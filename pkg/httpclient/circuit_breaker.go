@@ -15,25 +15,177 @@ const (
 	StateHalfOpen
 )
 
-var ErrCircuitOpen = errors.New("circuit breaker is open")
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	ErrCircuitOpen  = errors.New("circuit breaker is open")
+	ErrHalfOpenBusy = errors.New("circuit breaker: half-open probe slots exhausted")
+)
+
+// CircuitBreakerConfig parameterizes a CircuitBreaker (and every per-host
+// breaker a BreakerRegistry hands out).
+type CircuitBreakerConfig struct {
+	// WindowDuration is how far back the rolling failure ratio looks.
+	WindowDuration time.Duration
+	// BucketCount divides WindowDuration into buckets; older buckets are
+	// dropped wholesale as time advances instead of decaying a single counter.
+	BucketCount int
+	// MinRequestVolume is the minimum number of requests observed in the
+	// window before the failure ratio is evaluated at all, so one failed
+	// request out of one doesn't trip the breaker.
+	MinRequestVolume int
+	// FailureRatio is the fraction of failures+timeouts over total requests
+	// in the window that trips the breaker from closed to open.
+	FailureRatio float64
+	// OpenTimeout is how long the breaker stays open before allowing
+	// half-open probes.
+	OpenTimeout time.Duration
+	// HalfOpenMaxProbes is how many requests may be in flight concurrently
+	// while half-open; everything beyond that is rejected immediately.
+	HalfOpenMaxProbes int
+	// HalfOpenSuccessToClose is how many of those probes must succeed
+	// (without an intervening failure) before the breaker closes.
+	HalfOpenSuccessToClose int
+	// OnStateChange, if set, is invoked (from under the breaker's lock's
+	// caller, i.e. asynchronously-safe) whenever the breaker transitions.
+	OnStateChange func(host string, from, to State)
+}
 
+// DefaultCircuitBreakerConfig returns sane defaults for a shared outbound
+// HTTP client.
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		WindowDuration:         30 * time.Second,
+		BucketCount:            10,
+		MinRequestVolume:       10,
+		FailureRatio:           0.5,
+		OpenTimeout:            10 * time.Second,
+		HalfOpenMaxProbes:      3,
+		HalfOpenSuccessToClose: 3,
+	}
+}
+
+type bucket struct {
+	start     time.Time
+	successes int
+	failures  int
+	timeouts  int
+}
+
+// CircuitBreaker trips based on the failure ratio observed over a rolling
+// time window (bucketed so old outcomes age out in O(1) instead of decaying
+// a single counter), rather than a raw consecutive-failure count. While
+// open it periodically allows a bounded number of concurrent half-open
+// probes through, closing once enough of them succeed.
 type CircuitBreaker struct {
-	mu          sync.Mutex
-	state       State
-	failures    int
-	maxFailures int
-	openSince   time.Time
-	openTimeout time.Duration
+	host string
+	cfg  CircuitBreakerConfig
+
+	mu               sync.Mutex
+	state            State
+	buckets          []bucket
+	openSince        time.Time
+	halfOpenInFlight int
+	halfOpenSuccess  int
+}
+
+func newCircuitBreaker(host string, cfg CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.WindowDuration <= 0 {
+		cfg.WindowDuration = 30 * time.Second
+	}
+	if cfg.BucketCount <= 0 {
+		cfg.BucketCount = 10
+	}
+	if cfg.MinRequestVolume <= 0 {
+		cfg.MinRequestVolume = 10
+	}
+	if cfg.FailureRatio <= 0 {
+		cfg.FailureRatio = 0.5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 10 * time.Second
+	}
+	if cfg.HalfOpenMaxProbes <= 0 {
+		cfg.HalfOpenMaxProbes = 1
+	}
+	if cfg.HalfOpenSuccessToClose <= 0 {
+		cfg.HalfOpenSuccessToClose = 1
+	}
+
+	cb := &CircuitBreaker{
+		host:    host,
+		cfg:     cfg,
+		state:   StateClosed,
+		buckets: make([]bucket, cfg.BucketCount),
+	}
+	breakerState.WithLabelValues(host).Set(float64(StateClosed))
+	return cb
 }
 
-func NewCircuitBreaker(maxFailures int, openTimeout time.Duration) *CircuitBreaker {
-	return &CircuitBreaker{
-		state:       StateClosed,
-		maxFailures: maxFailures,
-		openTimeout: openTimeout,
+// NewCircuitBreaker builds a single, host-agnostic breaker. Most callers
+// going through Client should prefer a BreakerRegistry so one flaky host
+// can't trip the breaker for every other destination.
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	return newCircuitBreaker("", cfg)
+}
+
+func (cb *CircuitBreaker) bucketWidth() time.Duration {
+	return cb.cfg.WindowDuration / time.Duration(len(cb.buckets))
+}
+
+// currentBucket returns the bucket for "now", resetting any buckets that
+// have aged out of the window since they were last touched.
+func (cb *CircuitBreaker) currentBucket(now time.Time) *bucket {
+	width := cb.bucketWidth()
+	idx := int((now.UnixNano() / int64(width)) % int64(len(cb.buckets)))
+	b := &cb.buckets[idx]
+	if now.Sub(b.start) >= cb.cfg.WindowDuration || b.start.IsZero() {
+		*b = bucket{start: now}
+	}
+	return b
+}
+
+// windowCounts sums every non-stale bucket in the window.
+func (cb *CircuitBreaker) windowCounts(now time.Time) (successes, failures, timeouts int) {
+	for i := range cb.buckets {
+		b := &cb.buckets[i]
+		if b.start.IsZero() || now.Sub(b.start) >= cb.cfg.WindowDuration {
+			continue
+		}
+		successes += b.successes
+		failures += b.failures
+		timeouts += b.timeouts
 	}
+	return
 }
 
+func (cb *CircuitBreaker) transition(to State) {
+	from := cb.state
+	if from == to {
+		return
+	}
+	cb.state = to
+	breakerState.WithLabelValues(cb.host).Set(float64(to))
+	slog.Info("Circuit breaker state change", "host", cb.host, "from", from.String(), "to", to.String())
+	if cb.cfg.OnStateChange != nil {
+		cb.cfg.OnStateChange(cb.host, from, to)
+	}
+}
+
+// CheckBeforeRequest reports whether a request may proceed. Closed always
+// allows it; open allows it only after OpenTimeout has elapsed (moving to
+// half-open); half-open allows up to HalfOpenMaxProbes concurrent callers.
 func (cb *CircuitBreaker) CheckBeforeRequest() error {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
@@ -43,52 +195,134 @@ func (cb *CircuitBreaker) CheckBeforeRequest() error {
 		return nil
 
 	case StateOpen:
-		if time.Since(cb.openSince) > cb.openTimeout {
-			slog.Warn("Circuit Breaker: Open -> Half-Open")
-			cb.state = StateHalfOpen
-			return nil
+		if time.Since(cb.openSince) < cb.cfg.OpenTimeout {
+			return ErrCircuitOpen
 		}
-		return ErrCircuitOpen
+		cb.transition(StateHalfOpen)
+		cb.halfOpenInFlight = 0
+		cb.halfOpenSuccess = 0
+		fallthrough
 
 	case StateHalfOpen:
-		return ErrCircuitOpen
+		if cb.halfOpenInFlight >= cb.cfg.HalfOpenMaxProbes {
+			return ErrHalfOpenBusy
+		}
+		cb.halfOpenInFlight++
+		return nil
 	}
 	return nil
 }
 
 func (cb *CircuitBreaker) OnSuccess() {
+	now := time.Now()
+
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
+	cb.currentBucket(now).successes++
+
 	switch cb.state {
 	case StateHalfOpen:
-		slog.Info("Circuit Breaker: Half-Open -> Closed")
-		cb.state = StateClosed
-		cb.failures = 0
+		cb.halfOpenInFlight--
+		cb.halfOpenSuccess++
+		if cb.halfOpenSuccess >= cb.cfg.HalfOpenSuccessToClose {
+			cb.transition(StateClosed)
+			cb.resetBuckets()
+		}
+		cb.updateFailureRatioGauge(now)
 
 	case StateClosed:
-		cb.failures = 0
+		cb.evaluateTrip(now)
 	}
 }
 
-func (cb *CircuitBreaker) OnFailure() {
+// OnFailure records a failed attempt. isTimeout distinguishes a timeout
+// from any other failure for the per-host metrics; both count toward the
+// failure ratio that trips the breaker.
+func (cb *CircuitBreaker) OnFailure(isTimeout bool) {
+	now := time.Now()
+
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
+	b := cb.currentBucket(now)
+	if isTimeout {
+		b.timeouts++
+	} else {
+		b.failures++
+	}
+
 	switch cb.state {
 	case StateHalfOpen:
-		slog.Error("Circuit Breaker: Half-Open -> Open (test failed)")
-		cb.state = StateOpen
-		cb.openSince = time.Now()
+		cb.halfOpenInFlight--
+		cb.transition(StateOpen)
+		cb.openSince = now
+		cb.updateFailureRatioGauge(now)
 
 	case StateClosed:
-		cb.failures++
-		slog.Warn("Circuit Breaker: Failure recorded", "count", cb.failures)
+		cb.evaluateTrip(now)
+	}
+}
 
-		if cb.failures >= cb.maxFailures {
-			slog.Error("Circuit Breaker: Closed -> Open (threshold reached)")
-			cb.state = StateOpen
-			cb.openSince = time.Now()
-		}
+// evaluateTrip opens the breaker once both the minimum request volume and
+// the failure ratio threshold are exceeded within the rolling window.
+func (cb *CircuitBreaker) evaluateTrip(now time.Time) {
+	successes, failures, timeouts := cb.windowCounts(now)
+	total := successes + failures + timeouts
+	cb.updateFailureRatioGauge(now)
+
+	if total < cb.cfg.MinRequestVolume {
+		return
+	}
+
+	ratio := float64(failures+timeouts) / float64(total)
+	if ratio >= cb.cfg.FailureRatio {
+		cb.transition(StateOpen)
+		cb.openSince = now
+	}
+}
+
+func (cb *CircuitBreaker) updateFailureRatioGauge(now time.Time) {
+	successes, failures, timeouts := cb.windowCounts(now)
+	total := successes + failures + timeouts
+	if total == 0 {
+		breakerFailureRatio.WithLabelValues(cb.host).Set(0)
+		return
+	}
+	breakerFailureRatio.WithLabelValues(cb.host).Set(float64(failures+timeouts) / float64(total))
+}
+
+func (cb *CircuitBreaker) resetBuckets() {
+	for i := range cb.buckets {
+		cb.buckets[i] = bucket{}
+	}
+}
+
+// BreakerRegistry hands out one CircuitBreaker per request host, so a flaky
+// third party trips its own breaker without blocking requests to every
+// other destination the shared Client talks to.
+type BreakerRegistry struct {
+	mu       sync.Mutex
+	cfg      CircuitBreakerConfig
+	breakers map[string]*CircuitBreaker
+}
+
+func NewBreakerRegistry(cfg CircuitBreakerConfig) *BreakerRegistry {
+	return &BreakerRegistry{
+		cfg:      cfg,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// ForHost returns the breaker for host, creating it on first use.
+func (r *BreakerRegistry) ForHost(host string) *CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cb, ok := r.breakers[host]
+	if !ok {
+		cb = newCircuitBreaker(host, r.cfg)
+		r.breakers[host] = cb
 	}
+	return cb
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -15,19 +16,24 @@ import (
 )
 
 type Client struct {
-	client *http.Client
-	cb     *CircuitBreaker
+	client   *http.Client
+	breakers *BreakerRegistry
 }
 
-func NewClient(timeout time.Duration, maxFailures int, cbInterval time.Duration) *Client {
+func NewClient(timeout time.Duration, cbCfg CircuitBreakerConfig) *Client {
 	return &Client{
-		client: &http.Client{Timeout: timeout},
-		cb:     NewCircuitBreaker(maxFailures, cbInterval),
+		client:   &http.Client{Timeout: timeout},
+		breakers: NewBreakerRegistry(cbCfg),
 	}
 }
 
 func (c *Client) Get(ctx context.Context, baseURL string, queryParams map[string]string, headers map[string]string) (*http.Response, error) {
-	return c.attemptRequestWithRetry(ctx, func() (*http.Request, error) {
+	host, err := hostOf(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.attemptRequestWithRetry(ctx, host, func() (*http.Request, error) {
 		u, err := url.Parse(baseURL)
 		if err != nil {
 			return nil, err
@@ -47,12 +53,20 @@ func (c *Client) Get(ctx context.Context, baseURL string, queryParams map[string
 		for k, v := range headers {
 			req.Header.Set(k, v)
 		}
+		if id, ok := RequestIDFromContext(ctx); ok && id != "" {
+			req.Header.Set(RequestIDHeader, id)
+		}
 		return req, nil
 	})
 }
 
-func (c *Client) Post(ctx context.Context, url string, body any, headers map[string]string) (*http.Response, error) {
-	return c.attemptRequestWithRetry(ctx, func() (*http.Request, error) {
+func (c *Client) Post(ctx context.Context, targetURL string, body any, headers map[string]string) (*http.Response, error) {
+	host, err := hostOf(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.attemptRequestWithRetry(ctx, host, func() (*http.Request, error) {
 		var bodyReader io.Reader
 		if body != nil {
 			jsonData, err := json.Marshal(body)
@@ -62,7 +76,7 @@ func (c *Client) Post(ctx context.Context, url string, body any, headers map[str
 			bodyReader = bytes.NewBuffer(jsonData)
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bodyReader)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bodyReader)
 		if err != nil {
 			return nil, err
 		}
@@ -73,13 +87,26 @@ func (c *Client) Post(ctx context.Context, url string, body any, headers map[str
 		for k, v := range headers {
 			req.Header.Set(k, v)
 		}
+		if id, ok := RequestIDFromContext(ctx); ok && id != "" {
+			req.Header.Set(RequestIDHeader, id)
+		}
 		return req, nil
 	})
 }
 
-func (c *Client) attemptRequestWithRetry(ctx context.Context, reqFactory func() (*http.Request, error)) (*http.Response, error) {
-	if err := c.cb.CheckBeforeRequest(); err != nil {
-		slog.Error("Request blocked by circuit breaker", slog.String("error", err.Error()))
+func hostOf(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return u.Host, nil
+}
+
+func (c *Client) attemptRequestWithRetry(ctx context.Context, host string, reqFactory func() (*http.Request, error)) (*http.Response, error) {
+	cb := c.breakers.ForHost(host)
+
+	if err := cb.CheckBeforeRequest(); err != nil {
+		slog.Error("Request blocked by circuit breaker", slog.String("host", host), slog.String("error", err.Error()))
 		return nil, err
 	}
 
@@ -102,14 +129,30 @@ func (c *Client) attemptRequestWithRetry(ctx context.Context, reqFactory func()
 		lastErr = err
 
 		if err == nil && response.StatusCode < 500 {
-			c.cb.OnSuccess()
+			cb.OnSuccess()
 			return response, nil
 		}
 
+		isTimeout := errors.Is(err, context.DeadlineExceeded) || isNetTimeout(err)
+		cb.OnFailure(isTimeout)
+
 		if i == maxRetries {
 			break
 		}
 
+		// Once the breaker opens mid-retry (or its half-open probe slots
+		// fill up), further attempts would just be rejected locally anyway
+		// and would delay reporting the failure to the caller for nothing.
+		if checkErr := cb.CheckBeforeRequest(); checkErr != nil {
+			slog.Warn("Circuit breaker opened during retries, aborting early",
+				slog.String("host", host), slog.String("error", checkErr.Error()))
+			if response != nil {
+				response.Body.Close()
+			}
+			lastErr = checkErr
+			break
+		}
+
 		backoff := baseDelay * time.Duration(math.Pow(2, float64(i)))
 		jitter := time.Duration(r.Intn(maxJitterMs)) * time.Millisecond
 		sleepDuration := backoff + jitter
@@ -130,11 +173,14 @@ func (c *Client) attemptRequestWithRetry(ctx context.Context, reqFactory func()
 		}
 	}
 
-	c.cb.OnFailure()
-
 	if lastErr != nil {
-		return nil, fmt.Errorf("all retries failed, last network error: %w", lastErr)
+		return nil, fmt.Errorf("all retries failed, last error: %w", lastErr)
 	}
 
 	return nil, fmt.Errorf("all retries failed, last status: %s", response.Status)
 }
+
+func isNetTimeout(err error) bool {
+	var netErr interface{ Timeout() bool }
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
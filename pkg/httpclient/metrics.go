@@ -0,0 +1,24 @@
+package httpclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	breakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "http_client_circuit_breaker_state",
+			Help: "Circuit breaker state per host (1=closed, 2=open, 3=half_open).",
+		},
+		[]string{"host"},
+	)
+
+	breakerFailureRatio = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "http_client_circuit_breaker_failure_ratio",
+			Help: "Failure+timeout ratio over the rolling window per host.",
+		},
+		[]string{"host"},
+	)
+)
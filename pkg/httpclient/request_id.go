@@ -0,0 +1,23 @@
+package httpclient
+
+import "context"
+
+// RequestIDHeader is the header outbound requests forward the caller's
+// correlation id on, so a downstream service's logs and traces can be
+// stitched back to the request that triggered the call.
+const RequestIDHeader = "X-Request-Id"
+
+type requestIDContextKey struct{}
+
+// ContextWithRequestID attaches a request id to ctx for Client.Get/Post to
+// forward automatically on outbound requests made with that context.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the id attached by ContextWithRequestID, if
+// any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok
+}
@@ -7,9 +7,11 @@ import (
 	"time"
 
 	"github.com/IgorGrieder/encurtador-url/internal/constants"
+	"github.com/IgorGrieder/encurtador-url/internal/infrastructure/logger"
 	appvalidation "github.com/IgorGrieder/encurtador-url/internal/infrastructure/validation"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 const InternalServerErrMsg = "error processing the request, try again"
@@ -64,6 +66,11 @@ func WriteAPIResponse(w http.ResponseWriter, r *http.Request, status int, data a
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
+	logger.FromContext(r.Context()).Debug("api response",
+		zap.String("correlation_id", correlationID),
+		zap.Int("status", status),
+	)
+
 	response := APIResponse{
 		ResponseTime:  time.Now().UTC(),
 		CorrelationId: correlationID,
@@ -81,6 +88,12 @@ func WriteAPIError(w http.ResponseWriter, r *http.Request, apiErr constants.APIE
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(apiErr.Status)
 
+	logger.FromContext(r.Context()).Error("api error response",
+		zap.String("correlation_id", correlationID),
+		zap.String("code", apiErr.Code),
+		zap.Int("status", apiErr.Status),
+	)
+
 	response := APIResponse{
 		ResponseTime:  time.Now().UTC(),
 		CorrelationId: correlationID,